@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"net/http"
 	"os"
@@ -9,20 +10,26 @@ import (
 
 	"github.com/carlmjohnson/versioninfo"
 	"github.com/dustin/go-humanize"
+	"github.com/oursky/pageship/internal/accesslog"
+	"github.com/oursky/pageship/internal/analytics"
 	"github.com/oursky/pageship/internal/command"
 	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/cron"
 	"github.com/oursky/pageship/internal/db"
 	_ "github.com/oursky/pageship/internal/db/postgres"
 	_ "github.com/oursky/pageship/internal/db/sqlite"
+	"github.com/oursky/pageship/internal/dnsprovider"
 	domaindb "github.com/oursky/pageship/internal/domain/db"
 	"github.com/oursky/pageship/internal/handler/controller"
 	"github.com/oursky/pageship/internal/handler/site"
 	"github.com/oursky/pageship/internal/handler/site/middleware"
 	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/metrics"
 	sitedb "github.com/oursky/pageship/internal/site/db"
 	"github.com/oursky/pageship/internal/storage"
+	"github.com/oursky/pageship/internal/tracing"
 	"github.com/oursky/pageship/internal/watch"
+	"github.com/oursky/pageship/internal/webhook"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -43,58 +50,140 @@ func init() {
 
 	startCmd.PersistentFlags().Bool("migrate", false, "migrate before starting")
 	startCmd.PersistentFlags().String("addr", ":8001", "listen address")
+	startCmd.PersistentFlags().String("metrics-addr", "", "metrics listen address; disabled if empty")
+	startCmd.PersistentFlags().String("tracing-otlp-endpoint", "", "OTLP/HTTP endpoint URL to export traces; disabled if empty")
+	startCmd.PersistentFlags().Float64("tracing-sample-ratio", 1, "ratio of sampled traces")
 
 	startCmd.PersistentFlags().Bool("tls", false, "use TLS")
 	startCmd.PersistentFlags().String("tls-addr", ":443", "TLS listen address")
 	startCmd.PersistentFlags().String("tls-acme-endpoint", "", "TLS ACME directory endpoint")
 	startCmd.PersistentFlags().String("tls-acme-email", "", "TLS ACME directory account email")
 	startCmd.PersistentFlags().String("tls-protect-key", "", "TLS data protection key")
+	startCmd.PersistentFlags().String("tls-acme-eab-key-id", "", "TLS ACME external account binding key ID")
+	startCmd.PersistentFlags().String("tls-acme-eab-hmac-key", "", "TLS ACME external account binding HMAC key")
+	startCmd.PersistentFlags().StringSlice("tls-acme-challenges", httputil.DefaultACMEChallenges, "TLS ACME challenge types")
+	startCmd.PersistentFlags().String("tls-dns-provider", "", "TLS DNS provider URL for DNS-01 challenge")
+	startCmd.PersistentFlags().Bool("http3", false, "serve HTTP/3 on TLS address")
+	startCmd.PersistentFlags().String("tls-min-version", "", "minimum TLS version")
+	startCmd.PersistentFlags().StringSlice("tls-ciphers", nil, "allowed TLS cipher suites")
+	startCmd.PersistentFlags().String("tls-client-auth", "none", "TLS client certificate requirement")
+	startCmd.PersistentFlags().String("tls-client-ca", "", "TLS client certificate CA file")
+	startCmd.PersistentFlags().Duration("hsts-max-age", 0, "HSTS max age")
+
+	startCmd.PersistentFlags().String("controller-addr", "", "controller listen address")
+	startCmd.PersistentFlags().String("controller-tls-addr", "", "controller TLS listen address")
+	startCmd.PersistentFlags().String("controller-tls-min-version", "", "controller minimum TLS version")
+	startCmd.PersistentFlags().StringSlice("controller-tls-ciphers", nil, "controller allowed TLS cipher suites")
+	startCmd.PersistentFlags().String("controller-tls-client-auth", "none", "controller TLS client certificate requirement")
+	startCmd.PersistentFlags().String("controller-tls-client-ca", "", "controller TLS client certificate CA file")
+	startCmd.PersistentFlags().Duration("controller-hsts-max-age", 0, "controller HSTS max age")
 
 	startCmd.PersistentFlags().String("max-deployment-size", "200M", "max deployment files size")
 	startCmd.PersistentFlags().String("storage-key-prefix", "", "storage key prefix")
 	startCmd.PersistentFlags().String("host-pattern", config.DefaultHostPattern, "host match pattern")
 	startCmd.PersistentFlags().String("host-id-scheme", string(config.HostIDSchemeDefault), "host ID scheme")
+	startCmd.PersistentFlags().Bool("analytics", false, "collect visitor analytics of sites")
+	startCmd.PersistentFlags().Duration("analytics-flush-interval", time.Minute, "interval to flush collected analytics to database")
+	startCmd.PersistentFlags().String("access-log-export", "", "format of access logs exported to object storage (json/common); disabled if empty")
+	startCmd.PersistentFlags().Duration("access-log-flush-interval", time.Minute, "interval to flush exported access logs to object storage")
 	startCmd.PersistentFlags().StringSlice("reserved-apps", []string{defaultControllerHostID}, "reserved app IDs")
 	startCmd.PersistentFlags().String("api-acl", "", "API ACL file")
+	startCmd.PersistentFlags().String("admin-acl", "", "server admin ACL file")
 
 	startCmd.PersistentFlags().String("token-authority", "pageship", "auth token authority")
 	startCmd.PersistentFlags().String("token-signing-key", "", "auth token signing key")
 
 	startCmd.PersistentFlags().String("custom-domain-message", "", "message for custom domain users")
 
+	startCmd.PersistentFlags().Int("webhook-max-attempts", 8, "max delivery attempts of webhooks")
+	startCmd.PersistentFlags().Duration("webhook-delivery-retention", time.Hour*24*30, "retention of webhook delivery log; kept forever if zero")
+	startCmd.PersistentFlags().Bool("webhook-allow-private-network", false, "allow webhooks to private network addresses")
+
 	startCmd.PersistentFlags().String("cleanup-expired-crontab", "", "cleanup expired schedule")
 	startCmd.PersistentFlags().Duration("keep-after-expired", time.Hour*24, "keep-after-expired")
+	startCmd.PersistentFlags().String("cert-monitor-crontab", "@hourly", "certificate expiry monitor schedule")
+	startCmd.PersistentFlags().Duration("cert-renew-before", time.Hour*24*14, "retry renewal of certificates expiring within the duration")
 
 	startCmd.PersistentFlags().Bool("controller", true, "run controller server")
 	startCmd.PersistentFlags().Bool("cron", true, "run cron jobs")
 	startCmd.PersistentFlags().Bool("sites", true, "run sites server")
 	startCmd.PersistentFlags().String("controller-domain", "", "controller domain")
+	startCmd.PersistentFlags().String("controller-url", "", "public controller URL; derived from controller domain and host pattern if empty")
 }
 
 type StartConfig struct {
 	DatabaseURL string `mapstructure:"database-url" validate:"url"`
 	StorageURL  string `mapstructure:"storage-url" validate:"url"`
 	Addr        string `mapstructure:"addr" validate:"hostname_port"`
-
-	TLS             bool   `mapstructure:"tls"`
-	TLSAddr         string `mapstructure:"tls-addr" validate:"hostname_port"`
-	TLSACMEEndpoint string `mapstructure:"tls-acme-endpoint"`
-	TLSACMEEmail    string `mapstructure:"tls-acme-email"`
-	TLSProtectKey   string `mapstructure:"tls-protect-key"`
+	MetricsAddr string `mapstructure:"metrics-addr" validate:"omitempty,hostname_port"`
+
+	TracingOTLPEndpoint string  `mapstructure:"tracing-otlp-endpoint" validate:"omitempty,url"`
+	TracingSampleRatio  float64 `mapstructure:"tracing-sample-ratio" validate:"min=0,max=1"`
+
+	TLS               bool          `mapstructure:"tls"`
+	TLSAddr           string        `mapstructure:"tls-addr" validate:"hostname_port"`
+	TLSACMEEndpoint   string        `mapstructure:"tls-acme-endpoint"`
+	TLSACMEEmail      string        `mapstructure:"tls-acme-email"`
+	TLSProtectKey     string        `mapstructure:"tls-protect-key"`
+	TLSACMEEABKeyID   string        `mapstructure:"tls-acme-eab-key-id"`
+	TLSACMEEABHMACKey string        `mapstructure:"tls-acme-eab-hmac-key" validate:"required_with=TLSACMEEABKeyID"`
+	TLSACMEChallenges []string      `mapstructure:"tls-acme-challenges" validate:"dive,oneof=http-01 tls-alpn-01 dns-01"`
+	TLSDNSProvider    string        `mapstructure:"tls-dns-provider" validate:"omitempty,url"`
+	HTTP3             bool          `mapstructure:"http3"`
+	TLSMinVersion     string        `mapstructure:"tls-min-version" validate:"omitempty,oneof=1.0 1.1 1.2 1.3"`
+	TLSCiphers        []string      `mapstructure:"tls-ciphers"`
+	TLSClientAuth     string        `mapstructure:"tls-client-auth" validate:"oneof=none optional require"`
+	TLSClientCA       string        `mapstructure:"tls-client-ca" validate:"omitempty,filepath"`
+	HSTSMaxAge        time.Duration `mapstructure:"hsts-max-age" validate:"min=0"`
+
+	ControllerAddr          string        `mapstructure:"controller-addr" validate:"omitempty,hostname_port"`
+	ControllerTLSAddr       string        `mapstructure:"controller-tls-addr" validate:"omitempty,hostname_port"`
+	ControllerTLSMinVersion string        `mapstructure:"controller-tls-min-version" validate:"omitempty,oneof=1.0 1.1 1.2 1.3"`
+	ControllerTLSCiphers    []string      `mapstructure:"controller-tls-ciphers"`
+	ControllerTLSClientAuth string        `mapstructure:"controller-tls-client-auth" validate:"oneof=none optional require"`
+	ControllerTLSClientCA   string        `mapstructure:"controller-tls-client-ca" validate:"omitempty,filepath"`
+	ControllerHSTSMaxAge    time.Duration `mapstructure:"controller-hsts-max-age" validate:"min=0"`
 
 	Controller       bool   `mapstructure:"controller"`
 	Cron             bool   `mapstructure:"cron"`
 	Sites            bool   `mapstructure:"sites"`
 	ControllerDomain string `mapstructure:"controller-domain" validate:"omitempty,hostname_rfc1123"`
+	ControllerURL    string `mapstructure:"controller-url" validate:"omitempty,url"`
 
 	StartSitesConfig      `mapstructure:",squash"`
 	StartControllerConfig `mapstructure:",squash"`
 	StartCronConfig       `mapstructure:",squash"`
 }
 
+// checkTLS rejects TLS options that would be silently ignored.
+func (c StartConfig) checkTLS() error {
+	if c.HTTP3 && !c.TLS {
+		return errors.New("HTTP/3 requires TLS")
+	}
+
+	if c.ControllerAddr == "" {
+		hasControllerTLS := c.ControllerTLSAddr != "" ||
+			c.ControllerTLSMinVersion != "" ||
+			len(c.ControllerTLSCiphers) > 0 ||
+			(c.ControllerTLSClientAuth != "" && c.ControllerTLSClientAuth != "none") ||
+			c.ControllerTLSClientCA != "" ||
+			c.ControllerHSTSMaxAge != 0
+		if hasControllerTLS {
+			return errors.New("controller TLS options require controller address")
+		}
+	}
+	return nil
+}
+
 type StartSitesConfig struct {
 	HostPattern  string              `mapstructure:"host-pattern"`
 	HostIDScheme config.HostIDScheme `mapstructure:"host-id-scheme" validate:"hostidscheme"`
+
+	Analytics              bool          `mapstructure:"analytics"`
+	AnalyticsFlushInterval time.Duration `mapstructure:"analytics-flush-interval" validate:"min=1s"`
+
+	AccessLogExport        string        `mapstructure:"access-log-export" validate:"omitempty,oneof=json common"`
+	AccessLogFlushInterval time.Duration `mapstructure:"access-log-flush-interval" validate:"min=1s"`
 }
 
 type StartControllerConfig struct {
@@ -104,20 +193,62 @@ type StartControllerConfig struct {
 	TokenAuthority    string   `mapstructure:"token-authority"`
 	ReservedApps      []string `mapstructure:"reserved-apps"`
 	APIACLFile        string   `mapstructure:"api-acl" validate:"omitempty,filepath"`
+	AdminACLFile      string   `mapstructure:"admin-acl" validate:"omitempty,filepath"`
 
 	CustomDomainMessage string `mapstructure:"custom-domain-message"`
+
+	WebhookMaxAttempts         int           `mapstructure:"webhook-max-attempts" validate:"min=1"`
+	WebhookDeliveryRetention   time.Duration `mapstructure:"webhook-delivery-retention" validate:"min=0"`
+	WebhookAllowPrivateNetwork bool          `mapstructure:"webhook-allow-private-network"`
 }
 
 type StartCronConfig struct {
 	CleanupExpiredCrontab string        `mapstructure:"cleanup-expired-crontab" validate:"omitempty,cron"`
 	KeepAfterExpired      time.Duration `mapstructure:"keep-after-expired" validate:"min=0"`
+	CertMonitorCrontab    string        `mapstructure:"cert-monitor-crontab" validate:"omitempty,cron"`
+	CertRenewBefore       time.Duration `mapstructure:"cert-renew-before" validate:"min=0"`
+}
+
+// tlsListenerConfig is the TLS settings specific to a listener.
+type tlsListenerConfig struct {
+	Addr       string
+	MinVersion string
+	Ciphers    []string
+	ClientAuth string
+	ClientCA   string
+	HSTSMaxAge time.Duration
+}
+
+func (c tlsListenerConfig) apply(conf *httputil.ServerTLSConfig) error {
+	var err error
+	conf.Addr = c.Addr
+	conf.HSTSMaxAge = c.HSTSMaxAge
+	if conf.MinVersion, err = httputil.ParseTLSVersion(c.MinVersion); err != nil {
+		return err
+	}
+	if conf.CipherSuites, err = httputil.ParseCipherSuites(c.Ciphers); err != nil {
+		return err
+	}
+	if conf.ClientAuth, err = httputil.ParseClientAuth(c.ClientAuth); err != nil {
+		return err
+	}
+	if c.ClientCA != "" {
+		if conf.ClientCAs, err = httputil.LoadCertPool(c.ClientCA); err != nil {
+			return err
+		}
+	} else if conf.ClientAuth != tls.NoClientCert {
+		return errors.New("client certificate CA file is required")
+	}
+	return nil
 }
 
 type setup struct {
 	ctx              context.Context
 	database         db.DB
 	storage          *storage.Storage
+	storageKeyPrefix string
 	server           *httputil.Server
+	controllerServer *httputil.Server
 	mux              *http.ServeMux
 	works            []command.WorkFunc
 	checkDomainFuncs []func(name string) error
@@ -143,6 +274,28 @@ func (s *setup) sites(conf StartSitesConfig) error {
 		DB:           s.database,
 		Storage:      s.storage,
 	}
+	var collector *analytics.Collector
+	if conf.Analytics {
+		collector = &analytics.Collector{
+			Logger:        logger.Named("analytics"),
+			DB:            s.database,
+			FlushInterval: conf.AnalyticsFlushInterval,
+		}
+		s.works = append(s.works, collector.Run)
+	}
+
+	var accessLog *accesslog.Exporter
+	if conf.AccessLogExport != "" {
+		accessLog = &accesslog.Exporter{
+			Logger:        logger.Named("access-log"),
+			Storage:       s.storage,
+			KeyPrefix:     s.storageKeyPrefix,
+			Format:        accesslog.Format(conf.AccessLogExport),
+			FlushInterval: conf.AccessLogFlushInterval,
+		}
+		s.works = append(s.works, accessLog.Run)
+	}
+
 	handler, err := site.NewHandler(
 		s.ctx,
 		logger.Named("site"),
@@ -151,6 +304,9 @@ func (s *setup) sites(conf StartSitesConfig) error {
 		site.HandlerConfig{
 			HostPattern: conf.HostPattern,
 			Middlewares: middleware.Default,
+			AltSvc:      s.server.TLS.AltSvc(),
+			Analytics:   collector,
+			AccessLog:   accessLog,
 		},
 	)
 	if err != nil {
@@ -159,10 +315,46 @@ func (s *setup) sites(conf StartSitesConfig) error {
 
 	s.mux.Handle("/", handler)
 	s.checkDomainFuncs = append(s.checkDomainFuncs, handler.CheckValidDomain)
+	if s.server.TLS != nil {
+		s.server.TLS.WildcardDomains = domainResolver.WildcardDomains
+	}
 	return nil
 }
 
-func (s *setup) controller(domain string, conf StartControllerConfig, sitesConf StartSitesConfig) error {
+func (s *setup) watchACL(name string, path string) (*watch.File[config.ACL], error) {
+	aclLog := logger.Named(name)
+	acl, err := watch.NewFile(
+		aclLog,
+		path,
+		func(path string) (config.ACL, error) {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+
+			list, err := config.LoadACL(f)
+			if err != nil {
+				return nil, err
+			}
+
+			aclLog.Info("loaded ACL", zap.Int("count", len(list)))
+			return list, nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.works = append(s.works, func(ctx context.Context) error {
+		<-ctx.Done()
+		acl.Close()
+		return nil
+	})
+	return acl, nil
+}
+
+func (s *setup) controller(domain string, controllerURL string, conf StartControllerConfig, sitesConf StartSitesConfig) error {
 	maxDeploymentSize, _ := humanize.ParseBytes(conf.MaxDeploymentSize)
 	tokenSigningKey := conf.TokenSigningKey
 	if tokenSigningKey == "" {
@@ -185,39 +377,33 @@ func (s *setup) controller(domain string, conf StartControllerConfig, sitesConf
 		TokenAuthority:      conf.TokenAuthority,
 		ServerVersion:       versioninfo.Short(),
 		CustomDomainMessage: conf.CustomDomainMessage,
-	}
+		ControllerURL:       controllerURL,
 
-	if conf.APIACLFile != "" {
-		aclLog := logger.Named("api-acl")
-		acl, err := watch.NewFile(
-			aclLog,
-			conf.APIACLFile,
-			func(path string) (config.ACL, error) {
-				f, err := os.Open(path)
-				if err != nil {
-					return nil, err
-				}
-				defer f.Close()
+		AccessLogFlushInterval: sitesConf.AccessLogFlushInterval,
+	}
 
-				list, err := config.LoadACL(f)
-				if err != nil {
-					return nil, err
-				}
+	tlsConf := s.server.TLS
+	if s.controllerServer != nil {
+		tlsConf = s.controllerServer.TLS
+	}
+	if tlsConf != nil && tlsConf.ClientAuth != tls.NoClientCert {
+		controllerConf.ClientCAs = tlsConf.ClientCAs
+	}
 
-				aclLog.Info("loaded ACL", zap.Int("count", len(list)))
-				return list, nil
-			},
-		)
+	if conf.APIACLFile != "" {
+		acl, err := s.watchACL("api-acl", conf.APIACLFile)
 		if err != nil {
 			return err
 		}
-
 		controllerConf.ACL = acl
-		s.works = append(s.works, func(ctx context.Context) error {
-			<-ctx.Done()
-			acl.Close()
-			return nil
-		})
+	}
+
+	if conf.AdminACLFile != "" {
+		acl, err := s.watchACL("admin-acl", conf.AdminACLFile)
+		if err != nil {
+			return err
+		}
+		controllerConf.AdminACL = acl
 	}
 
 	ctrl := &controller.Controller{
@@ -227,17 +413,41 @@ func (s *setup) controller(domain string, conf StartControllerConfig, sitesConf
 		Storage: s.storage,
 		DB:      s.database,
 	}
-
-	s.mux.Handle(domain+"/", ctrl.Handler())
 	if s.server.TLS != nil {
-		s.server.TLS.DomainNames = append(s.server.TLS.DomainNames, domain)
+		ctrl.CertStorage = s.server.TLS.Storage
+		ctrl.CertIssuerKey = s.server.TLS.IssuerKey()
+	}
+
+	dispatcher := &webhook.Dispatcher{
+		Logger:              logger.Named("webhook"),
+		DB:                  s.database,
+		MaxAttempts:         conf.WebhookMaxAttempts,
+		Retention:           conf.WebhookDeliveryRetention,
+		AllowPrivateNetwork: conf.WebhookAllowPrivateNetwork,
 	}
-	s.checkDomainFuncs = append(s.checkDomainFuncs, func(name string) error {
+	s.works = append(s.works, dispatcher.Run)
+
+	checkDomain := func(name string) error {
 		if name != domain {
 			return errUnknownDomain
 		}
 		return nil
-	})
+	}
+
+	if s.controllerServer != nil {
+		// Controller has dedicated listeners.
+		s.controllerServer.Handler = ctrl.Handler()
+		if s.controllerServer.TLS != nil {
+			s.controllerServer.TLS.DomainNames = []string{domain}
+			s.controllerServer.TLS.CheckDomain = checkDomain
+		}
+	} else {
+		s.mux.Handle(domain+"/", ctrl.Handler())
+		if s.server.TLS != nil {
+			s.server.TLS.DomainNames = append(s.server.TLS.DomainNames, domain)
+		}
+		s.checkDomainFuncs = append(s.checkDomainFuncs, checkDomain)
+	}
 
 	logger.Info("setup controller", zap.String("domain", domain))
 
@@ -252,14 +462,33 @@ func (s *setup) cron(conf StartCronConfig) error {
 				Schedule:         conf.CleanupExpiredCrontab,
 				KeepAfterExpired: conf.KeepAfterExpired,
 				DB:               s.database,
+				Storage:          s.storage,
 			},
 		},
 	}
 
+	if s.server.TLS != nil {
+		cronr.Jobs = append(cronr.Jobs, &cron.CertMonitor{
+			Schedule:    conf.CertMonitorCrontab,
+			RenewBefore: conf.CertRenewBefore,
+			DB:          s.database,
+			Storage:     s.server.TLS.Storage,
+			Renew:       s.renewCertificate,
+		})
+	}
+
 	s.works = append(s.works, cronr.Run)
 	return nil
 }
 
+func (s *setup) renewCertificate(ctx context.Context, domain string) error {
+	err := s.server.RenewCertificate(ctx, domain)
+	if errors.Is(err, httputil.ErrCertNotManaged) && s.controllerServer != nil {
+		err = s.controllerServer.RenewCertificate(ctx, domain)
+	}
+	return err
+}
+
 var startCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Start server",
@@ -285,6 +514,21 @@ var startCmd = &cobra.Command{
 			logger.Fatal("invalid config", zap.Error(err))
 			return
 		}
+		if err := cmdArgs.checkTLS(); err != nil {
+			logger.Fatal("invalid config", zap.Error(err))
+			return
+		}
+
+		shutdownTracing, err := tracing.Setup(cmd.Context(), tracing.Config{
+			Endpoint:    cmdArgs.TracingOTLPEndpoint,
+			ServiceName: "pageship",
+			SampleRatio: cmdArgs.TracingSampleRatio,
+			Version:     versioninfo.Short(),
+		})
+		if err != nil {
+			logger.Fatal("failed to setup tracing", zap.Error(err))
+			return
+		}
 
 		database, err := db.New(cmdArgs.DatabaseURL)
 		if err != nil {
@@ -302,10 +546,11 @@ var startCmd = &cobra.Command{
 		defer cancel()
 
 		setup := &setup{
-			ctx:      ctx,
-			database: database,
-			storage:  storage,
-			mux:      new(http.ServeMux),
+			ctx:              ctx,
+			database:         database,
+			storage:          storage,
+			storageKeyPrefix: cmdArgs.StorageKeyPrefix,
+			mux:              new(http.ServeMux),
 			server: &httputil.Server{
 				Logger: logger.Named("server"),
 				Addr:   cmdArgs.Addr,
@@ -314,28 +559,107 @@ var startCmd = &cobra.Command{
 		setup.server.Handler = setup.mux
 		setup.works = append(setup.works, setup.server.Run)
 
+		setup.works = append(setup.works, func(ctx context.Context) error {
+			<-ctx.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				logger.Warn("failed to shutdown tracing", zap.Error(err))
+			}
+			return nil
+		})
+
+		if cmdArgs.MetricsAddr != "" {
+			metricsServer := &metrics.Server{
+				Logger: logger.Named("metrics"),
+				Addr:   cmdArgs.MetricsAddr,
+			}
+			setup.works = append(setup.works, metricsServer.Run)
+		}
+
+		if cmdArgs.Controller && cmdArgs.ControllerAddr != "" {
+			setup.controllerServer = &httputil.Server{
+				Logger: logger.Named("controller-server"),
+				Addr:   cmdArgs.ControllerAddr,
+			}
+			setup.works = append(setup.works, setup.controllerServer.Run)
+		}
+
 		if cmdArgs.TLS {
 			if cmdArgs.TLSProtectKey == "" {
 				logger.Warn("TLS protect key not specified; certificate private keys would be stored in plain text.")
 			}
 
-			setup.server.TLS = &httputil.ServerTLSConfig{
-				Storage:       db.NewCertStorage(database, cmdArgs.TLSProtectKey),
-				ACMEDirectory: cmdArgs.TLSACMEEndpoint,
-				ACMEEmail:     cmdArgs.TLSACMEEmail,
-				Addr:          cmdArgs.TLSAddr,
-				CheckDomain:   setup.checkDomain,
+			tlsConf := httputil.ServerTLSConfig{
+				Storage:        db.NewCertStorage(database, cmdArgs.TLSProtectKey),
+				ACMEDirectory:  cmdArgs.TLSACMEEndpoint,
+				ACMEEmail:      cmdArgs.TLSACMEEmail,
+				ACMEEABKeyID:   cmdArgs.TLSACMEEABKeyID,
+				ACMEEABHMACKey: cmdArgs.TLSACMEEABHMACKey,
+				ACMEChallenges: cmdArgs.TLSACMEChallenges,
+			}
+
+			if cmdArgs.TLSDNSProvider != "" {
+				provider, err := dnsprovider.New(cmdArgs.TLSDNSProvider)
+				if err != nil {
+					logger.Fatal("failed to setup DNS provider", zap.Error(err))
+					return
+				}
+				tlsConf.DNSProvider = provider
+			}
+
+			sitesTLS := tlsConf
+			sitesTLS.CheckDomain = setup.checkDomain
+			sitesTLS.HTTP3 = cmdArgs.HTTP3
+			err := tlsListenerConfig{
+				Addr:       cmdArgs.TLSAddr,
+				MinVersion: cmdArgs.TLSMinVersion,
+				Ciphers:    cmdArgs.TLSCiphers,
+				ClientAuth: cmdArgs.TLSClientAuth,
+				ClientCA:   cmdArgs.TLSClientCA,
+				HSTSMaxAge: cmdArgs.HSTSMaxAge,
+			}.apply(&sitesTLS)
+			if err != nil {
+				logger.Fatal("invalid TLS config", zap.Error(err))
+				return
+			}
+			setup.server.TLS = &sitesTLS
+
+			if setup.controllerServer != nil {
+				if cmdArgs.ControllerTLSAddr == "" {
+					logger.Fatal("invalid config", zap.Error(errors.New("controller TLS address is required")))
+					return
+				}
+
+				controllerTLS := tlsConf
+				err := tlsListenerConfig{
+					Addr:       cmdArgs.ControllerTLSAddr,
+					MinVersion: cmdArgs.ControllerTLSMinVersion,
+					Ciphers:    cmdArgs.ControllerTLSCiphers,
+					ClientAuth: cmdArgs.ControllerTLSClientAuth,
+					ClientCA:   cmdArgs.ControllerTLSClientCA,
+					HSTSMaxAge: cmdArgs.ControllerHSTSMaxAge,
+				}.apply(&controllerTLS)
+				if err != nil {
+					logger.Fatal("invalid controller TLS config", zap.Error(err))
+					return
+				}
+				setup.controllerServer.TLS = &controllerTLS
 			}
 		}
 
 		if cmdArgs.Controller {
+			pattern := config.NewHostPattern(cmdArgs.HostPattern)
 			domain := cmdArgs.ControllerDomain
 			if domain == "" {
-				pattern := config.NewHostPattern(cmdArgs.HostPattern)
 				domain = pattern.MakeDomain(cmdArgs.HostIDScheme.Make(defaultControllerHostID, ""))
 			}
+			controllerURL := cmdArgs.ControllerURL
+			if controllerURL == "" {
+				controllerURL = pattern.LeadingScheme + domain + pattern.TrailingPort
+			}
 
-			if err := setup.controller(domain,
+			if err := setup.controller(domain, controllerURL,
 				cmdArgs.StartControllerConfig,
 				cmdArgs.StartSitesConfig,
 			); err != nil {
@@ -148,11 +148,13 @@ var appsConfigureCmd = &cobra.Command{
 		}
 
 		for _, dconf := range conf.App.Domains {
-			if _, exists := oldConfig.ResolveDomain(dconf.Domain); !exists {
-				Info("Activating custom domain %q...", dconf.Domain)
-				_, err = API().CreateDomain(cmd.Context(), app.ID, dconf.Domain, "")
-				if err != nil {
-					Warn("Activation of custom domain %q failed: %s", dconf.Domain, err)
+			for _, domain := range append([]string{dconf.Domain}, dconf.Aliases...) {
+				if _, exists := oldConfig.ResolveDomain(domain); !exists {
+					Info("Activating custom domain %q...", domain)
+					_, err = API().CreateDomain(cmd.Context(), app.ID, domain, "")
+					if err != nil {
+						Warn("Activation of custom domain %q failed: %s", domain, err)
+					}
 				}
 			}
 		}
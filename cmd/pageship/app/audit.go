@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.PersistentFlags().String("app", "", "app ID")
+	auditCmd.PersistentFlags().String("since", "", "show events since duration ago (e.g. 24h) or timestamp (RFC3339)")
+	auditCmd.PersistentFlags().String("until", "", "show events until duration ago (e.g. 24h) or timestamp (RFC3339)")
+	auditCmd.PersistentFlags().Bool("json", false, "output events as JSON lines")
+
+	auditCmd.AddCommand(auditExportCmd)
+}
+
+func auditQuery() (api.AuditQuery, error) {
+	now := time.Now()
+	since, err := parseSinceTime(viper.GetString("since"), now)
+	if err != nil {
+		return api.AuditQuery{}, fmt.Errorf("invalid since: %w", err)
+	}
+	until, err := parseSinceTime(viper.GetString("until"), now)
+	if err != nil {
+		return api.AuditQuery{}, fmt.Errorf("invalid until: %w", err)
+	}
+	return api.AuditQuery{Since: since, Until: until}, nil
+}
+
+func listAuditEvents(ctx context.Context, appID string, query api.AuditQuery, fn func(e *models.AuditEvent) error) error {
+	for {
+		result, err := API().ListAuditEvents(ctx, appID, query)
+		if err != nil {
+			return fmt.Errorf("failed to list audit events: %w", err)
+		}
+
+		for i := range result.Events {
+			if err := fn(&result.Events[i]); err != nil {
+				return err
+			}
+		}
+
+		if result.Next == "" {
+			return nil
+		}
+		query.After = result.Next
+	}
+}
+
+func printAuditEventsJSON(ctx context.Context, appID string, query api.AuditQuery) error {
+	encoder := json.NewEncoder(os.Stdout)
+	return listAuditEvents(ctx, appID, query, func(e *models.AuditEvent) error {
+		return encoder.Encode(e)
+	})
+}
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show audit events of app",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		query, err := auditQuery()
+		if err != nil {
+			return err
+		}
+
+		if viper.GetBool("json") {
+			return printAuditEventsJSON(cmd.Context(), appID, query)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+		fmt.Fprintln(w, "TIME\tACTION\tTARGET\tACTOR\tCREDENTIAL")
+		err = listAuditEvents(cmd.Context(), appID, query, func(e *models.AuditEvent) error {
+			actor := e.ActorName
+			if actor == "" {
+				actor = e.ActorID
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				e.CreatedAt.Local().Format(time.DateTime),
+				e.Action,
+				e.Target,
+				actor,
+				e.CredentialID,
+			)
+			return nil
+		})
+		w.Flush()
+		return err
+	},
+}
+
+var auditExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export audit events as JSON lines",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query, err := auditQuery()
+		if err != nil {
+			return err
+		}
+
+		// Events of all apps are exported if app is not specified.
+		appID := viper.GetString("app")
+		return printAuditEventsJSON(cmd.Context(), appID, query)
+	},
+}
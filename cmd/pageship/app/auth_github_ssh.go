@@ -12,6 +12,34 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
+func promptSSHKeyFile(def string) (string, error) {
+	prompt := promptui.Prompt{
+		Label:   "SSH key file",
+		Default: def,
+		Validate: func(s string) error {
+			_, err := os.Stat(s)
+			if err != nil {
+				return fmt.Errorf("invalid keyfile: %w", err)
+			}
+			return nil
+		},
+	}
+	return prompt.Run()
+}
+
+func promptSSHPassphrase() ([]byte, error) {
+	prompt := promptui.Prompt{
+		Label:       "SSH key passphrase",
+		Mask:        '*',
+		HideEntered: true,
+	}
+	result, err := prompt.Run()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(result), nil
+}
+
 func authGitHubSSH(ctx context.Context) (string, error) {
 	var sources []sshkey.Source
 	defer func() {
@@ -27,34 +55,7 @@ func authGitHubSSH(ctx context.Context) (string, error) {
 		sources = append(sources, agent)
 	}
 
-	sources = append(sources, sshkey.NewUserKey(
-		func(def string) (string, error) {
-			prompt := promptui.Prompt{
-				Label:   "SSH key file",
-				Default: def,
-				Validate: func(s string) error {
-					_, err := os.Stat(s)
-					if err != nil {
-						return fmt.Errorf("invalid keyfile: %w", err)
-					}
-					return nil
-				},
-			}
-			return prompt.Run()
-		},
-		func() ([]byte, error) {
-			prompt := promptui.Prompt{
-				Label:       "SSH key passphrase",
-				Mask:        '*',
-				HideEntered: true,
-			}
-			result, err := prompt.Run()
-			if err != nil {
-				return nil, err
-			}
-			return []byte(result), nil
-		},
-	))
+	sources = append(sources, sshkey.NewUserKey(promptSSHKeyFile, promptSSHPassphrase))
 
 	conf, err := config.LoadClientConfig()
 	if err != nil {
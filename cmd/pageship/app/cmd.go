@@ -1,6 +1,7 @@
 package app
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -15,10 +16,13 @@ import (
 var apiEndpoint string
 var apiClient *api.Client
 var debugMode bool
+var clientCertFile, clientKeyFile string
 
 func init() {
 	rootCmd.PersistentFlags().Bool("debug", false, "debug mode")
 	rootCmd.PersistentFlags().String("api", "", "server API endpoint")
+	rootCmd.PersistentFlags().String("client-cert", "", "client certificate file for authentication")
+	rootCmd.PersistentFlags().String("client-key", "", "client certificate private key file")
 
 	cobra.OnInitialize(initConfig)
 }
@@ -28,6 +32,8 @@ func initConfig() {
 
 	debugMode = viper.GetBool("debug")
 	apiEndpoint = viper.GetString("api")
+	clientCertFile = viper.GetString("client-cert")
+	clientKeyFile = viper.GetString("client-key")
 }
 
 func API() *api.Client {
@@ -61,9 +67,21 @@ func API() *api.Client {
 			}
 		}
 
-		apiClient = api.NewClient(apiEndpoint)
-		apiClient.TokenFunc = func(r *http.Request) (string, error) {
-			return ensureAuth(r.Context())
+		if clientCertFile != "" {
+			cert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+			if err != nil {
+				panic(fmt.Errorf("failed to load client certificate: %w", err))
+			}
+
+			// Authenticate using client certificate instead of token.
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+			apiClient = api.NewClientWithTransport(apiEndpoint, transport)
+		} else {
+			apiClient = api.NewClient(apiEndpoint)
+			apiClient.TokenFunc = func(r *http.Request) (string, error) {
+				return ensureAuth(r.Context())
+			}
 		}
 	}
 	return apiClient
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/dustin/go-humanize"
 	"github.com/manifoldco/promptui"
@@ -16,9 +18,9 @@ import (
 	"github.com/oursky/pageship/internal/deploy"
 	"github.com/oursky/pageship/internal/models"
 	"github.com/oursky/pageship/internal/time"
-	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh"
 )
 
 func init() {
@@ -26,16 +28,61 @@ func init() {
 
 	deployCmd.PersistentFlags().String("site", "", "site to deploy")
 	deployCmd.PersistentFlags().String("name", "", "deployment name; autogenerated if not set")
+	deployCmd.PersistentFlags().String("preview-branch", "", "deploy as preview of git branch; replaces previous preview of the branch")
+	deployCmd.PersistentFlags().String("archive", "", "deploy files from archive (tar, tar.gz, tar.zst or zip); use '-' for stdin")
+	deployCmd.PersistentFlags().Bool("dry-run", false, "list files to deploy without deploying")
+	deployCmd.PersistentFlags().Bool("diff", false, "compare files with active deployment of site without deploying")
+	deployCmd.PersistentFlags().String("upload-chunk-size", "8M", "size of each upload chunk")
+	deployCmd.PersistentFlags().Int("upload-retries", 5, "max retries of each upload request")
+	deployCmd.PersistentFlags().Bool("direct-upload", false, "upload files directly to storage in parallel")
+	deployCmd.PersistentFlags().Int("upload-concurrency", 8, "max concurrent file uploads in direct upload")
+	deployCmd.PersistentFlags().Bool("sign", false, "sign deployment with SSH key")
+	deployCmd.PersistentFlags().String("sign-key", "", "SSH private key file to sign deployment; implies --sign")
+	deployCmd.PersistentFlags().Bool("github-status", false, "set GitHub commit status of deployment in GitHub Actions")
+	deployCmd.PersistentFlags().Bool("github-comment", false, "comment deployment URL on GitHub pull request in GitHub Actions")
 	deployCmd.PersistentFlags().BoolP("yes", "y", false, "skip confirmation")
 }
 
-func packTar(dir string, tarfile *os.File, conf *config.Config) ([]models.FileEntry, int64, error) {
+func openArchive(archive string) (io.ReadCloser, error) {
+	if archive == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(archive)
+}
+
+func loadIgnore(dir string, conf *config.Config) (*deploy.IgnoreMatcher, error) {
+	patterns, err := deploy.LoadIgnoreFile(os.DirFS(dir), deploy.IgnoreFileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", deploy.IgnoreFileName, err)
+	}
+
+	// Patterns in ignore file are relative to its directory, while excluded
+	// patterns in config are relative to public directory.
+	ignore := &deploy.IgnoreMatcher{}
+	err = ignore.Add(filepath.ToSlash(conf.Site.Public), patterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern in %s: %w", deploy.IgnoreFileName, err)
+	}
+	err = ignore.Add("", conf.Site.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern in site.exclude: %w", err)
+	}
+	return ignore, nil
+}
+
+func packTar(dir string, archive string, maxSize int64, tarfile *os.File, conf *config.Config) (*deploy.Collector, int64, error) {
+	ignore, err := loadIgnore(dir, conf)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	modTime := time.SystemClock.Now()
-	collector, err := deploy.NewCollector(modTime, tarfile)
+	collector, err := deploy.NewCollector(modTime, maxSize, tarfile)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer collector.Close()
+	collector.Ignore = ignore
 
 	collector.AddDir("/")
 
@@ -51,9 +98,22 @@ func packTar(dir string, tarfile *os.File, conf *config.Config) ([]models.FileEn
 		return nil, 0, err
 	}
 
-	err = collector.Collect(os.DirFS(publicDir), "/public")
-	if err != nil {
-		return nil, 0, fmt.Errorf("collecting from %s: %w", publicDir, err)
+	if archive != "" {
+		r, err := openArchive(archive)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer r.Close()
+
+		err = collector.CollectArchive(r, "/public")
+		if err != nil {
+			return nil, 0, fmt.Errorf("collecting from archive %s: %w", archive, err)
+		}
+	} else {
+		err = collector.Collect(os.DirFS(publicDir), "/public")
+		if err != nil {
+			return nil, 0, fmt.Errorf("collecting from %s: %w", publicDir, err)
+		}
 	}
 
 	collector.Close()
@@ -68,30 +128,149 @@ func packTar(dir string, tarfile *os.File, conf *config.Config) ([]models.FileEn
 		return nil, 0, err
 	}
 
-	return collector.Files(), fi.Size(), nil
+	return collector, fi.Size(), nil
 }
 
-func doDeploy(ctx context.Context, appID string, siteName string, deploymentName string, conf *config.Config, dir string) error {
-	tarfile, err := os.CreateTemp("", fmt.Sprintf("pageship-%s-%s-*.tar.zst", appID, deploymentName))
+func doDryRun(conf *config.Config, dir string, archive string) error {
+	tarfile, err := os.CreateTemp("", "pageship-dry-run-*.tar.zst")
 	if err != nil {
 		return fmt.Errorf("failed to create temp file: %w", err)
 	}
 	defer os.Remove(tarfile.Name())
+	defer tarfile.Close()
+
+	Info("Collecting files...")
+	collector, tarSize, err := packTar(dir, archive, 0, tarfile, conf)
+	if err != nil {
+		return fmt.Errorf("failed to collect files: %w", err)
+	}
+
+	var fileCount int
+	var totalSize, excludedSize int64
+	w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tPATH\tSIZE")
+	for _, f := range collector.Files() {
+		if strings.HasSuffix(f.Path, "/") {
+			continue
+		}
+		fileCount++
+		totalSize += f.Size
+		fmt.Fprintf(w, "INCLUDED\t%s\t%s\n", f.Path, humanize.Bytes(uint64(f.Size)))
+	}
+	for _, f := range collector.Excluded() {
+		excludedSize += f.Size
+		fmt.Fprintf(w, "EXCLUDED\t%s\t%s\n", f.Path, humanize.Bytes(uint64(f.Size)))
+	}
+	w.Flush()
+
+	Info("%d files found (%s). Tarball size: %s",
+		fileCount, humanize.Bytes(uint64(totalSize)), humanize.Bytes(uint64(tarSize)))
+	Info("%d entries excluded (%s).",
+		len(collector.Excluded()), humanize.Bytes(uint64(excludedSize)))
+	return nil
+}
+
+func doDiff(ctx context.Context, appID string, siteName string, conf *config.Config, dir string, archive string) error {
+	sites, err := API().ListSites(ctx, appID)
+	if err != nil {
+		return fmt.Errorf("failed to list sites: %w", err)
+	}
+
+	var remoteFiles []models.FileEntry
+	for _, site := range sites {
+		if site.Name != siteName || site.DeploymentName == nil {
+			continue
+		}
+		Info("Comparing with deployment '%s' of site %q...", *site.DeploymentName, siteName)
+		remoteFiles, err = API().GetDeploymentFiles(ctx, appID, *site.DeploymentName)
+		if err != nil {
+			return fmt.Errorf("failed to get deployment files: %w", err)
+		}
+	}
+	if remoteFiles == nil {
+		Info("Site %q has no active deployment.", siteName)
+	}
+
+	tarfile, err := os.CreateTemp("", fmt.Sprintf("pageship-%s-diff-*.tar.zst", appID))
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tarfile.Name())
+	defer tarfile.Close()
+
+	Info("Collecting files...")
+	collector, _, err := packTar(dir, archive, 0, tarfile, conf)
+	if err != nil {
+		return fmt.Errorf("failed to collect files: %w", err)
+	}
+
+	diffs := deploy.DiffFiles(remoteFiles, collector.Files())
+	if len(diffs) == 0 {
+		Info("No changes.")
+		return nil
+	}
+
+	counts := map[deploy.FileChange]int{}
+	var sizeDelta int64
+	w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+	fmt.Fprintln(w, "CHANGE\tPATH\tSIZE")
+	for _, d := range diffs {
+		counts[d.Change]++
+		sizeDelta += d.SizeDelta()
+
+		var size string
+		switch d.Change {
+		case deploy.FileChangeAdded:
+			size = humanize.Bytes(uint64(d.NewSize))
+		case deploy.FileChangeRemoved:
+			size = humanize.Bytes(uint64(d.OldSize))
+		case deploy.FileChangeChanged:
+			size = fmt.Sprintf("%s -> %s", humanize.Bytes(uint64(d.OldSize)), humanize.Bytes(uint64(d.NewSize)))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", strings.ToUpper(string(d.Change)), d.Path, size)
+	}
+	w.Flush()
+
+	sign := "+"
+	if sizeDelta < 0 {
+		sign = "-"
+		sizeDelta = -sizeDelta
+	}
+	Info("%d added, %d removed, %d changed. Size delta: %s%s",
+		counts[deploy.FileChangeAdded],
+		counts[deploy.FileChangeRemoved],
+		counts[deploy.FileChangeChanged],
+		sign, humanize.Bytes(uint64(sizeDelta)))
+	return nil
+}
+
+func doDeploy(ctx context.Context, appID string, siteName string, deploymentName string, previewName string, conf *config.Config, dir string, archive string, uploadOpts uploadOptions, signer ssh.Signer) (*api.APIDeployment, error) {
+	tarfile, err := os.CreateTemp("", fmt.Sprintf("pageship-%s-%s-*.tar.zst", appID, deploymentName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tarfile.Name())
 
 	Debug("Configuring app...")
 	_, err = API().ConfigureApp(ctx, appID, &conf.App)
 	if code, ok := api.ErrorStatusCode(err); ok && code == http.StatusForbidden {
 		Warn("Insufficient permission; skip configuring app.")
 	} else if err != nil {
-		return fmt.Errorf("failed to configure app: %w", err)
+		return nil, fmt.Errorf("failed to configure app: %w", err)
+	}
+
+	manifest, err := API().GetManifest(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest: %w", err)
 	}
 
 	Info("Collecting files...")
 	Debug("Tarball: %s", tarfile.Name())
-	files, tarSize, err := packTar(dir, tarfile, conf)
+	collector, tarSize, err := packTar(dir, archive, manifest.MaxDeploymentSize, tarfile, conf)
 	if err != nil {
-		return fmt.Errorf("failed to collect files: %w", err)
+		return nil, fmt.Errorf("failed to collect files: %w", err)
 	}
+	files := collector.Files()
 
 	Info("%d files found. Tarball size: %s", len(files), humanize.Bytes(uint64(tarSize)))
 
@@ -100,7 +279,7 @@ func doDeploy(ctx context.Context, appID string, siteName string, deploymentName
 	if siteName != "" {
 		site, err := API().CreateSite(ctx, appID, siteName)
 		if err != nil {
-			return fmt.Errorf("failed to setup site: %w", err)
+			return nil, fmt.Errorf("failed to setup site: %w", err)
 		}
 		Debug("Site ID: %s", site.ID)
 		lastDeploymentName := "-"
@@ -112,18 +291,34 @@ func doDeploy(ctx context.Context, appID string, siteName string, deploymentName
 		Info("Site not specified; deployment would not be assigned to site")
 	}
 
-	deployment, err := API().SetupDeployment(ctx, appID, deploymentName, files, &conf.Site)
+	var signature *models.DeploymentSignature
+	if signer != nil {
+		digest, err := deploy.ManifestDigest(files, deploy.DefaultHashAlgorithm, &conf.Site)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute manifest digest: %w", err)
+		}
+		signature, err = deploy.SignManifest(signer, digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign deployment: %w", err)
+		}
+		Info("Signed deployment with key %s", ssh.FingerprintSHA256(signer.PublicKey()))
+	}
+
+	setup, err := API().SetupDeployment(ctx, appID, deploymentName, files, &conf.Site, signature, uploadOpts.Direct)
 	if err != nil {
-		return fmt.Errorf("failed to setup deployment: %w", err)
+		return nil, fmt.Errorf("failed to setup deployment: %w", err)
 	}
+	deployment := setup.Deployment
 
 	Debug("Deployment ID: %s", deployment.ID)
 
-	bar := progressbar.DefaultBytes(tarSize, "uploading")
-	body := io.TeeReader(tarfile, bar)
-	deployment, err = API().UploadDeploymentTarball(ctx, appID, deployment.Name, body, tarSize)
+	if uploadOpts.Direct {
+		deployment, err = uploadDirect(ctx, appID, deployment.Name, tarfile, tarSize, files, setup.UploadURLs, uploadOpts)
+	} else {
+		deployment, err = uploadTarball(ctx, appID, deployment.Name, tarfile, tarSize, uploadOpts)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to upload tarball: %w", err)
+		return nil, fmt.Errorf("failed to upload tarball: %w", err)
 	}
 
 	if siteName != "" {
@@ -132,13 +327,22 @@ func doDeploy(ctx context.Context, appID string, siteName string, deploymentName
 			DeploymentName: &deployment.Name,
 		})
 		if err != nil {
-			return fmt.Errorf("failed to activate deployment: %w", err)
+			return nil, fmt.Errorf("failed to activate deployment: %w", err)
+		}
+	}
+
+	if previewName != "" {
+		Info("Updating preview '%s'...", previewName)
+		_, err = API().RenameDeployment(ctx, appID, deployment.Name, previewName, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update preview: %w", err)
 		}
+		deploymentName = previewName
 	}
 
 	d, err := API().GetDeployment(ctx, appID, deploymentName)
 	if err != nil {
-		return fmt.Errorf("failed to get deployment: %w", err)
+		return nil, fmt.Errorf("failed to get deployment: %w", err)
 	}
 
 	if d.URL != nil {
@@ -146,16 +350,28 @@ func doDeploy(ctx context.Context, appID string, siteName string, deploymentName
 	}
 
 	Info("Done!")
-	return nil
+	return d, nil
 }
 
 var deployCmd = &cobra.Command{
-	Use:   "deploy [deploy directory] [--site site to deploy] [--name deployment name] [--yes]",
+	Use:   "deploy [deploy directory] [--site site to deploy] [--name deployment name] [--preview-branch git branch] [--archive archive file] [--dry-run] [--diff] [--sign] [--yes]",
 	Short: "Deploy site",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		site := viper.GetString("site")
 		name := viper.GetString("name")
+		previewBranch := viper.GetString("preview-branch")
 		yes := viper.GetBool("yes")
+		archive := viper.GetString("archive")
+		dryRun := viper.GetBool("dry-run")
+		diff := viper.GetBool("diff")
+		uploadChunkSize := viper.GetString("upload-chunk-size")
+		uploadRetries := viper.GetInt("upload-retries")
+		directUpload := viper.GetBool("direct-upload")
+		uploadConcurrency := viper.GetInt("upload-concurrency")
+		sign := viper.GetBool("sign")
+		signKey := viper.GetString("sign-key")
+		githubStatus := viper.GetBool("github-status")
+		githubComment := viper.GetBool("github-comment")
 
 		dir := "."
 		if len(args) > 0 {
@@ -167,6 +383,29 @@ var deployCmd = &cobra.Command{
 			return fmt.Errorf("invalid deploy directory: %w", err)
 		}
 
+		chunkSize, err := humanize.ParseBytes(uploadChunkSize)
+		if err != nil || chunkSize == 0 {
+			return fmt.Errorf("invalid upload chunk size: %s", uploadChunkSize)
+		}
+		if uploadConcurrency <= 0 {
+			return fmt.Errorf("invalid upload concurrency: %d", uploadConcurrency)
+		}
+		uploadOpts := uploadOptions{
+			ChunkSize:   int64(chunkSize),
+			Retries:     uploadRetries,
+			Direct:      directUpload,
+			Concurrency: uploadConcurrency,
+		}
+
+		previewName := ""
+		if previewBranch != "" {
+			if name != "" || site != "" {
+				return fmt.Errorf("--preview-branch cannot be used with --name or --site")
+			}
+			previewName = deploy.PreviewDeploymentName(previewBranch)
+			Debug("Preview deployment name: %s", previewName)
+		}
+
 		if name == "" {
 			name = models.RandomID(4)
 		}
@@ -186,10 +425,50 @@ var deployCmd = &cobra.Command{
 				return fmt.Errorf("site is not defined: %s", site)
 			}
 		}
+		if previewName != "" {
+			// Sites are resolved before deployments; preview would be unreachable.
+			if _, ok := conf.App.ResolveSite(previewName); ok {
+				return fmt.Errorf("preview deployment name %q conflicts with site name", previewName)
+			}
+		}
+
+		if dryRun {
+			return doDryRun(conf, dir, archive)
+		}
+		if diff {
+			diffSite := site
+			if diffSite == "" {
+				diffSite = conf.App.DefaultSite
+			}
+			return doDiff(cmd.Context(), appID, diffSite, conf, dir, archive)
+		}
+
+		if archive == "-" && !yes {
+			return fmt.Errorf("must skip confirmation with --yes when reading archive from stdin")
+		}
+
+		reportName := name
+		if previewName != "" {
+			reportName = previewName
+		}
+		reporter, err := newGitHubReporter(githubStatus, githubComment, appID, site, reportName)
+		if err != nil {
+			return fmt.Errorf("failed to setup GitHub integration: %w", err)
+		}
+
+		var signer ssh.Signer
+		if sign || signKey != "" {
+			signer, err = loadDeploySigner(signKey)
+			if err != nil {
+				return fmt.Errorf("failed to load signing key: %w", err)
+			}
+		}
 
 		if !yes {
 			var label string
-			if site == "" {
+			if previewName != "" {
+				label = fmt.Sprintf("Deploy preview %q to app %q", previewName, appID)
+			} else if site == "" {
 				label = fmt.Sprintf("Deploy to app %q", appID)
 			} else {
 				label = fmt.Sprintf("Deploy to site %q of app %q", site, appID)
@@ -203,6 +482,13 @@ var deployCmd = &cobra.Command{
 			}
 		}
 
-		return doDeploy(cmd.Context(), appID, site, name, conf, dir)
+		if reporter != nil {
+			reporter.Start(cmd.Context())
+		}
+		deployment, err := doDeploy(cmd.Context(), appID, site, name, previewName, conf, dir, archive, uploadOpts, signer)
+		if reporter != nil {
+			reporter.Finish(cmd.Context(), deployment, err)
+		}
+		return err
 	},
 }
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/github"
+)
+
+// githubReporter reports deployment result to GitHub, as commit status and
+// sticky comment of pull request.
+type githubReporter struct {
+	actions        *github.ActionsContext
+	client         *github.Client
+	status         bool
+	comment        bool
+	appID          string
+	siteName       string
+	deploymentName string
+}
+
+func newGitHubReporter(status bool, comment bool, appID string, siteName string, deploymentName string) (*githubReporter, error) {
+	if !status && !comment {
+		return nil, nil
+	}
+
+	actions, err := github.LoadActionsContext()
+	if err != nil {
+		return nil, err
+	}
+
+	return &githubReporter{
+		actions:        actions,
+		client:         actions.Client(),
+		status:         status,
+		comment:        comment,
+		appID:          appID,
+		siteName:       siteName,
+		deploymentName: deploymentName,
+	}, nil
+}
+
+func (g *githubReporter) statusContext() string {
+	if g.siteName == "" {
+		return fmt.Sprintf("pageship/%s", g.appID)
+	}
+	return fmt.Sprintf("pageship/%s/%s", g.appID, g.siteName)
+}
+
+func (g *githubReporter) setStatus(ctx context.Context, state github.CommitState, targetURL string, description string) {
+	if !g.status {
+		return
+	}
+
+	err := g.client.CreateCommitStatus(ctx, g.actions.Repository, g.actions.SHA, &github.CommitStatus{
+		State:       state,
+		TargetURL:   targetURL,
+		Description: description,
+		Context:     g.statusContext(),
+	})
+	if err != nil {
+		Warn("Failed to set GitHub commit status: %s", err)
+		return
+	}
+	Debug("GitHub commit status: %s", state)
+}
+
+func (g *githubReporter) Start(ctx context.Context) {
+	g.setStatus(ctx, github.CommitStatePending, g.actions.RunURL,
+		fmt.Sprintf("Deploying '%s'", g.deploymentName))
+}
+
+func (g *githubReporter) Finish(ctx context.Context, deployment *api.APIDeployment, deployErr error) {
+	url := ""
+	if deployment != nil && deployment.URL != nil {
+		url = *deployment.URL
+	}
+
+	if deployErr != nil {
+		g.setStatus(ctx, github.CommitStateFailure, g.actions.RunURL,
+			fmt.Sprintf("Failed to deploy '%s'", g.deploymentName))
+		return
+	}
+	g.setStatus(ctx, github.CommitStateSuccess, url,
+		fmt.Sprintf("Deployed '%s'", g.deploymentName))
+
+	if !g.comment {
+		return
+	}
+	if g.actions.PullRequest == 0 {
+		Info("Not triggered by pull request; skip commenting on GitHub.")
+		return
+	}
+
+	// Comment is shared by deployments of same app & site.
+	marker := g.statusContext()
+	_, err := g.client.UpsertStickyComment(ctx, g.actions.Repository, g.actions.PullRequest, marker, g.commentBody(url))
+	if err != nil {
+		Warn("Failed to comment on GitHub pull request: %s", err)
+		return
+	}
+	Info("Commented on pull request #%d", g.actions.PullRequest)
+}
+
+func (g *githubReporter) commentBody(url string) string {
+	site := g.siteName
+	if site == "" {
+		site = "-"
+	}
+	if url == "" {
+		url = "-"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Pageship** deployed `%s` of app `%s`.\n\n", g.deploymentName, g.appID)
+	fmt.Fprintln(&b, "| Site | Deployment | URL |")
+	fmt.Fprintln(&b, "|------|------------|-----|")
+	fmt.Fprintf(&b, "| %s | %s | %s |\n", site, g.deploymentName, url)
+	fmt.Fprintf(&b, "\nCommit: %s", g.actions.SHA)
+	if g.actions.RunURL != "" {
+		fmt.Fprintf(&b, " ([workflow run](%s))", g.actions.RunURL)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"errors"
+
+	"github.com/oursky/pageship/internal/sshkey"
+	"golang.org/x/crypto/ssh"
+)
+
+// loadDeploySigner loads the SSH key for signing deployments. If key file is
+// not specified, the first key from SSH agent or the user SSH key is used.
+func loadDeploySigner(keyFile string) (ssh.Signer, error) {
+	if keyFile != "" {
+		return sshkey.LoadKeyFile(keyFile, promptSSHPassphrase)
+	}
+
+	var sources []sshkey.Source
+	defer func() {
+		for _, s := range sources {
+			s.Close()
+		}
+	}()
+
+	agent, err := sshkey.NewAgent()
+	if err != nil {
+		Debug("Failed to connect to SSH agent: %s", err)
+	} else {
+		sources = append(sources, agent)
+	}
+	sources = append(sources, sshkey.NewUserKey(promptSSHKeyFile, promptSSHPassphrase))
+
+	for _, s := range sources {
+		signers, err := s.Signers()
+		if err != nil {
+			Debug("Failed to load SSH key: %s", err)
+			continue
+		}
+		if len(signers) > 0 {
+			return signers[0], nil
+		}
+	}
+	return nil, errors.New("no SSH key available")
+}
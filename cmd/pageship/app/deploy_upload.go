@@ -0,0 +1,300 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/deploy"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/sync/errgroup"
+)
+
+const uploadMaxBackoff = 30 * time.Second
+
+type uploadOptions struct {
+	ChunkSize   int64
+	Retries     int
+	Direct      bool
+	Concurrency int
+}
+
+func isRetryableUploadError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var serverErr api.ServerError
+	if errors.As(err, &serverErr) {
+		// Offset conflict is resolved by re-querying upload offset.
+		return serverErr.Code == http.StatusConflict || serverErr.Code >= 500
+	}
+
+	var statusErr api.HTTPStatusCodeError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code >= 500 || statusErr.Code == http.StatusTooManyRequests
+	}
+
+	// Network errors
+	return true
+}
+
+func sleepBackoff(ctx context.Context, attempt int) error {
+	backoff := time.Second << attempt
+	if backoff > uploadMaxBackoff || backoff <= 0 {
+		backoff = uploadMaxBackoff
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(backoff):
+		return nil
+	}
+}
+
+func withRetry[T any](ctx context.Context, retries int, desc string, fn func() (T, error)) (T, error) {
+	for attempt := 0; ; attempt++ {
+		result, err := fn()
+		if err == nil || attempt >= retries || !isRetryableUploadError(err) {
+			return result, err
+		}
+
+		Warn("%s failed, retrying: %s", desc, err)
+		if err := sleepBackoff(ctx, attempt); err != nil {
+			return result, err
+		}
+	}
+}
+
+func uploadTarball(
+	ctx context.Context,
+	appID string,
+	deploymentName string,
+	tarfile *os.File,
+	tarSize int64,
+	opts uploadOptions,
+) (*models.Deployment, error) {
+	queryOffset := func() (int64, error) {
+		return API().GetDeploymentUploadOffset(ctx, appID, deploymentName)
+	}
+
+	offset, err := withRetry(ctx, opts.Retries, "query upload", queryOffset)
+	if isUnsupportedEndpoint(err) {
+		// Server does not support chunked upload; fallback to single request.
+		Debug("Chunked upload not supported by server")
+		bar := progressbar.DefaultBytes(tarSize, "uploading")
+		body := io.TeeReader(tarfile, bar)
+		return API().UploadDeploymentTarball(ctx, appID, deploymentName, body, tarSize)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if offset > 0 {
+		Info("Resuming upload from %s...", humanize.Bytes(uint64(offset)))
+	}
+
+	bar := progressbar.DefaultBytes(tarSize, "uploading")
+	attempt := 0
+	for offset < tarSize {
+		size := opts.ChunkSize
+		if offset+size > tarSize {
+			size = tarSize - offset
+		}
+
+		bar.Set64(offset)
+		chunk := io.TeeReader(io.NewSectionReader(tarfile, offset, size), bar)
+		newOffset, err := API().UploadDeploymentChunk(ctx, appID, deploymentName, chunk, offset, size, tarSize)
+		if err == nil {
+			offset = newOffset
+			attempt = 0
+			continue
+		}
+
+		if attempt >= opts.Retries || !isRetryableUploadError(err) {
+			return nil, err
+		}
+		Warn("upload chunk failed, retrying: %s", err)
+		if err := sleepBackoff(ctx, attempt); err != nil {
+			return nil, err
+		}
+		attempt++
+
+		// Re-sync upload offset with server before retrying.
+		offset, err = withRetry(ctx, opts.Retries, "query upload", queryOffset)
+		if err != nil {
+			return nil, err
+		}
+	}
+	bar.Finish()
+
+	return withRetry(ctx, opts.Retries, "complete upload", func() (*models.Deployment, error) {
+		deployment, err := API().CompleteDeploymentUpload(ctx, appID, deploymentName)
+		if err != nil {
+			// Previous attempt may have completed before connection lost.
+			if d, gerr := API().GetDeployment(ctx, appID, deploymentName); gerr == nil && d.UploadedAt != nil {
+				return d.Deployment, nil
+			}
+			return nil, err
+		}
+		return deployment, nil
+	})
+}
+
+func isUnsupportedEndpoint(err error) bool {
+	code, ok := api.ErrorStatusCode(err)
+	return ok && code == http.StatusNotFound && errors.As(err, new(api.HTTPStatusCodeError))
+}
+
+// uploadDirect uploads files of the tarball directly to the storage in
+// parallel, using upload URLs issued by server on setup; URLs are requested
+// again if not issued on setup, or when retrying.
+func uploadDirect(
+	ctx context.Context,
+	appID string,
+	deploymentName string,
+	tarfile *os.File,
+	tarSize int64,
+	files []models.FileEntry,
+	uploadURLs *api.APIDeploymentUploadURLs,
+	opts uploadOptions,
+) (*models.Deployment, error) {
+	getURLs := func() (*api.APIDeploymentUploadURLs, error) {
+		return API().GetDeploymentUploadURLs(ctx, appID, deploymentName)
+	}
+
+	if uploadURLs == nil {
+		var err error
+		uploadURLs, err = withRetry(ctx, opts.Retries, "get upload URLs", getURLs)
+		if isUnsupportedEndpoint(err) {
+			Warn("Direct upload not supported by server; uploading tarball instead.")
+			return uploadTarball(ctx, appID, deploymentName, tarfile, tarSize, opts)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	dir, err := os.MkdirTemp("", fmt.Sprintf("pageship-%s-%s-*", appID, deploymentName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = deploy.ExtractFiles(tarfile, deploy.DefaultHashAlgorithm, files, func(e models.FileEntry, r io.Reader) error {
+		if strings.HasSuffix(e.Path, "/") {
+			return nil
+		}
+		return writeFile(filepath.Join(dir, filepath.FromSlash(e.Path)), r)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract tarball: %w", err)
+	}
+
+	entries := make(map[string]models.FileEntry)
+	for _, f := range files {
+		entries[f.Path] = f
+	}
+
+	for round := 0; ; round++ {
+		if len(uploadURLs.URLs) > 0 {
+			var total int64
+			for path := range uploadURLs.URLs {
+				total += entries[path].Size
+			}
+			Info("Uploading %d files (%s)...", len(uploadURLs.URLs), humanize.Bytes(uint64(total)))
+
+			bar := progressbar.DefaultBytes(total, "uploading")
+			g, ctx := errgroup.WithContext(ctx)
+			g.SetLimit(opts.Concurrency)
+			for path, url := range uploadURLs.URLs {
+				path, url := path, url
+				g.Go(func() error {
+					_, err := withRetry(ctx, opts.Retries, "upload "+path, func() (any, error) {
+						return nil, uploadFile(ctx, url, filepath.Join(dir, filepath.FromSlash(path)), entries[path], bar)
+					})
+					if err != nil {
+						return fmt.Errorf("%s: %w", path, err)
+					}
+					return nil
+				})
+			}
+			if err := g.Wait(); err != nil {
+				return nil, err
+			}
+			bar.Finish()
+		}
+
+		deployment, err := withRetry(ctx, opts.Retries, "finalize upload", func() (*models.Deployment, error) {
+			deployment, err := API().FinalizeDeployment(ctx, appID, deploymentName)
+			if err != nil {
+				// Previous attempt may have completed before connection lost.
+				if d, gerr := API().GetDeployment(ctx, appID, deploymentName); gerr == nil && d.UploadedAt != nil {
+					return d.Deployment, nil
+				}
+				return nil, err
+			}
+			return deployment, nil
+		})
+
+		var serverErr api.ServerError
+		if errors.As(err, &serverErr) && serverErr.Code == http.StatusBadRequest && round < opts.Retries {
+			// Server discarded invalid/missing files; upload them again.
+			Warn("finalize upload failed, retrying: %s", err)
+			uploadURLs, err = withRetry(ctx, opts.Retries, "get upload URLs", getURLs)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return deployment, err
+	}
+}
+
+func writeFile(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func uploadFile(ctx context.Context, url string, path string, entry models.FileEntry, bar *progressbar.ProgressBar) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	counter := &progressCounter{bar: bar}
+	err = API().UploadFile(ctx, url, io.TeeReader(f, counter), entry.Size, entry.MD5)
+	if err != nil {
+		// Revert progress of failed attempt.
+		bar.Add64(-counter.n)
+	}
+	return err
+}
+
+type progressCounter struct {
+	bar *progressbar.ProgressBar
+	n   int64
+}
+
+func (c *progressCounter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return c.bar.Write(p)
+}
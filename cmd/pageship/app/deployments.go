@@ -2,10 +2,13 @@ package app
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"text/tabwriter"
 	"time"
 
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/deploy"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -13,6 +16,9 @@ import (
 func init() {
 	rootCmd.AddCommand(deploymentsCmd)
 	deploymentsCmd.PersistentFlags().String("app", "", "app ID")
+
+	deploymentsCmd.AddCommand(deploymentsDeleteCmd)
+	deploymentsDeleteCmd.PersistentFlags().String("preview-branch", "", "delete preview of git branch")
 }
 
 var deploymentsCmd = &cobra.Command{
@@ -61,3 +67,42 @@ var deploymentsCmd = &cobra.Command{
 		return nil
 	},
 }
+
+var deploymentsDeleteCmd = &cobra.Command{
+	Use:   "delete [deployment name] [--preview-branch git branch]",
+	Short: "Delete deployment",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		previewBranch := viper.GetString("preview-branch")
+
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		var name string
+		switch {
+		case len(args) == 1 && previewBranch == "":
+			name = args[0]
+		case len(args) == 0 && previewBranch != "":
+			name = deploy.PreviewDeploymentName(previewBranch)
+		default:
+			return fmt.Errorf("must specify either deployment name or --preview-branch")
+		}
+
+		err := API().DeleteDeployment(cmd.Context(), appID, name)
+		if code, ok := api.ErrorStatusCode(err); ok && code == http.StatusNotFound && previewBranch != "" {
+			// Preview may be expired already.
+			Info("Preview %q not found.", name)
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to delete deployment: %w", err)
+		}
+
+		Info("Deployment %q deleted.", name)
+		return nil
+	},
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -21,6 +22,13 @@ func init() {
 
 	domainsCmd.AddCommand(domainsActivateCmd)
 	domainsCmd.AddCommand(domainsDeactivateCmd)
+	domainsCmd.AddCommand(domainsVerifyCmd)
+	domainsCmd.AddCommand(domainsCheckCmd)
+
+	domainsCmd.AddCommand(domainsSetCertCmd)
+	domainsSetCertCmd.PersistentFlags().String("cert", "", "PEM file of certificate chain")
+	domainsSetCertCmd.PersistentFlags().String("key", "", "PEM file of certificate private key")
+	domainsSetCertCmd.PersistentFlags().Bool("remove", false, "remove uploaded certificate")
 }
 
 var domainsCmd = &cobra.Command{
@@ -52,11 +60,22 @@ var domainsCmd = &cobra.Command{
 		}
 		domains := map[string]domainEntry{}
 		for _, dconf := range app.Config.Domains {
+			site := dconf.Site
+			if dconf.IsWildcard() {
+				site = models.DomainSiteWildcard
+			}
 			domains[dconf.Domain] = domainEntry{
 				name:  dconf.Domain,
-				site:  dconf.Site,
+				site:  site,
 				model: nil,
 			}
+			for _, alias := range dconf.Aliases {
+				domains[alias] = domainEntry{
+					name:  alias,
+					site:  dconf.Site,
+					model: nil,
+				}
+			}
 		}
 
 		apiDomains, err := API().ListDomains(cmd.Context(), appID)
@@ -170,7 +189,10 @@ var domainsActivateCmd = &cobra.Command{
 			_, err = API().CreateDomain(cmd.Context(), appID, domainName, replaceApp)
 		}
 
-		if err != nil {
+		if api.IsErrorCode(err, models.ErrorCodeDomainNotVerified) {
+			Info("Verify ownership of the domain with `pageship domains verify %s` first.", domainName)
+			return err
+		} else if err != nil {
 			return fmt.Errorf("failed to create domain: %w", err)
 		}
 
@@ -203,3 +225,184 @@ var domainsDeactivateCmd = &cobra.Command{
 		return nil
 	},
 }
+
+var domainsVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify ownership of domain for the app",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domainName := args[0]
+
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		verification, err := API().VerifyDomain(cmd.Context(), appID, domainName)
+		if err != nil {
+			return fmt.Errorf("failed to verify domain: %w", err)
+		}
+
+		if verification.VerifiedAt != nil {
+			Info("Domain %q is verified.", domainName)
+			return nil
+		}
+
+		Info("Add the following DNS TXT record, then run this command again:")
+		w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+		fmt.Fprintln(w, "NAME\tTYPE\tVALUE")
+		fmt.Fprintf(w, "%s\tTXT\t%s\n", verification.RecordName, verification.Value)
+		w.Flush()
+
+		return models.ErrDomainNotVerified
+	},
+}
+
+var domainsCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check status of domain for the app",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domainName := args[0]
+
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		check, err := API().CheckDomain(cmd.Context(), appID, domainName)
+		if err != nil {
+			return fmt.Errorf("failed to check domain: %w", err)
+		}
+
+		orNone := func(s string) string {
+			if s == "" {
+				return "-"
+			}
+			return s
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+
+		verified := "NO"
+		if check.Verified {
+			verified = "YES"
+		}
+		fmt.Fprintf(w, "Domain:\t%s\n", check.Domain)
+		fmt.Fprintf(w, "Verified:\t%s\n", verified)
+
+		switch {
+		case check.Active:
+			fmt.Fprintf(w, "Status:\tACTIVE\n")
+		case check.AppID != "":
+			fmt.Fprintf(w, "Status:\tIN_USE (by app %q)\n", check.AppID)
+		default:
+			fmt.Fprintf(w, "Status:\tINACTIVE\n")
+		}
+		fmt.Fprintf(w, "Site:\t%s\n", orNone(check.SiteName))
+		deployment := ""
+		if check.DeploymentName != nil {
+			deployment = *check.DeploymentName
+		}
+		fmt.Fprintf(w, "Deployment:\t%s\n", orNone(deployment))
+
+		dns := "OK"
+		if check.DNS.Error != "" {
+			dns = "ERROR: " + check.DNS.Error
+		} else if !check.DNS.PointsToServer {
+			dns = "NOT POINTING TO SERVER"
+		}
+		fmt.Fprintf(w, "DNS:\t%s\n", dns)
+		if check.DNS.CNAME != "" {
+			fmt.Fprintf(w, "  CNAME:\t%s\n", check.DNS.CNAME)
+		}
+		fmt.Fprintf(w, "  Addresses:\t%s\n", orNone(strings.Join(check.DNS.Addresses, ", ")))
+		fmt.Fprintf(w, "  Expected:\t%s\n", orNone(strings.Join(check.DNS.ExpectedAddresses, ", ")))
+
+		cert := check.Certificate
+		switch {
+		case !cert.Managed:
+			fmt.Fprintf(w, "Certificate:\tTLS not enabled on server\n")
+		case cert.Custom && cert.NotAfter.Before(time.Now()):
+			fmt.Fprintf(w, "Certificate:\tEXPIRED (UPLOADED)\n")
+		case cert.Custom:
+			fmt.Fprintf(w, "Certificate:\tVALID (UPLOADED)\n")
+		case cert.NotAfter == nil:
+			fmt.Fprintf(w, "Certificate:\tNOT ISSUED\n")
+		case cert.NotAfter.Before(time.Now()):
+			fmt.Fprintf(w, "Certificate:\tEXPIRED\n")
+		default:
+			fmt.Fprintf(w, "Certificate:\tVALID\n")
+		}
+		if cert.NotAfter != nil {
+			fmt.Fprintf(w, "  Issuer:\t%s\n", cert.Issuer)
+			fmt.Fprintf(w, "  Expires At:\t%s\n", cert.NotAfter.Local().Format(time.DateTime))
+		}
+		if cert.LastError != nil {
+			fmt.Fprintf(w, "  Last Error:\t%s (%s)\n", cert.LastError.Error, cert.LastError.Time.Local().Format(time.DateTime))
+		}
+		w.Flush()
+
+		return nil
+	},
+}
+
+var domainsSetCertCmd = &cobra.Command{
+	Use:   "set-cert",
+	Short: "Upload TLS certificate for domain",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domainName := args[0]
+
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		if viper.GetBool("remove") {
+			if err := API().DeleteDomainCert(cmd.Context(), appID, domainName); err != nil {
+				return fmt.Errorf("failed to remove certificate: %w", err)
+			}
+			Info("Removed uploaded certificate of domain %q.", domainName)
+			return nil
+		}
+
+		certFile, keyFile := viper.GetString("cert"), viper.GetString("key")
+		if certFile == "" || keyFile == "" {
+			return fmt.Errorf("certificate and private key files are required")
+		}
+		certPEM, err := os.ReadFile(certFile)
+		if err != nil {
+			return fmt.Errorf("failed to read certificate: %w", err)
+		}
+		keyPEM, err := os.ReadFile(keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read private key: %w", err)
+		}
+
+		cert, err := API().SetDomainCert(cmd.Context(), appID, domainName, string(certPEM), string(keyPEM))
+		if err != nil {
+			return fmt.Errorf("failed to upload certificate: %w", err)
+		}
+
+		Info("Uploaded certificate of domain %q.", domainName)
+		w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+		fmt.Fprintf(w, "Issuer:\t%s\n", cert.Issuer)
+		fmt.Fprintf(w, "DNS Names:\t%s\n", strings.Join(cert.DNSNames, ", "))
+		if cert.NotAfter != nil {
+			fmt.Fprintf(w, "Expires At:\t%s\n", cert.NotAfter.Local().Format(time.DateTime))
+		}
+		w.Flush()
+
+		return nil
+	},
+}
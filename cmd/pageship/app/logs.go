@@ -0,0 +1,63 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.PersistentFlags().String("app", "", "app ID")
+	logsCmd.PersistentFlags().String("since", "", "show logs since duration ago (e.g. 30m) or timestamp (RFC3339); default to 1h")
+	logsCmd.PersistentFlags().String("until", "", "show logs until duration ago (e.g. 30m) or timestamp (RFC3339)")
+}
+
+func parseSinceTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show access logs of app",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		now := time.Now()
+		since, err := parseSinceTime(viper.GetString("since"), now)
+		if err != nil {
+			return fmt.Errorf("invalid since: %w", err)
+		}
+		until, err := parseSinceTime(viper.GetString("until"), now)
+		if err != nil {
+			return fmt.Errorf("invalid until: %w", err)
+		}
+
+		logs, err := API().GetAppLogs(cmd.Context(), appID, since, until)
+		if err != nil {
+			return fmt.Errorf("failed to get logs: %w", err)
+		}
+		defer logs.Close()
+
+		if _, err := io.Copy(os.Stdout, logs); err != nil {
+			return fmt.Errorf("failed to read logs: %w", err)
+		}
+		return nil
+	},
+}
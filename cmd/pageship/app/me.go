@@ -20,7 +20,7 @@ var meCmd = &cobra.Command{
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		if conf.AuthToken == "" {
+		if conf.AuthToken == "" && clientCertFile == "" {
 			Info("Logged out.")
 			return nil
 		}
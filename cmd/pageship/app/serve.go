@@ -11,6 +11,7 @@ import (
 	"github.com/caddyserver/certmagic"
 	"github.com/oursky/pageship/internal/command"
 	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/dnsprovider"
 	"github.com/oursky/pageship/internal/domain"
 	domainlocal "github.com/oursky/pageship/internal/domain/local"
 	handler "github.com/oursky/pageship/internal/handler/site"
@@ -31,6 +32,11 @@ func init() {
 	serveCmd.PersistentFlags().String("tls-addr", ":443", "TLS listen address")
 	serveCmd.PersistentFlags().String("tls-acme-endpoint", "", "TLS ACME directory endpoint")
 	serveCmd.PersistentFlags().String("tls-acme-email", "", "TLS ACME directory account email")
+	serveCmd.PersistentFlags().String("tls-acme-eab-key-id", "", "TLS ACME external account binding key ID")
+	serveCmd.PersistentFlags().String("tls-acme-eab-hmac-key", "", "TLS ACME external account binding HMAC key")
+	serveCmd.PersistentFlags().StringSlice("tls-acme-challenges", httputil.DefaultACMEChallenges, "TLS ACME challenge types")
+	serveCmd.PersistentFlags().String("tls-dns-provider", "", "TLS DNS provider URL for DNS-01 challenge")
+	serveCmd.PersistentFlags().Bool("http3", false, "serve HTTP/3 on TLS address")
 
 	serveCmd.PersistentFlags().String("default-site", config.DefaultSite, "default site")
 	serveCmd.PersistentFlags().String("host-pattern", config.DefaultHostPattern, "host match pattern")
@@ -47,7 +53,7 @@ func loadSitesConfig(fsys fs.FS) (*config.SitesConfig, error) {
 	return conf, nil
 }
 
-func makeHandler(prefix string, defaultSite string, hostPattern string) (*handler.Handler, error) {
+func makeHandler(prefix string, defaultSite string, hostPattern string, altSvc string) (*handler.Handler, error) {
 	dir, err := filepath.Abs(prefix)
 	if err != nil {
 		return nil, err
@@ -92,6 +98,7 @@ func makeHandler(prefix string, defaultSite string, hostPattern string) (*handle
 		handler.HandlerConfig{
 			HostPattern: hostPattern,
 			Middlewares: middleware.Default,
+			AltSvc:      altSvc,
 		})
 	if err != nil {
 		return nil, err
@@ -111,6 +118,11 @@ var serveCmd = &cobra.Command{
 		tlsAddr := viper.GetString("tls-addr")
 		tlsAcmeEndpoint := viper.GetString("tls-acme-endpoint")
 		tlsAcmeEmail := viper.GetString("tls-acme-email")
+		tlsAcmeEABKeyID := viper.GetString("tls-acme-eab-key-id")
+		tlsAcmeEABHMACKey := viper.GetString("tls-acme-eab-hmac-key")
+		tlsAcmeChallenges := viper.GetStringSlice("tls-acme-challenges")
+		tlsDNSProvider := viper.GetString("tls-dns-provider")
+		useHTTP3 := viper.GetBool("http3")
 
 		defaultSite := viper.GetString("default-site")
 		hostPattern := viper.GetString("host-pattern")
@@ -120,19 +132,34 @@ var serveCmd = &cobra.Command{
 			dir = args[0]
 		}
 
-		handler, err := makeHandler(dir, defaultSite, hostPattern)
+		var tls *httputil.ServerTLSConfig
+		if useTLS {
+			tls = &httputil.ServerTLSConfig{
+				Storage:        certmagic.Default.Storage,
+				ACMEDirectory:  tlsAcmeEndpoint,
+				ACMEEmail:      tlsAcmeEmail,
+				Addr:           tlsAddr,
+				ACMEEABKeyID:   tlsAcmeEABKeyID,
+				ACMEEABHMACKey: tlsAcmeEABHMACKey,
+				ACMEChallenges: tlsAcmeChallenges,
+				HTTP3:          useHTTP3,
+			}
+		}
+
+		handler, err := makeHandler(dir, defaultSite, hostPattern, tls.AltSvc())
 		if err != nil {
 			return fmt.Errorf("failed to setup server: %w", err)
 		}
 
-		var tls *httputil.ServerTLSConfig
-		if useTLS {
-			tls = &httputil.ServerTLSConfig{
-				Storage:       certmagic.Default.Storage,
-				ACMEDirectory: tlsAcmeEndpoint,
-				ACMEEmail:     tlsAcmeEmail,
-				Addr:          tlsAddr,
-				CheckDomain:   handler.CheckValidDomain,
+		if tls != nil {
+			tls.CheckDomain = handler.CheckValidDomain
+
+			if tlsDNSProvider != "" {
+				provider, err := dnsprovider.New(tlsDNSProvider)
+				if err != nil {
+					return fmt.Errorf("failed to setup DNS provider: %w", err)
+				}
+				tls.DNSProvider = provider
 			}
 
 			if len(tlsDomain) > 0 {
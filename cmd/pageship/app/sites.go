@@ -5,6 +5,7 @@ import (
 	"os"
 	"text/tabwriter"
 
+	"github.com/oursky/pageship/internal/models"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -12,6 +13,9 @@ import (
 func init() {
 	rootCmd.AddCommand(sitesCmd)
 	sitesCmd.PersistentFlags().String("app", "", "app ID")
+
+	sitesCmd.AddCommand(sitesStatsCmd)
+	sitesStatsCmd.PersistentFlags().Int("days", 30, "number of recent days")
 }
 
 var sitesCmd = &cobra.Command{
@@ -44,3 +48,66 @@ var sitesCmd = &cobra.Command{
 		return nil
 	},
 }
+
+var sitesStatsCmd = &cobra.Command{
+	Use:   "stats [site]",
+	Short: "Show visitor analytics of site",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		var siteName string
+		if len(args) > 0 {
+			siteName = args[0]
+		} else {
+			app, err := API().GetApp(cmd.Context(), appID)
+			if err != nil {
+				return fmt.Errorf("failed to get app: %w", err)
+			}
+			siteName = app.Config.DefaultSite
+		}
+
+		stats, err := API().GetSiteAnalytics(cmd.Context(), appID, siteName, viper.GetInt("days"))
+		if err != nil {
+			return fmt.Errorf("failed to get site analytics: %w", err)
+		}
+
+		var pageViews, visitors int64
+		for _, d := range stats.Daily {
+			pageViews += d.PageViews
+			visitors += d.Visitors
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+		fmt.Fprintf(w, "Site:\t%s\n", siteName)
+		fmt.Fprintf(w, "Since:\t%s\n", stats.Since)
+		fmt.Fprintf(w, "Page views:\t%d\n", pageViews)
+		fmt.Fprintf(w, "Daily visitors (sum):\t%d\n", visitors)
+
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "DATE\tPAGE VIEWS\tVISITORS")
+		for _, d := range stats.Daily {
+			fmt.Fprintf(w, "%s\t%d\t%d\n", d.Date, d.PageViews, d.Visitors)
+		}
+
+		printEntries := func(header string, entries []models.SiteAnalyticsEntry) {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, header)
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s\t%d\n", e.Key, e.Count)
+			}
+		}
+		printEntries("PATH\tVIEWS", stats.Paths)
+		printEntries("REFERRER\tVIEWS", stats.Referrers)
+		printEntries("STATUS\tREQUESTS", stats.Statuses)
+
+		w.Flush()
+		return nil
+	},
+}
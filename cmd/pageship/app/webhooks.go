@@ -0,0 +1,90 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(webhooksCmd)
+	webhooksCmd.PersistentFlags().String("app", "", "app ID")
+	webhooksCmd.PersistentFlags().Int("limit", 50, "max number of deliveries to show")
+
+	webhooksCmd.AddCommand(webhooksSecretCmd)
+	webhooksSecretCmd.PersistentFlags().Bool("rotate", false, "generate a new secret")
+}
+
+var webhooksCmd = &cobra.Command{
+	Use:   "webhooks",
+	Short: "Show recent webhook deliveries",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		deliveries, err := API().ListWebhookDeliveries(cmd.Context(), appID, viper.GetInt("limit"))
+		if err != nil {
+			return fmt.Errorf("failed to list webhook deliveries: %w", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+		fmt.Fprintln(w, "TIME\tEVENT\tURL\tSTATUS\tATTEMPTS\tRESPONSE\tERROR")
+		for _, d := range deliveries {
+			response := "-"
+			if d.ResponseStatus != nil {
+				response = strconv.Itoa(*d.ResponseStatus)
+			}
+			lastError := "-"
+			if d.LastError != nil {
+				lastError = *d.LastError
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+				d.CreatedAt.Local().Format(time.DateTime),
+				d.Event,
+				d.URL,
+				d.Status,
+				d.Attempts,
+				response,
+				lastError,
+			)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+var webhooksSecretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Show secret for verifying webhook signatures",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		rotate := viper.GetBool("rotate")
+		secret, err := API().GetWebhookSecret(cmd.Context(), appID, rotate)
+		if err != nil {
+			return fmt.Errorf("failed to get webhook secret: %w", err)
+		}
+
+		if rotate {
+			Info("Generated new webhook secret; pending deliveries would be signed with the new secret.")
+		}
+		fmt.Println(secret.Secret)
+		return nil
+	},
+}
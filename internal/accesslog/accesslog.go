@@ -0,0 +1,152 @@
+package accesslog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const commonTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	// FormatCommon is the Common Log Format.
+	FormatCommon Format = "common"
+)
+
+func (f Format) IsValid() bool {
+	switch f {
+	case FormatJSON, FormatCommon:
+		return true
+	}
+	return false
+}
+
+func (f Format) ext() string {
+	switch f {
+	case FormatJSON:
+		return ".jsonl"
+	default:
+		return ".log"
+	}
+}
+
+type Entry struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"requestID,omitempty"`
+	Site      string    `json:"site,omitempty"`
+	Host      string    `json:"host"`
+	Method    string    `json:"method"`
+	URI       string    `json:"uri"`
+	Proto     string    `json:"proto"`
+	Status    int       `json:"status"`
+	Bytes     int       `json:"bytes"`
+	Remote    string    `json:"remote"`
+	Referer   string    `json:"referer,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	// Elapsed is the request duration in seconds.
+	Elapsed float64 `json:"elapsed"`
+}
+
+func (e *Entry) Format(format Format) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		line, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		return append(line, '\n'), nil
+
+	case FormatCommon:
+		remote, _, err := net.SplitHostPort(e.Remote)
+		if err != nil {
+			remote = e.Remote
+		}
+		bytes := "-"
+		if e.Bytes > 0 {
+			bytes = strconv.Itoa(e.Bytes)
+		}
+		line := fmt.Sprintf("%s - - [%s] %q %d %s\n",
+			orDash(remote),
+			e.Time.Format(commonTimeLayout),
+			e.Method+" "+e.URI+" "+e.Proto,
+			e.Status,
+			bytes,
+		)
+		return []byte(line), nil
+
+	default:
+		return nil, fmt.Errorf("unknown access log format: %s", format)
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// parseTime parses the time of a formatted entry.
+func parseTime(format Format, line []byte) (time.Time, bool) {
+	switch format {
+	case FormatJSON:
+		var entry struct {
+			Time time.Time `json:"time"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return time.Time{}, false
+		}
+		return entry.Time, true
+
+	case FormatCommon:
+		start := bytes.IndexByte(line, '[')
+		end := bytes.IndexByte(line, ']')
+		if start == -1 || end < start {
+			return time.Time{}, false
+		}
+		t, err := time.Parse(commonTimeLayout, string(line[start+1:end]))
+		if err != nil {
+			return time.Time{}, false
+		}
+		return t, true
+
+	default:
+		return time.Time{}, false
+	}
+}
+
+// CopyEntries copies formatted entries from r to w, skipping entries with
+// time outside the time range. Lines that cannot be parsed are copied as is.
+func CopyEntries(w io.Writer, r io.Reader, format Format, since time.Time, until time.Time) error {
+	if format == FormatCommon {
+		// Common Log Format has time in seconds.
+		since = since.Truncate(time.Second)
+	}
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			t, ok := parseTime(format, line)
+			if !ok || (!t.Before(since) && !t.After(until)) {
+				if _, werr := w.Write(line); werr != nil {
+					return werr
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package accesslog_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/accesslog"
+	"github.com/oursky/pageship/internal/storage"
+	apptime "github.com/oursky/pageship/internal/time"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+var entry = &accesslog.Entry{
+	Time:    time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC),
+	Site:    "main",
+	Host:    "docs.example.com",
+	Method:  "GET",
+	URI:     "/guide/?q=1",
+	Proto:   "HTTP/1.1",
+	Status:  200,
+	Bytes:   1234,
+	Remote:  "10.0.0.1:54321",
+	Elapsed: 0.0125,
+}
+
+func TestFormat(t *testing.T) {
+	line, err := entry.Format(accesslog.FormatCommon)
+	assert.NoError(t, err)
+	assert.Equal(t, `10.0.0.1 - - [01/Mar/2024:12:30:45 +0000] "GET /guide/?q=1 HTTP/1.1" 200 1234`+"\n", string(line))
+
+	line, err = entry.Format(accesslog.FormatJSON)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"time": "2024-03-01T12:30:45Z",
+		"site": "main",
+		"host": "docs.example.com",
+		"method": "GET",
+		"uri": "/guide/?q=1",
+		"proto": "HTTP/1.1",
+		"status": 200,
+		"bytes": 1234,
+		"remote": "10.0.0.1:54321",
+		"elapsed": 0.0125
+	}`, string(line))
+}
+
+func TestExporter(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.New(ctx, "mem://")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := apptime.NewFakeClock(time.Date(2024, 3, 1, 23, 59, 0, 0, time.UTC))
+	e := &accesslog.Exporter{
+		Logger:    zap.NewNop(),
+		Clock:     clock,
+		Storage:   s,
+		KeyPrefix: "prefix/",
+		Format:    accesslog.FormatCommon,
+	}
+
+	e.Write("app", entry)
+	e.Write("other", entry)
+	assert.NoError(t, e.Flush(ctx))
+
+	clock.Advance(2 * time.Minute)
+	e.Write("app", entry)
+	e.Write("app", entry)
+	assert.NoError(t, e.Flush(ctx))
+
+	// No object written without logs
+	clock.Advance(2 * time.Minute)
+	assert.NoError(t, e.Flush(ctx))
+
+	read := func(keys []string) (lines int) {
+		for _, key := range keys {
+			r, err := s.OpenRead(ctx, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ := io.ReadAll(r)
+			r.Close()
+			for _, c := range data {
+				if c == '\n' {
+					lines++
+				}
+			}
+		}
+		return
+	}
+
+	keys, err := accesslog.List(ctx, s, "prefix/", "app",
+		time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC),
+		clock.Now())
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	assert.Regexp(t, `^prefix/app/\.logs/2024-03-01/235900\.000-[0-9a-f]+\.log$`, keys[0])
+	assert.Regexp(t, `^prefix/app/\.logs/2024-03-02/000100\.000-[0-9a-f]+\.log$`, keys[1])
+	assert.Equal(t, 3, read(keys))
+
+	keys, err = accesslog.List(ctx, s, "prefix/", "app",
+		time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+		clock.Now())
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Equal(t, 2, read(keys))
+}
+
+func TestExporterTimestamp(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.New(ctx, "mem://")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := apptime.NewFakeClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	e := &accesslog.Exporter{
+		Logger:  zap.NewNop(),
+		Clock:   clock,
+		Storage: s,
+		Format:  accesslog.FormatJSON,
+	}
+
+	entry := &accesslog.Entry{Method: "GET", URI: "/", Elapsed: 1.5}
+	e.Write("app", entry)
+	assert.Equal(t, time.Date(2024, 3, 1, 11, 59, 58, 500000000, time.UTC), entry.Time)
+}
+
+func TestCopyEntries(t *testing.T) {
+	at := func(min int) *accesslog.Entry {
+		e := *entry
+		e.Time = time.Date(2024, 3, 1, 12, min, 0, 0, time.UTC)
+		e.URI = fmt.Sprintf("/%d", min)
+		return &e
+	}
+	since := time.Date(2024, 3, 1, 12, 1, 0, 0, time.UTC)
+	until := time.Date(2024, 3, 1, 12, 3, 0, 0, time.UTC)
+
+	for _, format := range []accesslog.Format{accesslog.FormatJSON, accesslog.FormatCommon} {
+		var input bytes.Buffer
+		for _, min := range []int{0, 1, 2, 3, 4} {
+			line, err := at(min).Format(format)
+			assert.NoError(t, err)
+			input.Write(line)
+		}
+		input.WriteString("invalid\n")
+
+		var output strings.Builder
+		err := accesslog.CopyEntries(&output, &input, format, since, until)
+		assert.NoError(t, err)
+
+		lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+		if !assert.Len(t, lines, 4, format) {
+			continue
+		}
+		assert.Contains(t, lines[0], "/1")
+		assert.Contains(t, lines[1], "/2")
+		assert.Contains(t, lines[2], "/3")
+		assert.Equal(t, "invalid", lines[3])
+	}
+
+	assert.Equal(t, accesslog.FormatJSON, accesslog.KeyFormat("app/.logs/2024-03-01/120000.000-abcd.jsonl"))
+	assert.Equal(t, accesslog.FormatCommon, accesslog.KeyFormat("app/.logs/2024-03-01/120000.000-abcd.log"))
+}
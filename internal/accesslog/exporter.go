@@ -0,0 +1,220 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oursky/pageship/internal/storage"
+	apptime "github.com/oursky/pageship/internal/time"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxBatchSize is the buffered size of an app to trigger early flush.
+	maxBatchSize = 4 * 1024 * 1024
+
+	dateLayout = "2006-01-02"
+	timeLayout = "150405.000"
+)
+
+// Exporter buffers access logs by app, and writes them in batches to object
+// storage under per-app prefix. Each flush of an app writes a new object, keyed
+// by the flush time; entries are flushed within FlushInterval after written:
+//
+//	<prefix><app>/.logs/<YYYY-MM-DD>/<HHMMSS.sss>-<random>.<jsonl|log>
+type Exporter struct {
+	Logger        *zap.Logger
+	Clock         apptime.Clock
+	Storage       *storage.Storage
+	KeyPrefix     string
+	Format        Format
+	FlushInterval time.Duration
+
+	mutex   sync.Mutex
+	buffers map[string]*bytes.Buffer
+	full    chan struct{}
+}
+
+func (e *Exporter) clock() apptime.Clock {
+	if e.Clock == nil {
+		return apptime.SystemClock
+	}
+	return e.Clock
+}
+
+func (e *Exporter) fullCh() chan struct{} {
+	if e.full == nil {
+		e.full = make(chan struct{}, 1)
+	}
+	return e.full
+}
+
+// Write buffers an access log entry of the app. Entry without time is
+// timestamped with the request start time, using clock of the exporter.
+func (e *Exporter) Write(app string, entry *Entry) {
+	if entry.Time.IsZero() {
+		elapsed := time.Duration(entry.Elapsed * float64(time.Second))
+		entry.Time = e.clock().Now().Add(-elapsed)
+	}
+
+	line, err := entry.Format(e.Format)
+	if err != nil {
+		e.Logger.Warn("failed to format access log", zap.Error(err))
+		return
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.buffers == nil {
+		e.buffers = make(map[string]*bytes.Buffer)
+	}
+	buf, ok := e.buffers[app]
+	if !ok {
+		buf = new(bytes.Buffer)
+		e.buffers[app] = buf
+	}
+	buf.Write(line)
+
+	if buf.Len() >= maxBatchSize {
+		select {
+		case e.fullCh() <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *Exporter) Run(ctx context.Context) error {
+	e.mutex.Lock()
+	full := e.fullCh()
+	e.mutex.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			e.flush(ctx)
+			return nil
+
+		case <-full:
+			e.flush(ctx)
+
+		case <-e.clock().After(e.FlushInterval):
+			e.flush(ctx)
+		}
+	}
+}
+
+func (e *Exporter) flush(ctx context.Context) {
+	if err := e.Flush(ctx); err != nil {
+		e.Logger.Error("failed to export access logs", zap.Error(err))
+	}
+}
+
+// Flush writes buffered logs to storage. Buffered logs are discarded even if
+// failed to write.
+func (e *Exporter) Flush(ctx context.Context) error {
+	e.mutex.Lock()
+	buffers := e.buffers
+	e.buffers = nil
+	e.mutex.Unlock()
+
+	now := e.clock().Now()
+	var errs []error
+	for app, buf := range buffers {
+		key := objectKey(e.KeyPrefix, app, now, e.Format)
+		if err := e.Storage.Upload(ctx, key, buf); err != nil {
+			e.Logger.Warn("failed to upload access logs",
+				zap.String("app", app),
+				zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// Prefix returns the storage key prefix of access logs of the app.
+func Prefix(keyPrefix string, appID string) string {
+	return keyPrefix + appID + "/.logs/"
+}
+
+// KeyFormat returns the format of access log object by its key.
+func KeyFormat(key string) Format {
+	if strings.HasSuffix(key, FormatJSON.ext()) {
+		return FormatJSON
+	}
+	return FormatCommon
+}
+
+func objectKey(keyPrefix string, appID string, t time.Time, format Format) string {
+	t = t.UTC()
+	return Prefix(keyPrefix, appID) +
+		t.Format(dateLayout) + "/" +
+		t.Format(timeLayout) + "-" + randomSuffix() + format.ext()
+}
+
+func randomSuffix() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// objectTime returns the flush time of the object.
+func objectTime(prefix string, key string) (time.Time, bool) {
+	date, name, ok := strings.Cut(strings.TrimPrefix(key, prefix), "/")
+	if !ok {
+		return time.Time{}, false
+	}
+	ts, _, ok := strings.Cut(name, "-")
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(dateLayout+" "+timeLayout, date+" "+ts)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// List returns storage keys of access log objects of the app flushed within
+// the time range, in chronological order. Objects may contain entries outside
+// the time range; use CopyEntries to filter them.
+func List(ctx context.Context, s *storage.Storage, keyPrefix string, appID string, since time.Time, until time.Time) ([]string, error) {
+	prefix := Prefix(keyPrefix, appID)
+	since, until = since.UTC(), until.UTC()
+
+	var keys []string
+	day := since.Truncate(24 * time.Hour)
+	for !day.After(until) {
+		dayKeys, err := s.List(ctx, prefix+day.Format(dateLayout)+"/")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range dayKeys {
+			t, ok := objectTime(prefix, key)
+			if !ok || t.Before(since) || t.After(until) {
+				continue
+			}
+			keys = append(keys, key)
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,270 @@
+package analytics
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+	apptime "github.com/oursky/pageship/internal/time"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxKeys limits distinct keys of a kind buffered per site between
+	// flushes; excess keys are counted as OtherKey.
+	maxKeys = 1000
+	// maxVisitors limits distinct visitors buffered per site between flushes.
+	maxVisitors = 100000
+
+	OtherKey = "(other)"
+)
+
+// Collector aggregates page view counts of sites in memory, and flushes them
+// to database periodically.
+//
+// Visitors are identified by IP address hashed with a random salt of the day;
+// raw IP addresses are kept in memory only until flush, and salts are deleted
+// after the day passed, so the hashes cannot be linked across days.
+type Collector struct {
+	Logger        *zap.Logger
+	Clock         apptime.Clock
+	DB            db.DB
+	FlushInterval time.Duration
+
+	mutex   sync.Mutex
+	buckets map[bucketKey]*bucket
+	salts   map[string]string
+}
+
+type bucketKey struct {
+	app  string
+	site string
+	date string
+}
+
+type bucket struct {
+	paths     map[string]int64
+	referrers map[string]int64
+	statuses  map[string]int64
+	visitors  map[string]int64
+}
+
+func newBucket() *bucket {
+	return &bucket{
+		paths:     make(map[string]int64),
+		referrers: make(map[string]int64),
+		statuses:  make(map[string]int64),
+		visitors:  make(map[string]int64),
+	}
+}
+
+func count(m map[string]int64, key string, limit int) {
+	if _, ok := m[key]; !ok && len(m) >= limit {
+		key = OtherKey
+	}
+	m[key]++
+}
+
+func (c *Collector) clock() apptime.Clock {
+	if c.Clock == nil {
+		return apptime.SystemClock
+	}
+	return c.Clock
+}
+
+// Record records a request served by a site. Only GET & HEAD requests are
+// counted; paths, referrers & visitors are counted for page views (i.e.
+// successful HTML responses) only. The path is the requested path, before
+// rewritten by site handlers.
+func (c *Collector) Record(app string, site string, r *http.Request, path string, status int, header http.Header) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	key := bucketKey{app: app, site: site, date: models.SiteAnalyticsDate(c.clock().Now())}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.buckets == nil {
+		c.buckets = make(map[bucketKey]*bucket)
+	}
+	b, ok := c.buckets[key]
+	if !ok {
+		b = newBucket()
+		c.buckets[key] = b
+	}
+
+	count(b.statuses, strconv.Itoa(status), maxKeys)
+
+	if !isPageView(path, status, header) {
+		return
+	}
+
+	count(b.paths, path, maxKeys)
+	if referrer := externalReferrer(r); referrer != "" {
+		count(b.referrers, referrer, maxKeys)
+	}
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		count(b.visitors, ip, maxVisitors)
+	}
+}
+
+func isPageView(urlPath string, status int, header http.Header) bool {
+	switch {
+	case status == http.StatusNotModified:
+		// Content type is not sent for not modified responses.
+		ext := path.Ext(urlPath)
+		return ext == "" || ext == ".html" || ext == ".htm"
+	case status >= 200 && status < 300:
+		mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+		return mediaType == "text/html"
+	default:
+		return false
+	}
+}
+
+// externalReferrer returns the referrer host if it is not the site itself.
+func externalReferrer(r *http.Request) string {
+	ref, err := url.Parse(r.Referer())
+	if err != nil || ref.Hostname() == "" {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	if ref.Hostname() == host {
+		return ""
+	}
+	return ref.Hostname()
+}
+
+func (c *Collector) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			c.flush(ctx)
+			return nil
+
+		case <-c.clock().After(c.FlushInterval):
+			c.flush(ctx)
+		}
+	}
+}
+
+func (c *Collector) flush(ctx context.Context) {
+	if err := c.Flush(ctx); err != nil {
+		c.Logger.Error("failed to flush analytics", zap.Error(err))
+	}
+}
+
+// Flush writes buffered counts to database. Buffered counts are discarded
+// even if failed to write.
+func (c *Collector) Flush(ctx context.Context) error {
+	c.mutex.Lock()
+	buckets := c.buckets
+	c.buckets = nil
+	c.mutex.Unlock()
+
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	var counts []models.SiteAnalyticsCount
+	for key, b := range buckets {
+		salt, err := c.salt(ctx, key.date)
+		if err != nil {
+			return err
+		}
+
+		add := func(kind models.SiteAnalyticsKind, m map[string]int64) {
+			for k, n := range m {
+				counts = append(counts, models.SiteAnalyticsCount{
+					AppID:    key.app,
+					SiteName: key.site,
+					Date:     key.date,
+					Kind:     kind,
+					Key:      k,
+					Count:    n,
+				})
+			}
+		}
+		add(models.SiteAnalyticsPath, b.paths)
+		add(models.SiteAnalyticsReferrer, b.referrers)
+		add(models.SiteAnalyticsStatus, b.statuses)
+
+		visitors := make(map[string]int64, len(b.visitors))
+		for ip, n := range b.visitors {
+			if ip != OtherKey {
+				ip = hashVisitor(salt, ip)
+			}
+			visitors[ip] += n
+		}
+		add(models.SiteAnalyticsVisitor, visitors)
+	}
+
+	return db.WithTx(ctx, c.DB, func(tx db.Tx) error {
+		return tx.AddSiteAnalyticsCounts(ctx, counts)
+	})
+}
+
+// salt returns the salt of the date, shared by all servers through database.
+func (c *Collector) salt(ctx context.Context, date string) (string, error) {
+	if salt, ok := c.salts[date]; ok {
+		return salt, nil
+	}
+
+	salt, err := c.DB.GetOrCreateAnalyticsSalt(ctx, date, generateSalt())
+	if err != nil {
+		return "", err
+	}
+
+	// Salts of previous day are retained for late flushes from other
+	// servers.
+	now := c.clock().Now()
+	retainSince := models.SiteAnalyticsDate(now.AddDate(0, 0, -1))
+	if err := c.DB.DeleteAnalyticsSalts(ctx, retainSince); err != nil {
+		return "", err
+	}
+
+	salts := map[string]string{date: salt}
+	for d, s := range c.salts {
+		if d >= retainSince {
+			salts[d] = s
+		}
+	}
+	c.salts = salts
+	return salt, nil
+}
+
+func generateSalt() string {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		panic(err)
+	}
+	return base64.RawStdEncoding.EncodeToString(salt)
+}
+
+func hashVisitor(salt string, ip string) string {
+	h := sha256.Sum256([]byte(salt + ":" + ip))
+	return hex.EncodeToString(h[:16])
+}
@@ -0,0 +1,110 @@
+package analytics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/analytics"
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/db/dbtest"
+	"github.com/oursky/pageship/internal/models"
+	apptime "github.com/oursky/pageship/internal/time"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDB struct {
+	db.Tx
+	counts       []models.SiteAnalyticsCount
+	salts        map[string]string
+	deleteBefore string
+}
+
+func (d *mockDB) AddSiteAnalyticsCounts(ctx context.Context, counts []models.SiteAnalyticsCount) error {
+	d.counts = append(d.counts, counts...)
+	return nil
+}
+
+func (d *mockDB) GetOrCreateAnalyticsSalt(ctx context.Context, date string, salt string) (string, error) {
+	if s, ok := d.salts[date]; ok {
+		return s, nil
+	}
+	d.salts[date] = salt
+	return salt, nil
+}
+
+func (d *mockDB) DeleteAnalyticsSalts(ctx context.Context, before string) error {
+	d.deleteBefore = before
+	return nil
+}
+
+func (d *mockDB) sum(kind models.SiteAnalyticsKind) map[string]int64 {
+	result := make(map[string]int64)
+	for _, c := range d.counts {
+		if c.Kind == kind {
+			result[c.Key] += c.Count
+		}
+	}
+	return result
+}
+
+func request(method string, path string, ip string, referrer string) *http.Request {
+	r := httptest.NewRequest(method, "http://docs.example.com"+path, nil)
+	r.RemoteAddr = ip + ":12345"
+	if referrer != "" {
+		r.Header.Set("Referer", referrer)
+	}
+	return r
+}
+
+func TestCollector(t *testing.T) {
+	clock := apptime.NewFakeClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	database := &mockDB{salts: map[string]string{}}
+	c := &analytics.Collector{Clock: clock, DB: &dbtest.DB{Tx: database}}
+
+	html := http.Header{"Content-Type": []string{"text/html; charset=utf-8"}}
+	css := http.Header{"Content-Type": []string{"text/css"}}
+
+	c.Record("app", "main", request("GET", "/", "10.0.0.1", "https://www.google.com/search"), "/", 0, html)
+	c.Record("app", "main", request("GET", "/guide/", "10.0.0.1", "http://docs.example.com/"), "/guide/", 200, html)
+	c.Record("app", "main", request("GET", "/", "10.0.0.2", ""), "/", 304, http.Header{})
+	c.Record("app", "main", request("GET", "/style.css", "10.0.0.2", ""), "/style.css", 200, css)
+	c.Record("app", "main", request("GET", "/missing", "10.0.0.3", ""), "/missing", 404, html)
+	c.Record("app", "main", request("POST", "/", "10.0.0.3", ""), "/", 200, html)
+
+	assert.NoError(t, c.Flush(context.Background()))
+
+	assert.Equal(t, map[string]int64{"/": 2, "/guide/": 1}, database.sum(models.SiteAnalyticsPath))
+	assert.Equal(t, map[string]int64{"www.google.com": 1}, database.sum(models.SiteAnalyticsReferrer))
+	assert.Equal(t, map[string]int64{"200": 3, "304": 1, "404": 1}, database.sum(models.SiteAnalyticsStatus))
+
+	visitors := database.sum(models.SiteAnalyticsVisitor)
+	assert.Len(t, visitors, 2)
+	for key := range visitors {
+		assert.NotContains(t, key, "10.0.0.")
+	}
+	for _, count := range database.counts {
+		assert.Equal(t, "2024-03-01", count.Date)
+	}
+	assert.Equal(t, "2024-02-29", database.deleteBefore)
+
+	// Same visitor hashes within the day
+	database.counts = nil
+	c.Record("app", "main", request("GET", "/", "10.0.0.1", ""), "/", 200, html)
+	assert.NoError(t, c.Flush(context.Background()))
+	for key := range database.sum(models.SiteAnalyticsVisitor) {
+		assert.Contains(t, visitors, key)
+	}
+
+	// Different visitor hashes on next day
+	database.counts = nil
+	clock.Advance(24 * time.Hour)
+	c.Record("app", "main", request("GET", "/", "10.0.0.1", ""), "/", 200, html)
+	assert.NoError(t, c.Flush(context.Background()))
+	for key := range database.sum(models.SiteAnalyticsVisitor) {
+		assert.NotContains(t, visitors, key)
+	}
+	assert.Equal(t, "2024-03-01", database.deleteBefore)
+}
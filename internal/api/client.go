@@ -2,11 +2,14 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/models"
@@ -140,6 +143,141 @@ func (c *Client) GetApp(ctx context.Context, id string) (*APIApp, error) {
 	return decodeJSONResponse[*APIApp](resp)
 }
 
+// GetAppLogs streams exported access logs of the app flushed within the time
+// range; zero time means the server default.
+func (c *Client) GetAppLogs(ctx context.Context, appID string, since time.Time, until time.Time) (io.ReadCloser, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "logs")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	if !since.IsZero() {
+		query.Set("since", since.UTC().Format(time.RFC3339))
+	}
+	if !until.IsZero() {
+		query.Set("until", until.UTC().Format(time.RFC3339))
+	}
+	req.URL.RawQuery = query.Encode()
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		_, err := decodeJSONResponse[any](resp)
+		if err == nil {
+			err = HTTPStatusCodeError{Status: resp.Status, Code: resp.StatusCode}
+		}
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// ListAuditEvents lists audit events of the app; all apps are included if
+// app ID is empty, which requires server admin access.
+func (c *Client) ListAuditEvents(ctx context.Context, appID string, query AuditQuery) (*APIAuditEvents, error) {
+	var endpoint string
+	var err error
+	if appID != "" {
+		endpoint, err = url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "audit")
+	} else {
+		endpoint, err = url.JoinPath(c.endpoint, "api", "v1", "admin", "audit")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	if !query.Since.IsZero() {
+		values.Set("since", query.Since.UTC().Format(time.RFC3339))
+	}
+	if !query.Until.IsZero() {
+		values.Set("until", query.Until.UTC().Format(time.RFC3339))
+	}
+	if query.After != "" {
+		values.Set("after", query.After)
+	}
+	req.URL.RawQuery = values.Encode()
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*APIAuditEvents](resp)
+}
+
+func (c *Client) ListWebhookDeliveries(ctx context.Context, appID string, limit int) ([]models.WebhookDelivery, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "webhooks", "deliveries")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = url.Values{"limit": []string{strconv.Itoa(limit)}}.Encode()
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[[]models.WebhookDelivery](resp)
+}
+
+// GetWebhookSecret returns the webhook signing secret of the app; a new
+// secret is generated if rotate is true.
+func (c *Client) GetWebhookSecret(ctx context.Context, appID string, rotate bool) (*APIWebhookSecret, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "webhooks", "secret")
+	if err != nil {
+		return nil, err
+	}
+
+	method := "GET"
+	if rotate {
+		method = "POST"
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*APIWebhookSecret](resp)
+}
+
 func (c *Client) ListUsers(ctx context.Context, appID string) ([]APIUser, error) {
 	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "users")
 	if err != nil {
@@ -320,6 +458,34 @@ func (c *Client) UpdateSite(
 	return decodeJSONResponse[*APISite](resp)
 }
 
+func (c *Client) GetSiteAnalytics(ctx context.Context, appID string, siteName string, days int) (*APISiteAnalytics, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "sites", siteName, "analytics")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if days > 0 {
+		req.URL.RawQuery = url.Values{
+			"days": []string{strconv.Itoa(days)},
+		}.Encode()
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*APISiteAnalytics](resp)
+}
+
 func (c *Client) GetDeployment(ctx context.Context, appID string, deploymentName string) (*APIDeployment, error) {
 	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments", deploymentName)
 	if err != nil {
@@ -343,6 +509,79 @@ func (c *Client) GetDeployment(ctx context.Context, appID string, deploymentName
 	return decodeJSONResponse[*APIDeployment](resp)
 }
 
+func (c *Client) RenameDeployment(ctx context.Context, appID string, deploymentName string, newName string, replace bool) (*APIDeployment, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments", deploymentName)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newJSONRequest(ctx, "PATCH", endpoint, map[string]any{
+		"name":    newName,
+		"replace": replace,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*APIDeployment](resp)
+}
+
+func (c *Client) DeleteDeployment(ctx context.Context, appID string, deploymentName string) error {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments", deploymentName)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if err := c.attachToken(req); err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = decodeJSONResponse[struct{}](resp)
+	return err
+}
+
+func (c *Client) GetDeploymentFiles(ctx context.Context, appID string, deploymentName string) ([]models.FileEntry, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments", deploymentName, "files")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[[]models.FileEntry](resp)
+}
+
 func (c *Client) ListDeployments(ctx context.Context, appID string) ([]APIDeployment, error) {
 	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments")
 	if err != nil {
@@ -372,17 +611,27 @@ func (c *Client) SetupDeployment(
 	name string,
 	files []models.FileEntry,
 	siteConfig *config.SiteConfig,
-) (*models.Deployment, error) {
+	signature *models.DeploymentSignature,
+	directUpload bool,
+) (*APIDeploymentSetup, error) {
 	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments")
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := newJSONRequest(ctx, "POST", endpoint, map[string]any{
+	body := map[string]any{
 		"name":        name,
 		"files":       files,
 		"site_config": siteConfig,
-	})
+	}
+	if signature != nil {
+		body["signature"] = signature
+	}
+	if directUpload {
+		body["direct_upload"] = true
+	}
+
+	req, err := newJSONRequest(ctx, "POST", endpoint, body)
 	if err != nil {
 		return nil, err
 	}
@@ -396,7 +645,7 @@ func (c *Client) SetupDeployment(
 	}
 	defer resp.Body.Close()
 
-	return decodeJSONResponse[*models.Deployment](resp)
+	return decodeJSONResponse[*APIDeploymentSetup](resp)
 }
 
 func (c *Client) UploadDeploymentTarball(
@@ -429,6 +678,168 @@ func (c *Client) UploadDeploymentTarball(
 	return decodeJSONResponse[*models.Deployment](resp)
 }
 
+func (c *Client) GetDeploymentUploadOffset(ctx context.Context, appID string, deploymentName string) (int64, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments", deploymentName, "upload")
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return 0, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	upload, err := decodeJSONResponse[APIDeploymentUpload](resp)
+	if err != nil {
+		return 0, err
+	}
+	return upload.Offset, nil
+}
+
+func (c *Client) UploadDeploymentChunk(
+	ctx context.Context,
+	appID string,
+	deploymentName string,
+	chunk io.Reader,
+	offset int64,
+	size int64,
+	totalSize int64,
+) (int64, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments", deploymentName, "upload")
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", endpoint, chunk)
+	if err != nil {
+		return 0, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return 0, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+size-1, totalSize))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	upload, err := decodeJSONResponse[APIDeploymentUpload](resp)
+	if err != nil {
+		return 0, err
+	}
+	return upload.Offset, nil
+}
+
+func (c *Client) CompleteDeploymentUpload(ctx context.Context, appID string, deploymentName string) (*models.Deployment, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments", deploymentName, "upload", "complete")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*models.Deployment](resp)
+}
+
+func (c *Client) GetDeploymentUploadURLs(ctx context.Context, appID string, deploymentName string) (*APIDeploymentUploadURLs, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments", deploymentName, "upload-urls")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*APIDeploymentUploadURLs](resp)
+}
+
+// UploadFile uploads file content to an upload URL issued by the server;
+// the URL is self-authorizing so no token is attached. The MD5 checksum, if
+// known, is sent as Content-MD5 header as required by presigned URLs.
+func (c *Client) UploadFile(ctx context.Context, uploadURL string, content io.Reader, size int64, contentMD5 string) error {
+	req, err := http.NewRequestWithContext(ctx, "PUT", uploadURL, content)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentMD5 != "" {
+		req.Header.Set("Content-MD5", contentMD5)
+	}
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return HTTPStatusCodeError{Status: resp.Status, Code: resp.StatusCode}
+	}
+	return nil
+}
+
+func (c *Client) FinalizeDeployment(ctx context.Context, appID string, deploymentName string) (*models.Deployment, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments", deploymentName, "finalize")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*models.Deployment](resp)
+}
+
 func (c *Client) ListDomains(ctx context.Context, appID string) ([]APIDomain, error) {
 	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "domains")
 	if err != nil {
@@ -503,6 +914,102 @@ func (c *Client) DeleteDomain(ctx context.Context, appID string, domainName stri
 	return decodeJSONResponse[*APIDomain](resp)
 }
 
+func (c *Client) VerifyDomain(ctx context.Context, appID string, domainName string) (*APIDomainVerification, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "domains", domainName, "verify")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*APIDomainVerification](resp)
+}
+
+func (c *Client) CheckDomain(ctx context.Context, appID string, domainName string) (*APIDomainCheck, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "domains", domainName, "check")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*APIDomainCheck](resp)
+}
+
+func (c *Client) SetDomainCert(ctx context.Context, appID string, domainName string, certPEM string, keyPEM string) (*APIDomainCheckCertificate, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "domains", domainName, "cert")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newJSONRequest(ctx, "PUT", endpoint, map[string]any{
+		"certificate": certPEM,
+		"privateKey":  keyPEM,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*APIDomainCheckCertificate](resp)
+}
+
+func (c *Client) DeleteDomainCert(ctx context.Context, appID string, domainName string) error {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "domains", domainName, "cert")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if err := c.attachToken(req); err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = decodeJSONResponse[struct{}](resp)
+	return err
+}
+
 func (c *Client) OpenAuthGitHubSSH(ctx context.Context) (*websocket.Conn, error) {
 	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "auth", "github-ssh")
 	if err != nil {
@@ -23,6 +23,8 @@ func (e HTTPStatusCodeError) StatusCode() int {
 type ServerError struct {
 	Message string
 	Code    int
+	// ErrorCode is the machine-readable error code; empty if unspecified.
+	ErrorCode string
 }
 
 func (e ServerError) Error() string {
@@ -40,6 +42,13 @@ func ErrorStatusCode(err error) (int, bool) {
 	return 0, false
 }
 
+// IsErrorCode checks whether the error is a server error with the
+// machine-readable error code.
+func IsErrorCode(err error, code string) bool {
+	var e ServerError
+	return errors.As(err, &e) && e.ErrorCode == code
+}
+
 func newJSONRequest(ctx context.Context, method string, endpoint string, v any) (*http.Request, error) {
 	body := new(bytes.Buffer)
 	err := json.NewEncoder(body).Encode(v)
@@ -58,8 +67,9 @@ func newJSONRequest(ctx context.Context, method string, endpoint string, v any)
 
 func decodeJSONResponse[T any](resp *http.Response) (result T, err error) {
 	type response struct {
-		Error  *string `json:"error"`
-		Result T       `json:"result"`
+		Error     *string `json:"error"`
+		ErrorCode string  `json:"code"`
+		Result    T       `json:"result"`
 	}
 	if resp.StatusCode != http.StatusOK && (resp.StatusCode < 400 || resp.StatusCode >= 500) {
 		err = HTTPStatusCodeError{Status: resp.Status, Code: resp.StatusCode}
@@ -75,7 +85,7 @@ func decodeJSONResponse[T any](resp *http.Response) (result T, err error) {
 	}
 
 	if v.Error != nil {
-		err = ServerError{Message: *v.Error, Code: resp.StatusCode}
+		err = ServerError{Message: *v.Error, Code: resp.StatusCode, ErrorCode: v.ErrorCode}
 		return
 	}
 	result = v.Result
@@ -1,12 +1,15 @@
 package api
 
 import (
+	"time"
+
 	"github.com/oursky/pageship/internal/models"
 )
 
 type APIManifest struct {
 	Version             string `json:"version"`
 	CustomDomainMessage string `json:"customDomainMessage,omitempty"`
+	MaxDeploymentSize   int64  `json:"maxDeploymentSize,omitempty"`
 }
 
 type APIApp struct {
@@ -20,16 +23,93 @@ type APISite struct {
 	DeploymentName *string `json:"deploymentName"`
 }
 
+type APISiteAnalytics struct {
+	Since     string                      `json:"since"`
+	Daily     []models.SiteAnalyticsDaily `json:"daily"`
+	Paths     []models.SiteAnalyticsEntry `json:"paths"`
+	Referrers []models.SiteAnalyticsEntry `json:"referrers"`
+	Statuses  []models.SiteAnalyticsEntry `json:"statuses"`
+}
+
+type APIAuditEvents struct {
+	Events []models.AuditEvent `json:"events"`
+	Next   string              `json:"next,omitempty"`
+}
+
+type APIWebhookSecret struct {
+	Secret string `json:"secret"`
+}
+
+type AuditQuery struct {
+	Since time.Time
+	Until time.Time
+	// After is the cursor returned by previous page.
+	After string
+}
+
 type APIDeployment struct {
 	*models.Deployment
 	SiteName *string `json:"siteName"`
 	URL      *string `json:"url"`
 }
 
+type APIDeploymentUpload struct {
+	Offset int64 `json:"offset"`
+}
+
+type APIDeploymentSetup struct {
+	*models.Deployment
+	// UploadURLs is set if direct upload is requested.
+	UploadURLs *APIDeploymentUploadURLs `json:"uploadURLs"`
+}
+
+type APIDeploymentUploadURLs struct {
+	URLs     map[string]string `json:"urls"`
+	ExpireAt time.Time         `json:"expireAt"`
+}
+
 type APIDomain struct {
 	*models.Domain
 }
 
+type APIDomainCheck struct {
+	Domain         string                    `json:"domain"`
+	Active         bool                      `json:"active"`
+	AppID          string                    `json:"appID,omitempty"`
+	SiteName       string                    `json:"siteName,omitempty"`
+	Verified       bool                      `json:"verified"`
+	DeploymentName *string                   `json:"deploymentName"`
+	DNS            APIDomainCheckDNS         `json:"dns"`
+	Certificate    APIDomainCheckCertificate `json:"certificate"`
+}
+
+type APIDomainCheckDNS struct {
+	CNAME             string   `json:"cname,omitempty"`
+	Addresses         []string `json:"addresses"`
+	ExpectedAddresses []string `json:"expectedAddresses"`
+	PointsToServer    bool     `json:"pointsToServer"`
+	Error             string   `json:"error,omitempty"`
+}
+
+type APIDomainCheckCertificate struct {
+	Managed   bool       `json:"managed"`
+	Custom    bool       `json:"custom"`
+	Issuer    string     `json:"issuer,omitempty"`
+	DNSNames  []string   `json:"dnsNames,omitempty"`
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+	NotAfter  *time.Time `json:"notAfter,omitempty"`
+	LastError *struct {
+		Time    time.Time `json:"time"`
+		Renewal bool      `json:"renewal"`
+		Error   string    `json:"error"`
+	} `json:"lastError,omitempty"`
+}
+
+type APIDomainVerification struct {
+	*models.DomainVerification
+	RecordName string `json:"recordName"`
+}
+
 type APIUser struct {
 	ID          string                `json:"id"`
 	Name        string                `json:"name"`
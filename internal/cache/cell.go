@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"context"
 	"sync"
 	"time"
 )
@@ -8,7 +9,7 @@ import (
 type TTLCell[T any] struct {
 	id   string
 	ttl  time.Duration
-	load func(id string) (T, error)
+	load func(ctx context.Context, id string) (T, error)
 
 	m        sync.RWMutex
 	expireAt time.Time
@@ -20,16 +21,16 @@ type TTLCell[T any] struct {
 func NewTTLCell[T any](
 	id string,
 	ttl time.Duration,
-	load func(id string) (T, error),
+	load func(ctx context.Context, id string) (T, error),
 ) *TTLCell[T] {
 	return &TTLCell[T]{id: id, ttl: ttl, load: load}
 }
 
-func (c *TTLCell[T]) Load() (T, error) {
+func (c *TTLCell[T]) Load(ctx context.Context) (T, error) {
 	if value, err, ok := c.loadCached(); ok {
 		return value, err
 	}
-	return c.loadNew()
+	return c.loadNew(ctx)
 }
 
 func (c *TTLCell[T]) checkCachedValue() (value T, err error, ok bool) {
@@ -49,7 +50,7 @@ func (c *TTLCell[T]) loadCached() (T, error, bool) {
 	return c.checkCachedValue()
 }
 
-func (c *TTLCell[T]) loadNew() (T, error) {
+func (c *TTLCell[T]) loadNew(ctx context.Context) (T, error) {
 	c.m.Lock()
 	defer c.m.Unlock()
 
@@ -57,7 +58,7 @@ func (c *TTLCell[T]) loadNew() (T, error) {
 		return value, err
 	}
 
-	c.value, c.err = c.load(c.id)
+	c.value, c.err = c.load(ctx, c.id)
 	c.loaded = true
 	c.expireAt = time.Now().Add(cacheTTL)
 	return c.value, c.err
@@ -1,10 +1,12 @@
 package cache
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"github.com/hashicorp/golang-lru/v2/simplelru"
+	"github.com/oursky/pageship/internal/metrics"
 )
 
 const (
@@ -13,19 +15,21 @@ const (
 )
 
 type Cache[T any] struct {
+	name  string
 	m     sync.Mutex
 	ttl   time.Duration
 	cache *simplelru.LRU[string, *TTLCell[T]]
-	load  func(id string) (T, error)
+	load  func(ctx context.Context, id string) (T, error)
 }
 
-func NewCache[T any](size int, ttl time.Duration, load func(id string) (T, error)) (*Cache[T], error) {
+// NewCache creates a cache; name identifies the cache in metrics.
+func NewCache[T any](name string, size int, ttl time.Duration, load func(ctx context.Context, id string) (T, error)) (*Cache[T], error) {
 	cache, err := simplelru.NewLRU[string, *TTLCell[T]](cacheSize, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Cache[T]{cache: cache, ttl: ttl, load: load}, nil
+	return &Cache[T]{name: name, cache: cache, ttl: ttl, load: load}, nil
 }
 
 func (c *Cache[T]) getCell(id string) *TTLCell[T] {
@@ -42,7 +46,14 @@ func (c *Cache[T]) getCell(id string) *TTLCell[T] {
 	return ce
 }
 
-func (c *Cache[T]) Load(id string) (T, error) {
+// Load loads the cached value, or loads it if not cached. Loaded value is
+// shared by concurrent callers, so load is not cancelled with ctx.
+func (c *Cache[T]) Load(ctx context.Context, id string) (T, error) {
 	cell := c.getCell(id)
-	return cell.Load()
+	if value, err, ok := cell.loadCached(); ok {
+		metrics.ObserveCache(c.name, true)
+		return value, err
+	}
+	metrics.ObserveCache(c.name, false)
+	return cell.loadNew(context.WithoutCancel(ctx))
 }
@@ -2,7 +2,10 @@ package command
 
 import (
 	"context"
+	"time"
 
+	"github.com/oursky/pageship/internal/metrics"
+	"github.com/oursky/pageship/internal/tracing"
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 )
@@ -32,17 +35,24 @@ func (s *CronRunner) Run(ctx context.Context) error {
 
 		jobLogger := s.Logger.Named(jobName)
 		c.AddFunc(schedule, func() {
+			start := time.Now()
 			defer func() {
 				if err := recover(); err != nil {
 					if err != nil {
 						jobLogger.Error("job panic", zap.Any("error", err))
+						metrics.ObserveCronJob(jobName, "panic", time.Since(start))
 					}
 				}
 			}()
 
+			ctx, span := tracing.Start(ctx, "cron "+jobName)
 			err := job.Run(ctx, jobLogger)
+			tracing.End(span, err)
 			if err != nil {
 				jobLogger.Error("job failed", zap.Error(err))
+				metrics.ObserveCronJob(jobName, "failure", time.Since(start))
+			} else {
+				metrics.ObserveCronJob(jobName, "success", time.Since(start))
 			}
 		})
 	}
@@ -35,6 +35,7 @@ type ACLSubjectRule struct {
 	GitHubUser              string `json:"githubUser,omitempty" pageship:"max=100"`
 	GitHubRepositoryActions string `json:"gitHubRepositoryActions,omitempty" pageship:"max=100"`
 	IpRange                 string `json:"ipRange,omitempty" pageship:"omitempty,max=100,cidr"`
+	ClientCert              string `json:"clientCert,omitempty" pageship:"max=200"`
 }
 
 func (c *ACLSubjectRule) String() string {
@@ -47,6 +48,8 @@ func (c *ACLSubjectRule) String() string {
 		return fmt.Sprintf("gitHubRepositoryActions:%s", c.GitHubRepositoryActions)
 	case c.IpRange != "":
 		return fmt.Sprintf("ipRange:%s", c.IpRange)
+	case c.ClientCert != "":
+		return fmt.Sprintf("clientCert:%s", c.ClientCert)
 	}
 	return "<unknown>"
 }
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"bytes"
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+
+	"golang.org/x/crypto/ssh"
 )
 
 type AppConfig struct {
@@ -12,7 +15,9 @@ type AppConfig struct {
 	Sites       []AppSiteConfig      `json:"sites" pageship:"max=10,dive,required"`
 	Deployments AppDeploymentsConfig `json:"deployments"`
 	Team        []*AccessRule        `json:"team" pageship:"max=100,dive,required"`
-	Domains     []AppDomainConfig    `json:"domains" pageship:"max=10,unique=Domain,unique=Site,dive,required"`
+	Domains     []AppDomainConfig    `json:"domains" pageship:"max=10,unique=Domain,dive,required"`
+	TrustedKeys []string             `json:"trustedKeys,omitempty" pageship:"max=20,dive,sshPublicKey"`
+	Webhooks    []AppWebhookConfig   `json:"webhooks,omitempty" pageship:"max=10,dive,required"`
 }
 
 func DefaultAppConfig() AppConfig {
@@ -46,11 +51,16 @@ func (c *AppConfig) SetDefaults() {
 	for _, r := range c.Team {
 		r.SetDefaults()
 	}
+	for i := range c.Webhooks {
+		c.Webhooks[i].SetDefaults()
+	}
 }
 
+// ResolveDomain resolves the domain config of the domain name; alias domain
+// names resolves to the config of canonical domain.
 func (c *AppConfig) ResolveDomain(domain string) (resolved AppDomainConfig, ok bool) {
 	for _, d := range c.Domains {
-		if d.Domain == domain {
+		if d.Domain == domain || d.IsAlias(domain) {
 			resolved = d
 			ok = true
 			return
@@ -74,6 +84,20 @@ func (c *AppConfig) ResolveSite(site string) (resolved AppSiteConfig, ok bool) {
 	return
 }
 
+// IsTrustedKey checks whether the public key is listed in trusted keys.
+func (c *AppConfig) IsTrustedKey(key ssh.PublicKey) bool {
+	for _, k := range c.TrustedKeys {
+		trusted, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k))
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(trusted.Marshal(), key.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *AppConfig) Scan(val any) error {
 	switch v := val.(type) {
 	case []byte:
@@ -1,6 +1,42 @@
 package config
 
+import "strings"
+
+const wildcardDomainPrefix = "*."
+
 type AppDomainConfig struct {
-	Domain string `json:"domain" pageship:"required,max=200,hostname_rfc1123,lowercase"`
-	Site   string `json:"site" pageship:"required,dnsLabel"`
+	Domain  string   `json:"domain" pageship:"required,max=200,domainName,lowercase"`
+	Site    string   `json:"site,omitempty" pageship:"omitempty,dnsLabel"`
+	Aliases []string `json:"aliases,omitempty" pageship:"max=10,dive,required,max=200,hostname_rfc1123,lowercase"`
+}
+
+// IsWildcard reports whether the domain is a wildcard domain (e.g.
+// '*.preview.example.com'); each subdomain label of wildcard domain resolves
+// to the site/deployment of same name.
+func (c *AppDomainConfig) IsWildcard() bool {
+	return IsWildcardDomain(c.Domain)
+}
+
+// IsAlias reports whether the domain name is an alias of this domain.
+func (c *AppDomainConfig) IsAlias(domain string) bool {
+	for _, a := range c.Aliases {
+		if a == domain {
+			return true
+		}
+	}
+	return false
+}
+
+func IsWildcardDomain(domain string) bool {
+	return strings.HasPrefix(domain, wildcardDomainPrefix)
+}
+
+// WildcardDomainOf returns the wildcard domain name matching the domain name,
+// and the matched subdomain label.
+func WildcardDomainOf(domain string) (wildcard string, label string, ok bool) {
+	label, parent, ok := strings.Cut(domain, ".")
+	if !ok || !ValidateDNSLabel(label) || !strings.Contains(parent, ".") {
+		return "", "", false
+	}
+	return wildcardDomainPrefix + parent, label, true
 }
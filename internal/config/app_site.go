@@ -5,6 +5,9 @@ import "regexp"
 type AppSiteConfig struct {
 	Name    string `json:"name" pageship:"excluded_with=Pattern,dnsLabel"`
 	Pattern string `json:"pattern,omitempty" pageship:"excluded_with=Name,max=100,regexp"`
+	// RequireSignature requires deployments assigned to the site to be
+	// signed by a trusted key.
+	RequireSignature bool `json:"requireSignature,omitempty"`
 }
 
 func (c *AppSiteConfig) CompilePattern() (*regexp.Regexp, error) {
@@ -0,0 +1,39 @@
+package config
+
+type WebhookEvent string
+
+const (
+	WebhookEventDeploymentUploaded WebhookEvent = "deployment.uploaded"
+	WebhookEventSiteUpdated        WebhookEvent = "site.updated"
+	WebhookEventDomainActivated    WebhookEvent = "domain.activated"
+)
+
+type WebhookFormat string
+
+const (
+	WebhookFormatJSON WebhookFormat = "json"
+	// WebhookFormatSlack is the payload format of Slack incoming webhooks.
+	WebhookFormatSlack WebhookFormat = "slack"
+)
+
+type AppWebhookConfig struct {
+	URL    string         `json:"url" pageship:"required,max=500,url,startswith=http"`
+	Events []WebhookEvent `json:"events" pageship:"min=1,max=10,unique,dive,oneof=deployment.uploaded site.updated domain.activated"`
+	Format WebhookFormat  `json:"format,omitempty" pageship:"omitempty,oneof=json slack"`
+}
+
+func (c *AppWebhookConfig) SetDefaults() {
+	if c.Format == "" {
+		c.Format = WebhookFormatJSON
+	}
+}
+
+// Subscribes reports whether the webhook subscribes to the event.
+func (c *AppWebhookConfig) Subscribes(event WebhookEvent) bool {
+	for _, e := range c.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
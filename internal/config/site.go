@@ -5,8 +5,9 @@ const SiteConfigName = "pageship"
 const DefaultSite = "main"
 
 type SiteConfig struct {
-	Public string `json:"public" pageship:"required"`
-	Access ACL    `json:"access" pageship:"omitempty"`
+	Public  string   `json:"public" pageship:"required"`
+	Access  ACL      `json:"access" pageship:"omitempty"`
+	Exclude []string `json:"exclude,omitempty" pageship:"max=100,dive,max=200"`
 }
 
 func DefaultSiteConfig() SiteConfig {
@@ -2,9 +2,11 @@ package config
 
 import (
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/ssh"
 )
 
 var validate = validator.New()
@@ -23,6 +25,11 @@ func init() {
 		return ValidateDNSLabel(value)
 	})
 
+	validate.RegisterValidation("domainName", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		return ValidateDomainName(value)
+	})
+
 	validate.RegisterValidation("duration", func(fl validator.FieldLevel) bool {
 		value := fl.Field().String()
 		return ValidateDuration(value)
@@ -32,6 +39,53 @@ func init() {
 		value := fl.Field().String()
 		return AccessLevel(value).IsValid()
 	})
+
+	validate.RegisterValidation("sshPublicKey", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		_, _, _, _, err := ssh.ParseAuthorizedKey([]byte(value))
+		return err == nil
+	})
+
+	validate.RegisterStructValidation(func(sl validator.StructLevel) {
+		conf := sl.Current().Interface().(AppConfig)
+
+		// Domain names must be unique, including aliases.
+		domains := make(map[string]struct{})
+		sites := make(map[string]struct{})
+		wildcard := false
+		for _, d := range conf.Domains {
+			if d.IsWildcard() {
+				// Wildcard domain serves all sites; at most one is allowed.
+				if wildcard {
+					sl.ReportError(conf.Domains, "Domains", "domains", "uniqueWildcard", d.Domain)
+					return
+				}
+				wildcard = true
+				if d.Site != "" || len(d.Aliases) > 0 {
+					sl.ReportError(d.Site, "Site", "site", "excluded_with", d.Domain)
+					return
+				}
+			} else {
+				if d.Site == "" {
+					sl.ReportError(d.Site, "Site", "site", "required", "")
+					return
+				}
+				if _, exists := sites[d.Site]; exists {
+					sl.ReportError(conf.Domains, "Domains", "domains", "unique", "Site")
+					return
+				}
+				sites[d.Site] = struct{}{}
+			}
+
+			for _, name := range append([]string{d.Domain}, d.Aliases...) {
+				if _, exists := domains[name]; exists {
+					sl.ReportError(conf.Domains, "Domains", "domains", "uniqueDomain", name)
+					return
+				}
+				domains[name] = struct{}{}
+			}
+		}
+	}, AppConfig{})
 }
 
 // ref: RFC1123
@@ -49,6 +103,26 @@ func ValidateDNSLabel(value string) bool {
 	return true
 }
 
+// ValidateDomainName validates the domain name; it may contain a leading
+// wildcard label (e.g. '*.example.com').
+func ValidateDomainName(value string) bool {
+	name := value
+	if IsWildcardDomain(value) {
+		name = strings.TrimPrefix(value, wildcardDomainPrefix)
+		if !strings.Contains(name, ".") {
+			return false
+		}
+	}
+
+	labels := strings.Split(name, ".")
+	for _, l := range labels {
+		if !ValidateDNSLabel(l) {
+			return false
+		}
+	}
+	return true
+}
+
 func ValidateDuration(value string) bool {
 	d, err := time.ParseDuration(value)
 	if err != nil {
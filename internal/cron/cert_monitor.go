@@ -0,0 +1,217 @@
+package cron
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/caddyserver/certmagic"
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/metrics"
+	"github.com/oursky/pageship/internal/time"
+	"go.uber.org/zap"
+)
+
+// CertMonitor checks expiry of stored certificates, and retries renewal of
+// managed certificates expiring soon. The results are stored as a report in
+// certificate storage.
+type CertMonitor struct {
+	Clock       time.Clock
+	Schedule    string
+	RenewBefore time.Duration
+	DB          db.DB
+	Storage     certmagic.Storage
+	Renew       func(ctx context.Context, domain string) error
+}
+
+func (c *CertMonitor) Name() string { return "cert-monitor" }
+
+func (c *CertMonitor) CronSchedule() string { return c.Schedule }
+
+type storedCert struct {
+	key    string
+	status httputil.CertStatus
+}
+
+func (c *CertMonitor) Run(ctx context.Context, logger *zap.Logger) error {
+	clock := c.Clock
+	if clock == nil {
+		clock = time.SystemClock
+	}
+	now := clock.Now().UTC()
+
+	certs, err := c.listCerts(ctx, logger)
+	if err != nil {
+		return err
+	}
+
+	report := &httputil.CertReport{CheckedAt: now}
+	for _, cert := range certs {
+		status := cert.status
+		status.Expired = now.After(status.NotAfter)
+		status.Expiring = status.NotAfter.Sub(now) < c.RenewBefore
+
+		if status.Expiring && status.Custom {
+			logger.Warn("uploaded certificate expiring",
+				zap.String("domain", status.Domain),
+				zap.Time("not_after", status.NotAfter))
+		} else if status.Expiring && c.Renew != nil {
+			err := c.Renew(ctx, status.Domain)
+			if errors.Is(err, httputil.ErrCertNotManaged) {
+				// Domain is removed; skip renewal.
+				logger.Debug("skipped renewal of unmanaged certificate", zap.String("domain", status.Domain))
+			} else {
+				status.RenewalAttemptedAt = &now
+				metrics.ObserveCertRenewal(err)
+				if err != nil {
+					status.RenewalError = err.Error()
+					logger.Error("failed to renew certificate",
+						zap.String("domain", status.Domain),
+						zap.Time("not_after", status.NotAfter),
+						zap.Error(err))
+				} else if renewed, err := c.loadCert(ctx, cert.key); err == nil {
+					status.Issuer = renewed.Issuer.String()
+					status.NotAfter = renewed.NotAfter
+					status.Expired = now.After(status.NotAfter)
+					status.Expiring = status.NotAfter.Sub(now) < c.RenewBefore
+				}
+			}
+		}
+
+		report.Certificates = append(report.Certificates, status)
+	}
+
+	if err := httputil.StoreCertReport(ctx, c.Storage, report); err != nil {
+		return fmt.Errorf("failed to store certificate report: %w", err)
+	}
+
+	expiring := 0
+	counts := make(map[[2]string]int)
+	for _, kind := range []string{"managed", "custom"} {
+		for _, state := range []string{"valid", "expiring", "expired"} {
+			counts[[2]string{kind, state}] = 0
+		}
+	}
+	for _, s := range report.Certificates {
+		if s.Expiring {
+			expiring++
+		}
+		counts[[2]string{certKind(s), certState(s)}]++
+	}
+	for k, n := range counts {
+		metrics.SetCertificates(k[0], k[1], n)
+	}
+	logger.Info("checked certificates",
+		zap.Int("n", len(report.Certificates)),
+		zap.Int("expiring", expiring))
+	return nil
+}
+
+// listCerts lists stored certificates; if a domain has certificates from
+// multiple issuers, the latest one is used.
+func (c *CertMonitor) listCerts(ctx context.Context, logger *zap.Logger) ([]storedCert, error) {
+	certKeys, err := c.DB.ListCertificateData(ctx, "certificates/")
+	if err != nil {
+		return nil, err
+	}
+	customKeys, err := c.DB.ListCertificateData(ctx, httputil.CustomCertPrefix+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	managed := make(map[string]storedCert)
+	var certs []storedCert
+	for _, key := range append(certKeys, customKeys...) {
+		custom := strings.HasPrefix(key, httputil.CustomCertPrefix+"/")
+		var name string
+		if custom {
+			name = strings.TrimSuffix(strings.TrimPrefix(key, httputil.CustomCertPrefix+"/"), ".pem")
+		} else if strings.HasSuffix(key, ".crt") {
+			// Certificates are stored at 'certificates/<issuer>/<domain>/<domain>.crt'.
+			parts := strings.Split(key, "/")
+			name = parts[len(parts)-2]
+		} else {
+			continue
+		}
+
+		cert, err := c.loadCert(ctx, key)
+		if err != nil {
+			logger.Warn("failed to load certificate", zap.String("key", key), zap.Error(err))
+			continue
+		}
+
+		entry := storedCert{
+			key: key,
+			status: httputil.CertStatus{
+				Domain:   domainFromSafeName(name),
+				Issuer:   cert.Issuer.String(),
+				Custom:   custom,
+				NotAfter: cert.NotAfter,
+			},
+		}
+		if custom {
+			certs = append(certs, entry)
+		} else if e, ok := managed[entry.status.Domain]; !ok || e.status.NotAfter.Before(entry.status.NotAfter) {
+			managed[entry.status.Domain] = entry
+		}
+	}
+	for _, e := range managed {
+		certs = append(certs, e)
+	}
+
+	sort.Slice(certs, func(i, j int) bool {
+		if certs[i].status.Domain != certs[j].status.Domain {
+			return certs[i].status.Domain < certs[j].status.Domain
+		}
+		return !certs[i].status.Custom
+	})
+	return certs, nil
+}
+
+func (c *CertMonitor) loadCert(ctx context.Context, key string) (*x509.Certificate, error) {
+	data, err := c.Storage.Load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("certificate not found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+func certKind(s httputil.CertStatus) string {
+	if s.Custom {
+		return "custom"
+	}
+	return "managed"
+}
+
+func certState(s httputil.CertStatus) string {
+	switch {
+	case s.Expired:
+		return "expired"
+	case s.Expiring:
+		return "expiring"
+	default:
+		return "valid"
+	}
+}
+
+func domainFromSafeName(name string) string {
+	if rest, ok := strings.CutPrefix(name, "wildcard_."); ok {
+		return "*." + rest
+	}
+	return name
+}
@@ -2,8 +2,11 @@ package cron
 
 import (
 	"context"
+	"errors"
 
 	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/storage"
 	"github.com/oursky/pageship/internal/time"
 	"go.uber.org/zap"
 )
@@ -13,6 +16,7 @@ type CleanupExpired struct {
 	Schedule         string
 	KeepAfterExpired time.Duration
 	DB               db.DB
+	Storage          *storage.Storage
 }
 
 func (c *CleanupExpired) Name() string { return "cleanup-expired" }
@@ -27,7 +31,7 @@ func (c *CleanupExpired) Run(ctx context.Context, logger *zap.Logger) error {
 	now := clock.Now().UTC()
 	expireBefore := now.Add(-c.KeepAfterExpired)
 
-	return db.WithTx(ctx, c.DB, func(c db.Tx) error {
+	err := db.WithTx(ctx, c.DB, func(c db.Tx) error {
 		n, err := c.DeleteExpiredDeployments(ctx, now, expireBefore)
 		if err != nil {
 			return err
@@ -36,4 +40,93 @@ func (c *CleanupExpired) Run(ctx context.Context, logger *zap.Logger) error {
 		logger.Info("deleted expired deployment", zap.Int64("n", n))
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	if err := c.cleanupUploadChunks(ctx, logger, now); err != nil {
+		return err
+	}
+	return c.cleanupUploadStagings(ctx, logger, now)
+}
+
+// cleanupUploadChunks deletes staged chunks of abandoned uploads, i.e.
+// deployments that are expired, deleted, or already uploaded.
+func (c *CleanupExpired) cleanupUploadChunks(ctx context.Context, logger *zap.Logger, now time.Time) error {
+	chunks, err := c.DB.ListStaleDeploymentUploadChunks(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	failed := make(map[string]bool)
+	for _, chunk := range chunks {
+		err := c.Storage.Delete(ctx, chunk.StorageKey(chunk.StorageKeyPrefix))
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			logger.Warn("failed to delete upload chunk",
+				zap.String("deployment", chunk.DeploymentID),
+				zap.String("chunk", chunk.ID),
+				zap.Error(err))
+			failed[chunk.DeploymentID] = true
+		}
+	}
+
+	deleted := make(map[string]bool)
+	for _, chunk := range chunks {
+		if failed[chunk.DeploymentID] || deleted[chunk.DeploymentID] {
+			continue
+		}
+		if err := c.DB.DeleteDeploymentUploadChunks(ctx, chunk.DeploymentID); err != nil {
+			return err
+		}
+		deleted[chunk.DeploymentID] = true
+	}
+
+	logger.Info("deleted stale upload chunks", zap.Int("chunks", len(chunks)), zap.Int("deployments", len(deleted)))
+	return nil
+}
+
+// cleanupUploadStagings deletes objects uploaded directly to storage of
+// deployments that are expired, deleted, or already uploaded.
+func (c *CleanupExpired) cleanupUploadStagings(ctx context.Context, logger *zap.Logger, now time.Time) error {
+	stagings, err := c.DB.ListStaleDeploymentUploadStagings(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	var objects, deleted int
+	for _, staging := range stagings {
+		n, err := c.deleteStagedObjects(ctx, staging.StorageKeyPrefix)
+		objects += n
+		if err != nil {
+			logger.Warn("failed to delete staged objects",
+				zap.String("deployment", staging.DeploymentID),
+				zap.Error(err))
+			continue
+		}
+
+		if err := c.DB.DeleteDeploymentUploadStaging(ctx, staging.DeploymentID); err != nil {
+			return err
+		}
+		deleted++
+	}
+
+	logger.Info("deleted stale staged objects", zap.Int("objects", objects), zap.Int("deployments", deleted))
+	return nil
+}
+
+func (c *CleanupExpired) deleteStagedObjects(ctx context.Context, storageKeyPrefix string) (int, error) {
+	keys, err := c.Storage.List(ctx, models.StagingKeyPrefix(storageKeyPrefix)+"/")
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, key := range keys {
+		err := c.Storage.Delete(ctx, key)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
 }
@@ -46,6 +46,9 @@ type DBQuery interface {
 	DomainsDB
 	UserDB
 	CertificateDB
+	AnalyticsDB
+	AuditDB
+	WebhookDB
 }
 
 type AppsDB interface {
@@ -69,10 +72,21 @@ type DeploymentsDB interface {
 	GetDeploymentByName(ctx context.Context, appID string, name string) (*models.Deployment, error)
 	ListDeployments(ctx context.Context, appID string) ([]DeploymentInfo, error)
 	MarkDeploymentUploaded(ctx context.Context, now time.Time, deployment *models.Deployment) error
+	RenameDeployment(ctx context.Context, deployment *models.Deployment) error
+	DeleteDeployment(ctx context.Context, id string, now time.Time) error
 	GetSiteDeployment(ctx context.Context, appID string, siteName string) (*models.Deployment, error)
 	GetDeploymentSiteNames(ctx context.Context, deployment *models.Deployment) ([]string, error)
 	SetDeploymentExpiry(ctx context.Context, deployment *models.Deployment) error
 	DeleteExpiredDeployments(ctx context.Context, now time.Time, expireBefore time.Time) (int64, error)
+
+	AddDeploymentUploadChunk(ctx context.Context, chunk *models.DeploymentUploadChunk) error
+	ListDeploymentUploadChunks(ctx context.Context, deploymentID string) ([]*models.DeploymentUploadChunk, error)
+	ListStaleDeploymentUploadChunks(ctx context.Context, now time.Time) ([]DeploymentUploadChunkInfo, error)
+	DeleteDeploymentUploadChunks(ctx context.Context, deploymentID string) error
+
+	AddDeploymentUploadStaging(ctx context.Context, deploymentID string, now time.Time) error
+	ListStaleDeploymentUploadStagings(ctx context.Context, now time.Time) ([]DeploymentUploadStagingInfo, error)
+	DeleteDeploymentUploadStaging(ctx context.Context, deploymentID string) error
 }
 
 type DomainsDB interface {
@@ -81,6 +95,11 @@ type DomainsDB interface {
 	GetDomainBySite(ctx context.Context, appID string, siteName string) (*models.Domain, error)
 	DeleteDomain(ctx context.Context, id string, now time.Time) error
 	ListDomains(ctx context.Context, appID string) ([]*models.Domain, error)
+	ListWildcardDomains(ctx context.Context) ([]*models.Domain, error)
+
+	CreateDomainVerification(ctx context.Context, verification *models.DomainVerification) error
+	GetDomainVerification(ctx context.Context, domain string, appID string) (*models.DomainVerification, error)
+	UpdateDomainVerification(ctx context.Context, verification *models.DomainVerification) error
 }
 
 type UserDB interface {
@@ -99,6 +118,34 @@ type CertificateDB interface {
 	ListCertificateData(ctx context.Context, prefix string) ([]string, error)
 }
 
+type AnalyticsDB interface {
+	AddSiteAnalyticsCounts(ctx context.Context, counts []models.SiteAnalyticsCount) error
+	GetSiteAnalyticsDaily(ctx context.Context, appID string, siteName string, since string) ([]models.SiteAnalyticsDaily, error)
+	GetSiteAnalyticsTop(ctx context.Context, appID string, siteName string, kind models.SiteAnalyticsKind, since string, limit int) ([]models.SiteAnalyticsEntry, error)
+
+	GetOrCreateAnalyticsSalt(ctx context.Context, date string, salt string) (string, error)
+	DeleteAnalyticsSalts(ctx context.Context, before string) error
+}
+
+type AuditDB interface {
+	CreateAuditEvent(ctx context.Context, event *models.AuditEvent) error
+	ListAuditEvents(ctx context.Context, query AuditEventQuery) ([]*models.AuditEvent, error)
+}
+
+type WebhookDB interface {
+	GetOrCreateWebhookSecret(ctx context.Context, appID string, now time.Time, secret string) (string, error)
+	SetWebhookSecret(ctx context.Context, appID string, now time.Time, secret string) error
+
+	CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error)
+	// ClaimWebhookDelivery postpones next attempt of the due delivery to
+	// leaseUntil; it reports false if the delivery is claimed by others.
+	ClaimWebhookDelivery(ctx context.Context, id string, now time.Time, leaseUntil time.Time) (bool, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, appID string, limit int) ([]*models.WebhookDelivery, error)
+	DeleteWebhookDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
+
 type LockerDB interface {
 	Close() error
 	Lock(ctx context.Context, name string) error
@@ -110,6 +157,31 @@ type DeploymentInfo struct {
 	FirstSiteName *string `db:"site_name"`
 }
 
+// DeploymentUploadChunkInfo is a staged upload chunk, with storage key prefix
+// of its deployment.
+type DeploymentUploadChunkInfo struct {
+	*models.DeploymentUploadChunk
+	StorageKeyPrefix string `db:"storage_key_prefix"`
+}
+
+// DeploymentUploadStagingInfo is a deployment which may have objects uploaded
+// directly to storage staged, with its storage key prefix.
+type DeploymentUploadStagingInfo struct {
+	DeploymentID     string `db:"deployment_id"`
+	StorageKeyPrefix string `db:"storage_key_prefix"`
+}
+
+type AuditEventQuery struct {
+	// AppID filters events of the app; nil to include all events.
+	AppID *string
+	Until time.Time
+	// AfterTime & AfterID is the position (exclusive) to list events from, in
+	// order of creation time and ID.
+	AfterTime time.Time
+	AfterID   string
+	Limit     int
+}
+
 type SiteInfo struct {
 	*models.Site
 	DeploymentName *string `db:"deployment_name"`
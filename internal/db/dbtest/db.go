@@ -0,0 +1,30 @@
+// Package dbtest provides helpers for testing with fake databases.
+package dbtest
+
+import (
+	"context"
+
+	"github.com/oursky/pageship/internal/db"
+)
+
+// DB adapts a fake Tx, which usually implements only the queries under
+// test, as db.DB. Transactions share the same Tx, and commit/rollback are
+// no-op.
+type DB struct {
+	db.Tx
+}
+
+func (d *DB) BeginTx(ctx context.Context) (db.Tx, error) {
+	return tx{d.Tx}, nil
+}
+
+func (d *DB) Locker(ctx context.Context) (db.LockerDB, error) {
+	panic("not implemented")
+}
+
+type tx struct {
+	db.Tx
+}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
@@ -8,6 +8,8 @@ import (
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
 	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 )
 
 func init() {
@@ -28,10 +30,11 @@ func (t Tx) Rollback() error { return t.ext.Rollback() }
 func (t Tx) Commit() error   { return t.ext.Commit() }
 
 func NewPostgres(url url.URL) (db.DB, error) {
-	db, err := sqlx.Open("pgx", url.String())
+	sqlDB, err := tracing.OpenDB("pgx", url.String(), semconv.DBSystemPostgreSQL)
 	if err != nil {
 		return nil, fmt.Errorf("open postgres DB: %w", err)
 	}
+	db := sqlx.NewDb(sqlDB, "pgx")
 
 	return DB{query: query[*sqlx.DB]{ext: db}}, nil
 }
@@ -93,6 +93,38 @@ func (q query[T]) MarkDeploymentUploaded(ctx context.Context, now time.Time, dep
 	return nil
 }
 
+func (q query[T]) RenameDeployment(ctx context.Context, deployment *models.Deployment) error {
+	result, err := q.ext.ExecContext(ctx, `
+		UPDATE deployment SET name = $1, updated_at = $2 WHERE id = $3 AND NOT EXISTS (
+			SELECT 1 FROM deployment d WHERE d.app_id = $4 AND d.name = $5 AND d.deleted_at IS NULL
+		)
+	`, deployment.Name, deployment.UpdatedAt, deployment.ID, deployment.AppID, deployment.Name)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return models.ErrDeploymentUsedName
+	}
+
+	return nil
+}
+
+func (q query[T]) DeleteDeployment(ctx context.Context, id string, now time.Time) error {
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE deployment SET deleted_at = $1 WHERE id = $2
+	`, now, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (q query[T]) GetSiteDeployment(ctx context.Context, appID string, siteName string) (*models.Deployment, error) {
 	var deployment models.Deployment
 
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+)
+
+func (q query[T]) AddDeploymentUploadChunk(ctx context.Context, chunk *models.DeploymentUploadChunk) error {
+	result, err := sqlx.NamedExecContext(ctx, q.ext, `
+		INSERT INTO deployment_upload_chunk (id, created_at, deployment_id, byte_offset, size, total_size)
+			VALUES (:id, :created_at, :deployment_id, :byte_offset, :size, :total_size)
+			ON CONFLICT (deployment_id, byte_offset) DO NOTHING
+	`, chunk)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return models.ErrDeploymentUploadOffset
+	}
+
+	return nil
+}
+
+func (q query[T]) ListDeploymentUploadChunks(ctx context.Context, deploymentID string) ([]*models.DeploymentUploadChunk, error) {
+	var chunks []*models.DeploymentUploadChunk
+	err := sqlx.SelectContext(ctx, q.ext, &chunks, `
+		SELECT c.id, c.created_at, c.deployment_id, c.byte_offset, c.size, c.total_size FROM deployment_upload_chunk c
+			WHERE c.deployment_id = $1
+			ORDER BY c.byte_offset
+	`, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return chunks, nil
+}
+
+func (q query[T]) ListStaleDeploymentUploadChunks(ctx context.Context, now time.Time) ([]db.DeploymentUploadChunkInfo, error) {
+	var chunks []db.DeploymentUploadChunkInfo
+	err := sqlx.SelectContext(ctx, q.ext, &chunks, `
+		SELECT c.id, c.created_at, c.deployment_id, c.byte_offset, c.size, c.total_size, d.storage_key_prefix FROM deployment_upload_chunk c
+			JOIN deployment d ON (d.id = c.deployment_id)
+			WHERE d.deleted_at IS NOT NULL OR d.uploaded_at IS NOT NULL OR d.expire_at < $1
+			ORDER BY c.deployment_id, c.byte_offset
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return chunks, nil
+}
+
+func (q query[T]) DeleteDeploymentUploadChunks(ctx context.Context, deploymentID string) error {
+	_, err := q.ext.ExecContext(ctx, `
+		DELETE FROM deployment_upload_chunk WHERE deployment_id = $1
+	`, deploymentID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) AddDeploymentUploadStaging(ctx context.Context, deploymentID string, now time.Time) error {
+	_, err := q.ext.ExecContext(ctx, `
+		INSERT INTO deployment_upload_staging (deployment_id, created_at)
+			VALUES ($1, $2)
+			ON CONFLICT (deployment_id) DO NOTHING
+	`, deploymentID, now)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) ListStaleDeploymentUploadStagings(ctx context.Context, now time.Time) ([]db.DeploymentUploadStagingInfo, error) {
+	var stagings []db.DeploymentUploadStagingInfo
+	err := sqlx.SelectContext(ctx, q.ext, &stagings, `
+		SELECT s.deployment_id, d.storage_key_prefix FROM deployment_upload_staging s
+			JOIN deployment d ON (d.id = s.deployment_id)
+			WHERE d.deleted_at IS NOT NULL OR d.uploaded_at IS NOT NULL OR d.expire_at < $1
+			ORDER BY s.deployment_id
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return stagings, nil
+}
+
+func (q query[T]) DeleteDeploymentUploadStaging(ctx context.Context, deploymentID string) error {
+	_, err := q.ext.ExecContext(ctx, `
+		DELETE FROM deployment_upload_staging WHERE deployment_id = $1
+	`, deploymentID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
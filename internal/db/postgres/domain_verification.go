@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oursky/pageship/internal/models"
+)
+
+func (q query[T]) CreateDomainVerification(ctx context.Context, verification *models.DomainVerification) error {
+	_, err := sqlx.NamedExecContext(ctx, q.ext, `
+		INSERT INTO domain_verification (id, created_at, updated_at, deleted_at, domain, app_id, value, verified_at, last_checked_at)
+			VALUES (:id, :created_at, :updated_at, :deleted_at, :domain, :app_id, :value, :verified_at, :last_checked_at)
+	`, verification)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) GetDomainVerification(ctx context.Context, domain string, appID string) (*models.DomainVerification, error) {
+	var verification models.DomainVerification
+
+	err := sqlx.GetContext(ctx, q.ext, &verification, `
+		SELECT v.id, v.created_at, v.updated_at, v.deleted_at, v.domain, v.app_id, v.value, v.verified_at, v.last_checked_at
+			FROM domain_verification v
+			WHERE v.domain = $1 AND v.app_id = $2 AND v.deleted_at IS NULL
+	`, domain, appID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrDomainVerificationNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &verification, nil
+}
+
+func (q query[T]) UpdateDomainVerification(ctx context.Context, verification *models.DomainVerification) error {
+	_, err := sqlx.NamedExecContext(ctx, q.ext, `
+		UPDATE domain_verification SET updated_at = :updated_at, verified_at = :verified_at, last_checked_at = :last_checked_at
+			WHERE id = :id
+	`, verification)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
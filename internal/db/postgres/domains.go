@@ -12,8 +12,8 @@ import (
 
 func (q query[T]) CreateDomain(ctx context.Context, domain *models.Domain) error {
 	result, err := sqlx.NamedExecContext(ctx, q.ext, `
-		INSERT INTO domain_association (id, created_at, updated_at, deleted_at, domain, app_id, site_name)
-			VALUES (:id, :created_at, :updated_at, :deleted_at, :domain, :app_id, :site_name)
+		INSERT INTO domain_association (id, created_at, updated_at, deleted_at, domain, app_id, site_name, alias_of)
+			VALUES (:id, :created_at, :updated_at, :deleted_at, :domain, :app_id, :site_name, :alias_of)
 			ON CONFLICT (domain) WHERE deleted_at IS NULL DO NOTHING
 	`, domain)
 	if err != nil {
@@ -35,7 +35,7 @@ func (q query[T]) GetDomainByName(ctx context.Context, domainName string) (*mode
 	var domain models.Domain
 
 	err := sqlx.GetContext(ctx, q.ext, &domain, `
-		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.domain, d.app_id, d.site_name FROM domain_association d
+		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.domain, d.app_id, d.site_name, d.alias_of FROM domain_association d
 			JOIN app a ON (a.id = d.app_id AND a.deleted_at IS NULL)
 			WHERE d.domain = $1 AND d.deleted_at IS NULL
 	`, domainName)
@@ -52,9 +52,9 @@ func (q query[T]) GetDomainBySite(ctx context.Context, appID string, siteName st
 	var domain models.Domain
 
 	err := sqlx.GetContext(ctx, q.ext, &domain, `
-		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.domain, d.app_id, d.site_name FROM domain_association d
+		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.domain, d.app_id, d.site_name, d.alias_of FROM domain_association d
 			JOIN app a ON (a.id = d.app_id AND a.deleted_at IS NULL)
-			WHERE d.app_id = $1 AND d.site_name = $2 AND d.deleted_at IS NULL
+			WHERE d.app_id = $1 AND d.site_name = $2 AND d.alias_of IS NULL AND d.deleted_at IS NULL
 	`, appID, siteName)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, models.ErrDomainNotFound
@@ -79,7 +79,7 @@ func (q query[T]) DeleteDomain(ctx context.Context, id string, now time.Time) er
 func (q query[T]) ListDomains(ctx context.Context, appID string) ([]*models.Domain, error) {
 	var domains []*models.Domain
 	err := sqlx.SelectContext(ctx, q.ext, &domains, `
-		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.domain, d.app_id, d.site_name FROM domain_association d
+		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.domain, d.app_id, d.site_name, d.alias_of FROM domain_association d
 			WHERE d.app_id = $1 AND d.deleted_at IS NULL
 			ORDER BY d.domain, d.created_at
 	`, appID)
@@ -89,3 +89,18 @@ func (q query[T]) ListDomains(ctx context.Context, appID string) ([]*models.Doma
 
 	return domains, nil
 }
+
+func (q query[T]) ListWildcardDomains(ctx context.Context) ([]*models.Domain, error) {
+	var domains []*models.Domain
+	err := sqlx.SelectContext(ctx, q.ext, &domains, `
+		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.domain, d.app_id, d.site_name, d.alias_of FROM domain_association d
+			JOIN app a ON (a.id = d.app_id AND a.deleted_at IS NULL)
+			WHERE d.site_name = $1 AND d.deleted_at IS NULL
+			ORDER BY d.domain
+	`, models.DomainSiteWildcard)
+	if err != nil {
+		return nil, err
+	}
+
+	return domains, nil
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oursky/pageship/internal/models"
+)
+
+func (q query[T]) AddSiteAnalyticsCounts(ctx context.Context, counts []models.SiteAnalyticsCount) error {
+	for _, count := range counts {
+		_, err := sqlx.NamedExecContext(ctx, q.ext, `
+			INSERT INTO site_analytics (app_id, site_name, date, kind, key, count)
+				VALUES (:app_id, :site_name, :date, :kind, :key, :count)
+				ON CONFLICT (app_id, site_name, date, kind, key) DO UPDATE SET count = site_analytics.count + excluded.count
+		`, count)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (q query[T]) GetSiteAnalyticsDaily(ctx context.Context, appID string, siteName string, since string) ([]models.SiteAnalyticsDaily, error) {
+	var daily []models.SiteAnalyticsDaily
+	err := sqlx.SelectContext(ctx, q.ext, &daily, `
+		SELECT a.date, SUM(a.count)::BIGINT AS page_views, COUNT(CASE WHEN a.key <> '(other)' THEN 1 END) AS visitors FROM site_analytics a
+			WHERE a.app_id = $1 AND a.site_name = $2 AND a.kind = $3 AND a.date >= $4
+			GROUP BY a.date
+			ORDER BY a.date
+	`, appID, siteName, models.SiteAnalyticsVisitor, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return daily, nil
+}
+
+func (q query[T]) GetSiteAnalyticsTop(ctx context.Context, appID string, siteName string, kind models.SiteAnalyticsKind, since string, limit int) ([]models.SiteAnalyticsEntry, error) {
+	var entries []models.SiteAnalyticsEntry
+	err := sqlx.SelectContext(ctx, q.ext, &entries, `
+		SELECT a.key, SUM(a.count)::BIGINT AS count FROM site_analytics a
+			WHERE a.app_id = $1 AND a.site_name = $2 AND a.kind = $3 AND a.date >= $4
+			GROUP BY a.key
+			ORDER BY count DESC, a.key
+			LIMIT $5
+	`, appID, siteName, kind, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (q query[T]) GetOrCreateAnalyticsSalt(ctx context.Context, date string, salt string) (string, error) {
+	_, err := q.ext.ExecContext(ctx, `
+		INSERT INTO site_analytics_salt (date, salt) VALUES ($1, $2)
+			ON CONFLICT (date) DO NOTHING
+	`, date, salt)
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	err = sqlx.GetContext(ctx, q.ext, &result, `
+		SELECT salt FROM site_analytics_salt WHERE date = $1
+	`, date)
+	if err != nil {
+		return "", err
+	}
+
+	return result, nil
+}
+
+func (q query[T]) DeleteAnalyticsSalts(ctx context.Context, before string) error {
+	_, err := q.ext.ExecContext(ctx, `
+		DELETE FROM site_analytics_salt WHERE date < $1
+	`, before)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
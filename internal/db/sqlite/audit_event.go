@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+)
+
+func (q query[T]) CreateAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	_, err := sqlx.NamedExecContext(ctx, q.ext, `
+		INSERT INTO audit_event (id, created_at, app_id, actor_id, actor_name, credential_id, credential_rule, action, target, diff, request_id)
+			VALUES (:id, :created_at, :app_id, :actor_id, :actor_name, :credential_id, :credential_rule, :action, :target, :diff, :request_id)
+	`, event)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) ListAuditEvents(ctx context.Context, query db.AuditEventQuery) ([]*models.AuditEvent, error) {
+	var events []*models.AuditEvent
+	err := sqlx.SelectContext(ctx, q.ext, &events, `
+		SELECT a.id, a.created_at, a.app_id, a.actor_id, a.actor_name, a.credential_id, a.credential_rule, a.action, a.target, a.diff, a.request_id
+			FROM audit_event a
+			WHERE (? IS NULL OR a.app_id = ?)
+				AND (a.created_at > ? OR (a.created_at = ? AND a.id > ?))
+				AND a.created_at < ?
+			ORDER BY a.created_at, a.id
+			LIMIT ?
+	`, query.AppID, query.AppID, query.AfterTime, query.AfterTime, query.AfterID, query.Until, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
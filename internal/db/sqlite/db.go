@@ -8,6 +8,8 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 	_ "modernc.org/sqlite"
 )
 
@@ -37,10 +39,11 @@ func NewSqlite(url url.URL) (db.DB, error) {
 	url.RawQuery = q.Encode()
 
 	dsn := strings.TrimPrefix(url.String(), "sqlite://")
-	db, err := sqlx.Open("sqlite", dsn)
+	sqlDB, err := tracing.OpenDB("sqlite", dsn, semconv.DBSystemSqlite)
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite DB: %w", err)
 	}
+	db := sqlx.NewDb(sqlDB, "sqlite")
 
 	return DB{query: query[*sqlx.DB]{ext: db}}, nil
 }
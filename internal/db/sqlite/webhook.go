@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oursky/pageship/internal/models"
+)
+
+func (q query[T]) GetOrCreateWebhookSecret(ctx context.Context, appID string, now time.Time, secret string) (string, error) {
+	_, err := q.ext.ExecContext(ctx, `
+		INSERT INTO webhook_secret (app_id, created_at, secret) VALUES (?, ?, ?)
+			ON CONFLICT (app_id) DO NOTHING
+	`, appID, now, secret)
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	err = sqlx.GetContext(ctx, q.ext, &result, `
+		SELECT secret FROM webhook_secret WHERE app_id = ?
+	`, appID)
+	if err != nil {
+		return "", err
+	}
+
+	return result, nil
+}
+
+func (q query[T]) SetWebhookSecret(ctx context.Context, appID string, now time.Time, secret string) error {
+	_, err := q.ext.ExecContext(ctx, `
+		INSERT INTO webhook_secret (app_id, created_at, secret) VALUES (?, ?, ?)
+			ON CONFLICT (app_id) DO UPDATE SET created_at = excluded.created_at, secret = excluded.secret
+	`, appID, now, secret)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	_, err := sqlx.NamedExecContext(ctx, q.ext, `
+		INSERT INTO webhook_delivery (id, created_at, app_id, url, event, payload, status, attempts, next_attempt_at, last_attempt_at, response_status, last_error)
+			VALUES (:id, :created_at, :app_id, :url, :event, :payload, :status, :attempts, :next_attempt_at, :last_attempt_at, :response_status, :last_error)
+	`, delivery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	err := sqlx.SelectContext(ctx, q.ext, &deliveries, `
+		SELECT d.id, d.created_at, d.app_id, d.url, d.event, d.payload, d.status, d.attempts, d.next_attempt_at, d.last_attempt_at, d.response_status, d.last_error
+			FROM webhook_delivery d
+			WHERE d.status = ? AND d.next_attempt_at <= ?
+			ORDER BY d.next_attempt_at
+			LIMIT ?
+	`, models.WebhookDeliveryPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (q query[T]) ClaimWebhookDelivery(ctx context.Context, id string, now time.Time, leaseUntil time.Time) (bool, error) {
+	result, err := q.ext.ExecContext(ctx, `
+		UPDATE webhook_delivery SET next_attempt_at = ?
+			WHERE id = ? AND status = ? AND next_attempt_at <= ?
+	`, leaseUntil, id, models.WebhookDeliveryPending, now)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+func (q query[T]) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	_, err := sqlx.NamedExecContext(ctx, q.ext, `
+		UPDATE webhook_delivery SET
+			status = :status,
+			attempts = :attempts,
+			next_attempt_at = :next_attempt_at,
+			last_attempt_at = :last_attempt_at,
+			response_status = :response_status,
+			last_error = :last_error
+			WHERE id = :id
+	`, delivery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) ListWebhookDeliveries(ctx context.Context, appID string, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	err := sqlx.SelectContext(ctx, q.ext, &deliveries, `
+		SELECT d.id, d.created_at, d.app_id, d.url, d.event, d.payload, d.status, d.attempts, d.next_attempt_at, d.last_attempt_at, d.response_status, d.last_error
+			FROM webhook_delivery d
+			WHERE d.app_id = ?
+			ORDER BY d.created_at DESC, d.id DESC
+			LIMIT ?
+	`, appID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (q query[T]) DeleteWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.ext.ExecContext(ctx, `
+		DELETE FROM webhook_delivery WHERE status <> ? AND created_at < ?
+	`, models.WebhookDeliveryPending, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,267 @@
+package deploy
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/oursky/pageship/internal/models"
+)
+
+var ErrInvalidArchivePath error = Error("invalid archive path")
+var ErrUnsupportedArchiveEntry error = Error("unsupported archive entry")
+var ErrDuplicatedArchiveEntry error = Error("duplicated archive entry")
+
+var (
+	magicGzip = []byte{0x1f, 0x8b}
+	magicZstd = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	magicZip  = []byte{'P', 'K', 0x03, 0x04}
+)
+
+// CollectArchive collects files from an archive into dir. The archive format
+// (tar, tar.gz, tar.zst or zip) is detected from the content.
+func (c *Collector) CollectArchive(r io.Reader, dir string) error {
+	reader := bufio.NewReader(r)
+	magic, err := reader.Peek(4)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	a := &archiveCollector{
+		coll:  c,
+		dir:   dir,
+		paths: make(map[string]struct{}),
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, magicZip):
+		return a.collectZip(reader)
+
+	case bytes.HasPrefix(magic, magicGzip):
+		decomp, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer decomp.Close()
+		return a.collectTar(decomp)
+
+	case bytes.HasPrefix(magic, magicZstd):
+		decomp, err := zstd.NewReader(reader, zstd.WithDecoderMaxMemory(zstdMaxMemory))
+		if err != nil {
+			return err
+		}
+		defer decomp.Close()
+		return a.collectTar(decomp)
+
+	default:
+		return a.collectTar(reader)
+	}
+}
+
+type archiveCollector struct {
+	coll  *Collector
+	dir   string
+	paths map[string]struct{}
+}
+
+func (a *archiveCollector) collectTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := a.addDir(hdr.Name); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := a.addFile(hdr.Name, hdr.Size, tr); err != nil {
+				return err
+			}
+		case tar.TypeXGlobalHeader:
+			continue
+		default:
+			return fmt.Errorf("%w: %s", ErrUnsupportedArchiveEntry, hdr.Name)
+		}
+	}
+
+	return a.addDir(".")
+}
+
+func (a *archiveCollector) collectZip(r io.Reader) error {
+	// Zip central directory is located at the end of archive; buffer
+	// the archive to a temp file to allow random access.
+	file, err := os.CreateTemp("", "pageship-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	size, err := io.Copy(file, r)
+	if err != nil {
+		return err
+	}
+
+	zr, err := zip.NewReader(file, size)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := a.addDir(f.Name); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			if a.coll.maxSize > 0 && f.UncompressedSize64 > uint64(a.coll.maxSize) {
+				return ErrTooLarge
+			}
+
+			reader, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.Name, err)
+			}
+			err = a.addFile(f.Name, int64(f.UncompressedSize64), reader)
+			reader.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: %s", ErrUnsupportedArchiveEntry, f.Name)
+		}
+	}
+
+	return a.addDir(".")
+}
+
+func cleanArchivePath(name string) (string, error) {
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("%w: %s", ErrInvalidArchivePath, name)
+		}
+	}
+	return strings.TrimPrefix(path.Clean("/"+name), "/"), nil
+}
+
+func (a *archiveCollector) addDir(name string) error {
+	p, err := cleanArchivePath(name)
+	if err != nil {
+		return err
+	}
+
+	dirPath := path.Join(a.dir, p) + "/"
+	if p != "" && a.coll.Ignore.Match(p, true) {
+		return nil
+	}
+	if _, ok := a.paths[dirPath]; ok {
+		return nil
+	} else if _, ok := a.paths[strings.TrimSuffix(dirPath, "/")]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicatedArchiveEntry, name)
+	}
+
+	if p != "" {
+		if err := a.addDir(path.Dir(p)); err != nil {
+			return err
+		}
+	}
+
+	header := &tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     dirPath,
+		ModTime:  a.coll.modTime,
+		Size:     0,
+	}
+	if err := a.coll.writer.WriteHeader(header); err != nil {
+		return err
+	}
+
+	a.paths[dirPath] = struct{}{}
+	return a.coll.addEntry(models.FileEntry{
+		Path:        header.Name,
+		Size:        0,
+		Hash:        "",
+		ContentType: "",
+	})
+}
+
+func (a *archiveCollector) addFile(name string, size int64, r io.Reader) error {
+	p, err := cleanArchivePath(name)
+	if err != nil {
+		return err
+	}
+	if p == "" {
+		return fmt.Errorf("%w: %s", ErrInvalidArchivePath, name)
+	}
+
+	filePath := path.Join(a.dir, p)
+	if a.coll.Ignore.Match(p, false) {
+		a.coll.excluded = append(a.coll.excluded, models.FileEntry{Path: filePath, Size: size})
+		return nil
+	}
+	if _, ok := a.paths[filePath]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicatedArchiveEntry, name)
+	} else if _, ok := a.paths[filePath+"/"]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicatedArchiveEntry, name)
+	}
+	a.paths[filePath] = struct{}{}
+
+	// Check declared size before copying, so oversized entries are not
+	// written to tarball.
+	if a.coll.maxSize > 0 && a.coll.size+size > a.coll.maxSize {
+		return ErrTooLarge
+	}
+
+	if err := a.addDir(path.Dir(p)); err != nil {
+		return err
+	}
+
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     filePath,
+		ModTime:  a.coll.modTime,
+		Size:     size,
+	}
+	if err := a.coll.writer.WriteHeader(header); err != nil {
+		return err
+	}
+
+	initialBytes := make([]byte, 512)
+	n, _ := io.ReadFull(r, initialBytes)
+	initialBytes = initialBytes[:n]
+	contentType := models.DetectContentType(header.Name, initialBytes)
+
+	// Limit the read to declared size, and verify actual size matches.
+	fileData := io.LimitReader(io.MultiReader(bytes.NewBuffer(initialBytes), r), size+1)
+	h := NewFileHash()
+	n64, err := io.Copy(a.coll.writer, io.TeeReader(fileData, h))
+	if errors.Is(err, tar.ErrWriteTooLong) || (err == nil && n64 != size) {
+		return fmt.Errorf("%w: %s", ErrUnexpectedFileSize, name)
+	} else if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return a.coll.addEntry(models.FileEntry{
+		Path:        header.Name,
+		Size:        header.Size,
+		Hash:        h.Sum(),
+		ContentType: contentType,
+		MD5:         h.MD5(),
+	})
+}
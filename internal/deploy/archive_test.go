@@ -0,0 +1,165 @@
+package deploy_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/oursky/pageship/internal/deploy"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type archiveFile struct {
+	name string
+	data string
+}
+
+func makeTar(files []archiveFile) []byte {
+	buf := new(bytes.Buffer)
+	w := tar.NewWriter(buf)
+	for _, f := range files {
+		w.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: f.name, Size: int64(len(f.data))})
+		w.Write([]byte(f.data))
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func makeZip(files []archiveFile) []byte {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for _, f := range files {
+		fw, _ := w.Create(f.name)
+		fw.Write([]byte(f.data))
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func gzipData(data []byte) []byte {
+	buf := new(bytes.Buffer)
+	w := gzip.NewWriter(buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func zstdData(data []byte) []byte {
+	buf := new(bytes.Buffer)
+	w, _ := zstd.NewWriter(buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func collectArchive(t *testing.T, maxSize int64, archive []byte) ([]models.FileEntry, *os.File, error) {
+	tarfile, err := os.CreateTemp(t.TempDir(), "*.tar.zst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tarfile.Close() })
+
+	coll, err := deploy.NewCollector(time.Now(), maxSize, tarfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = coll.CollectArchive(bytes.NewReader(archive), "/public")
+	coll.Close()
+	tarfile.Seek(0, io.SeekStart)
+	return coll.Files(), tarfile, err
+}
+
+func paths(files []models.FileEntry) []string {
+	var p []string
+	for _, f := range files {
+		p = append(p, f.Path)
+	}
+	return p
+}
+
+func TestCollectArchive(t *testing.T) {
+	files := []archiveFile{
+		{name: "./index.html", data: "<html></html>"},
+		{name: "assets/js/main.js", data: "console.log(1)"},
+	}
+	expected := []string{
+		"/public/",
+		"/public/index.html",
+		"/public/assets/",
+		"/public/assets/js/",
+		"/public/assets/js/main.js",
+	}
+
+	for name, archive := range map[string][]byte{
+		"tar":    makeTar(files),
+		"tar.gz": gzipData(makeTar(files)),
+		"zip":    makeZip(files),
+	} {
+		t.Run(name, func(t *testing.T) {
+			entries, tarfile, err := collectArchive(t, 0, archive)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, paths(entries))
+
+			var extracted []string
+			err = deploy.ExtractFiles(tarfile, deploy.DefaultHashAlgorithm, entries, func(e models.FileEntry, r io.Reader) error {
+				extracted = append(extracted, e.Path)
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, expected, extracted)
+		})
+	}
+}
+
+func TestCollectArchiveInvalid(t *testing.T) {
+	_, _, err := collectArchive(t, 0, makeTar([]archiveFile{
+		{name: "../secret", data: "x"},
+	}))
+	assert.ErrorIs(t, err, deploy.ErrInvalidArchivePath)
+
+	_, _, err = collectArchive(t, 0, makeTar([]archiveFile{
+		{name: "a.txt", data: "x"},
+		{name: "./a.txt", data: "y"},
+	}))
+	assert.ErrorIs(t, err, deploy.ErrDuplicatedArchiveEntry)
+
+	_, _, err = collectArchive(t, 4, makeZip([]archiveFile{
+		{name: "a.txt", data: "hello world"},
+	}))
+	assert.ErrorIs(t, err, deploy.ErrTooLarge)
+}
+
+func TestCollectArchiveTooLarge(t *testing.T) {
+	files := []archiveFile{
+		{name: "a.txt", data: "hello"},
+		{name: "b.txt", data: "world"},
+	}
+	for name, archive := range map[string][]byte{
+		"tar":     makeTar(files),
+		"tar.zst": zstdData(makeTar(files)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := collectArchive(t, 8, archive)
+			assert.ErrorIs(t, err, deploy.ErrTooLarge)
+		})
+	}
+
+	// Declared size is checked before reading entry data.
+	buf := new(bytes.Buffer)
+	w := tar.NewWriter(buf)
+	w.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "bomb.bin", Size: 1 << 40})
+	w.Flush()
+
+	_, tarfile, err := collectArchive(t, 1<<20, zstdData(buf.Bytes()))
+	assert.ErrorIs(t, err, deploy.ErrTooLarge)
+	fi, err := tarfile.Stat()
+	assert.NoError(t, err)
+	assert.Less(t, fi.Size(), int64(1<<20))
+}
@@ -15,19 +15,31 @@ import (
 )
 
 var ErrTooManyFiles error = Error("too many files collected")
+var ErrTooLarge error = Error("collected files too large")
 
 type Collector struct {
-	files   []models.FileEntry
-	modTime time.Time
+	// Ignore excludes matching files from collection, relative to the
+	// collected directory.
+	Ignore *IgnoreMatcher
+
+	files    []models.FileEntry
+	excluded []models.FileEntry
+	size     int64
+	maxSize  int64
+	modTime  time.Time
 
 	closed bool
 	comp   *zstd.Encoder
 	writer *tar.Writer
 }
 
-func NewCollector(modTime time.Time, tarfile *os.File) (coll *Collector, err error) {
+// NewCollector creates a collector writing tarball to tarfile. Total size of
+// collected files is limited to maxSize bytes, unlimited if zero.
+func NewCollector(modTime time.Time, maxSize int64, tarfile *os.File) (coll *Collector, err error) {
 	coll = &Collector{
 		files:   nil,
+		size:    0,
+		maxSize: maxSize,
 		modTime: modTime,
 		closed:  false,
 	}
@@ -66,6 +78,25 @@ func (c *Collector) Files() []models.FileEntry {
 	return c.files
 }
 
+// Excluded returns the entries excluded by ignore patterns. Size of excluded
+// directory is the total size of files within.
+func (c *Collector) Excluded() []models.FileEntry {
+	return c.excluded
+}
+
+func (c *Collector) addEntry(entry models.FileEntry) error {
+	c.files = append(c.files, entry)
+	if len(c.files) > models.MaxFiles {
+		return ErrTooManyFiles
+	}
+
+	c.size += entry.Size
+	if c.maxSize > 0 && c.size > c.maxSize {
+		return ErrTooLarge
+	}
+	return nil
+}
+
 func (c *Collector) AddDir(path string) {
 	header := &tar.Header{
 		Typeflag: tar.TypeDir,
@@ -90,6 +121,7 @@ func (c *Collector) AddFile(path string, data []byte) error {
 		return err
 	}
 	hash := h.Sum()
+	md5 := h.MD5()
 
 	header := &tar.Header{
 		Typeflag: tar.TypeReg,
@@ -100,13 +132,13 @@ func (c *Collector) AddFile(path string, data []byte) error {
 	c.writer.WriteHeader(header)
 	c.writer.Write(data)
 
-	c.files = append(c.files, models.FileEntry{
+	return c.addEntry(models.FileEntry{
 		Path:        header.Name,
 		Size:        header.Size,
 		Hash:        hash,
 		ContentType: models.DetectContentType(header.Name, data),
+		MD5:         md5,
 	})
-	return nil
 }
 
 func (c *Collector) Collect(fsys fs.FS, dir string) error {
@@ -116,6 +148,10 @@ func (c *Collector) Collect(fsys fs.FS, dir string) error {
 			return err
 		}
 
+		if p != "." && c.Ignore.Match(p, d.IsDir()) {
+			return c.exclude(fsys, p, d, dir)
+		}
+
 		if d.Type()&fs.ModeSymlink == fs.ModeSymlink {
 			return fs.WalkDir(fsys, p, walker)
 		}
@@ -125,14 +161,44 @@ func (c *Collector) Collect(fsys fs.FS, dir string) error {
 			return err
 		}
 
-		c.files = append(c.files, entry)
-		if len(c.files) > models.MaxFiles {
-			return ErrTooManyFiles
+		return c.addEntry(entry)
+	}
+
+	return fs.WalkDir(fsys, ".", walker)
+}
+
+func (c *Collector) exclude(fsys fs.FS, p string, d fs.DirEntry, dir string) error {
+	entry := models.FileEntry{Path: path.Join(dir, filepath.ToSlash(p))}
+	if !d.IsDir() {
+		info, err := d.Info()
+		if err != nil {
+			return err
 		}
+		entry.Size = info.Size()
+		c.excluded = append(c.excluded, entry)
 		return nil
 	}
 
-	return fs.WalkDir(fsys, ".", walker)
+	entry.Path += "/"
+	err := fs.WalkDir(fsys, p, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			entry.Size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.excluded = append(c.excluded, entry)
+	return fs.SkipDir
 }
 
 func addFile(fsys fs.FS, filePath string, d fs.DirEntry, dir string, modTime time.Time, writer *tar.Writer) (models.FileEntry, error) {
@@ -157,6 +223,7 @@ func addFile(fsys fs.FS, filePath string, d fs.DirEntry, dir string, modTime tim
 	writer.WriteHeader(&header)
 
 	hash := ""
+	md5 := ""
 	contentType := ""
 	if !info.IsDir() {
 		file, err := fsys.Open(filePath)
@@ -176,6 +243,7 @@ func addFile(fsys fs.FS, filePath string, d fs.DirEntry, dir string, modTime tim
 			return models.FileEntry{}, err
 		}
 		hash = h.Sum()
+		md5 = h.MD5()
 	}
 
 	return models.FileEntry{
@@ -183,5 +251,6 @@ func addFile(fsys fs.FS, filePath string, d fs.DirEntry, dir string, modTime tim
 		Size:        header.Size,
 		Hash:        hash,
 		ContentType: contentType,
+		MD5:         md5,
 	}, nil
 }
@@ -0,0 +1,60 @@
+package deploy
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/oursky/pageship/internal/models"
+)
+
+type FileChange string
+
+const (
+	FileChangeAdded   FileChange = "added"
+	FileChangeRemoved FileChange = "removed"
+	FileChangeChanged FileChange = "changed"
+)
+
+type FileDiff struct {
+	Change  FileChange
+	Path    string
+	OldSize int64
+	NewSize int64
+}
+
+func (d FileDiff) SizeDelta() int64 {
+	return d.NewSize - d.OldSize
+}
+
+// DiffFiles compares file entries by hash, ignoring directories. The result
+// is sorted by path.
+func DiffFiles(oldFiles []models.FileEntry, newFiles []models.FileEntry) []FileDiff {
+	old := make(map[string]models.FileEntry)
+	for _, f := range oldFiles {
+		if !strings.HasSuffix(f.Path, "/") {
+			old[f.Path] = f
+		}
+	}
+
+	var diffs []FileDiff
+	for _, f := range newFiles {
+		if strings.HasSuffix(f.Path, "/") {
+			continue
+		}
+
+		o, ok := old[f.Path]
+		delete(old, f.Path)
+		switch {
+		case !ok:
+			diffs = append(diffs, FileDiff{Change: FileChangeAdded, Path: f.Path, NewSize: f.Size})
+		case o.Hash != f.Hash:
+			diffs = append(diffs, FileDiff{Change: FileChangeChanged, Path: f.Path, OldSize: o.Size, NewSize: f.Size})
+		}
+	}
+	for _, o := range old {
+		diffs = append(diffs, FileDiff{Change: FileChangeRemoved, Path: o.Path, OldSize: o.Size})
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
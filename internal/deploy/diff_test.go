@@ -0,0 +1,35 @@
+package deploy_test
+
+import (
+	"testing"
+
+	"github.com/oursky/pageship/internal/deploy"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffFiles(t *testing.T) {
+	oldFiles := []models.FileEntry{
+		{Path: "/public/", Size: 0},
+		{Path: "/public/index.html", Size: 10, Hash: "a"},
+		{Path: "/public/main.js", Size: 20, Hash: "b"},
+		{Path: "/public/old.css", Size: 30, Hash: "c"},
+	}
+	newFiles := []models.FileEntry{
+		{Path: "/public/", Size: 0},
+		{Path: "/public/assets/", Size: 0},
+		{Path: "/public/assets/new.css", Size: 40, Hash: "d"},
+		{Path: "/public/index.html", Size: 10, Hash: "a"},
+		{Path: "/public/main.js", Size: 25, Hash: "e"},
+	}
+
+	diffs := deploy.DiffFiles(oldFiles, newFiles)
+	assert.Equal(t, []deploy.FileDiff{
+		{Change: deploy.FileChangeAdded, Path: "/public/assets/new.css", NewSize: 40},
+		{Change: deploy.FileChangeChanged, Path: "/public/main.js", OldSize: 20, NewSize: 25},
+		{Change: deploy.FileChangeRemoved, Path: "/public/old.css", OldSize: 30},
+	}, diffs)
+	assert.Equal(t, int64(5), diffs[1].SizeDelta())
+
+	assert.Empty(t, deploy.DiffFiles(oldFiles, oldFiles))
+}
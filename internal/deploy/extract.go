@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/klauspost/compress/zstd"
 	"github.com/oursky/pageship/internal/models"
@@ -12,12 +13,24 @@ import (
 
 var ErrUnexpectedFile error = Error("unexpected file")
 var ErrUnexpectedFileSize error = Error("unexpected file size")
+var ErrUnexpectedFileHash error = Error("unexpected file hash")
 var ErrMissingFile error = Error("missing file")
 
 const zstdWindowSize = 1024 * 1024 * 1 // 1MB
 const zstdMaxMemory = 1024 * 1024 * 1  // 1MB
 
-func ExtractFiles(r io.Reader, files []models.FileEntry, handle func(models.FileEntry, io.Reader) error) error {
+// ExtractFiles extracts files in the archive, verifying the size & hash
+// (computed using hashAlgorithm) of each file while extracting.
+func ExtractFiles(
+	r io.Reader,
+	hashAlgorithm string,
+	files []models.FileEntry,
+	handle func(models.FileEntry, io.Reader) error,
+) error {
+	if _, err := NewFileHashWithAlgorithm(hashAlgorithm); err != nil {
+		return err
+	}
+
 	pending := make(map[string]models.FileEntry)
 	for _, entry := range files {
 		pending[entry.Path] = entry
@@ -47,8 +60,22 @@ func ExtractFiles(r io.Reader, files []models.FileEntry, handle func(models.File
 			return fmt.Errorf("%w: %s", ErrUnexpectedFileSize, hdr.Name)
 		}
 
-		if err := handle(file, tr); err != nil {
-			return fmt.Errorf("%s: %w", hdr.Name, err)
+		if strings.HasSuffix(file.Path, "/") {
+			if err := handle(file, tr); err != nil {
+				return fmt.Errorf("%s: %w", hdr.Name, err)
+			}
+		} else {
+			hash, _ := NewFileHashWithAlgorithm(hashAlgorithm)
+			if err := handle(file, io.TeeReader(tr, hash)); err != nil {
+				return fmt.Errorf("%s: %w", hdr.Name, err)
+			}
+			// Hash remaining content not consumed by handler.
+			if _, err := io.Copy(hash, tr); err != nil {
+				return err
+			}
+			if hash.Sum() != file.Hash {
+				return fmt.Errorf("%w: %s", ErrUnexpectedFileHash, hdr.Name)
+			}
 		}
 
 		delete(pending, hdr.Name)
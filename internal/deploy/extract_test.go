@@ -0,0 +1,88 @@
+package deploy_test
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/deploy"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func collectFiles(t *testing.T, files []archiveFile) ([]models.FileEntry, *os.File) {
+	tarfile, err := os.CreateTemp(t.TempDir(), "*.tar.zst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tarfile.Close() })
+
+	coll, err := deploy.NewCollector(time.Now(), 0, tarfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coll.AddDir("/")
+	for _, f := range files {
+		if err := coll.AddFile(f.name, []byte(f.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	coll.Close()
+	tarfile.Seek(0, io.SeekStart)
+	return coll.Files(), tarfile
+}
+
+func TestExtractFilesVerifyHash(t *testing.T) {
+	files := []archiveFile{
+		{name: "/index.html", data: "<html></html>"},
+		{name: "/main.js", data: "console.log(1)"},
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		entries, tarfile := collectFiles(t, files)
+
+		contents := make(map[string]string)
+		err := deploy.ExtractFiles(tarfile, deploy.DefaultHashAlgorithm, entries, func(e models.FileEntry, r io.Reader) error {
+			data, err := io.ReadAll(r)
+			contents[e.Path] = string(data)
+			return err
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"/":           "",
+			"/index.html": "<html></html>",
+			"/main.js":    "console.log(1)",
+		}, contents)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		entries, tarfile := collectFiles(t, files)
+		entries[2].Hash = entries[1].Hash
+
+		err := deploy.ExtractFiles(tarfile, deploy.DefaultHashAlgorithm, entries, func(e models.FileEntry, r io.Reader) error {
+			// Content not consumed by handler is verified too.
+			return nil
+		})
+		assert.ErrorIs(t, err, deploy.ErrUnexpectedFileHash)
+		assert.ErrorContains(t, err, "/main.js")
+	})
+
+	t.Run("algorithm mismatch", func(t *testing.T) {
+		entries, tarfile := collectFiles(t, files)
+
+		err := deploy.ExtractFiles(tarfile, deploy.HashAlgorithmSHA256, entries, func(e models.FileEntry, r io.Reader) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, deploy.ErrUnexpectedFileHash)
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		entries, tarfile := collectFiles(t, files)
+
+		err := deploy.ExtractFiles(tarfile, "md5", entries, func(e models.FileEntry, r io.Reader) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, deploy.ErrUnsupportedHashAlgorithm)
+	})
+}
@@ -1,24 +1,69 @@
 package deploy
 
 import (
+	"crypto/md5"
+	"crypto/sha256"
 	"encoding/base64"
+	"fmt"
 	"hash"
 
 	"golang.org/x/crypto/sha3"
 )
 
+const (
+	HashAlgorithmSHA3_256 = "sha3-256"
+	HashAlgorithmSHA256   = "sha256"
+
+	DefaultHashAlgorithm = HashAlgorithmSHA3_256
+)
+
+var ErrUnsupportedHashAlgorithm error = Error("unsupported hash algorithm")
+
+var hashAlgorithms = map[string]func() hash.Hash{
+	HashAlgorithmSHA3_256: sha3.New256,
+	HashAlgorithmSHA256:   sha256.New,
+}
+
 type FileHash struct {
 	hash hash.Hash
+	md5  hash.Hash
 }
 
+// NewFileHash creates file hash using the default algorithm, computing MD5
+// checksum as well.
 func NewFileHash() *FileHash {
-	return &FileHash{hash: sha3.New256()}
+	return &FileHash{hash: sha3.New256(), md5: md5.New()}
+}
+
+// NewFileHashWithAlgorithm creates file hash using the named algorithm;
+// empty name refers to the default algorithm.
+func NewFileHashWithAlgorithm(algorithm string) (*FileHash, error) {
+	if algorithm == "" {
+		algorithm = DefaultHashAlgorithm
+	}
+	newHash, ok := hashAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedHashAlgorithm, algorithm)
+	}
+	return &FileHash{hash: newHash()}, nil
 }
 
 func (h *FileHash) Write(p []byte) (int, error) {
+	if h.md5 != nil {
+		h.md5.Write(p)
+	}
 	return h.hash.Write(p)
 }
 
 func (h *FileHash) Sum() string {
 	return base64.RawURLEncoding.EncodeToString(h.hash.Sum(nil))
 }
+
+// MD5 returns the base64-encoded MD5 checksum, as in Content-MD5 header;
+// empty if not computed.
+func (h *FileHash) MD5() string {
+	if h.md5 == nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(h.md5.Sum(nil))
+}
@@ -0,0 +1,181 @@
+package deploy
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const IgnoreFileName = ".pageshipignore"
+
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+	base    string
+}
+
+// IgnoreMatcher matches slash-separated relative paths against patterns
+// following gitignore semantics.
+type IgnoreMatcher struct {
+	rules []ignoreRule
+}
+
+func NewIgnoreMatcher(patterns []string) (*IgnoreMatcher, error) {
+	m := &IgnoreMatcher{}
+	if err := m.Add("", patterns); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Add appends patterns relative to a directory containing the matched
+// directory, with base being the path of matched directory relative to it.
+// Later patterns take precedence over earlier ones.
+func (m *IgnoreMatcher) Add(base string, patterns []string) error {
+	if base = path.Clean(base); base == "." {
+		base = ""
+	}
+	for _, p := range patterns {
+		rule, ok, err := compileIgnoreRule(p)
+		if err != nil {
+			return err
+		} else if ok {
+			rule.base = base
+			m.rules = append(m.rules, rule)
+		}
+	}
+	return nil
+}
+
+// LoadIgnoreFile reads ignore patterns from the named file. Missing file is
+// treated as empty.
+func LoadIgnoreFile(fsys fs.FS, name string) ([]string, error) {
+	file, err := fsys.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+// Match reports whether the path is excluded. Paths under an excluded
+// directory are always excluded.
+func (m *IgnoreMatcher) Match(p string, isDir bool) bool {
+	if m == nil || len(m.rules) == 0 {
+		return false
+	}
+
+	p = strings.Trim(p, "/")
+	segments := strings.Split(p, "/")
+	for i := 1; i < len(segments); i++ {
+		if m.match(strings.Join(segments[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.match(p, isDir)
+}
+
+func (m *IgnoreMatcher) match(p string, isDir bool) bool {
+	excluded := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.pattern.MatchString(path.Join(r.base, p)) {
+			excluded = !r.negate
+		}
+	}
+	return excluded
+}
+
+func compileIgnoreRule(line string) (rule ignoreRule, ok bool, err error) {
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return
+	}
+
+	// Patterns containing a slash are relative to the root; otherwise
+	// they match at any level.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case strings.HasPrefix(line[i:], "**/") && (i == 0 || line[i-1] == '/'):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "**") && i+2 == len(line) && (i == 0 || line[i-1] == '/'):
+			expr.WriteString(".*")
+			i++
+		case ch == '*':
+			expr.WriteString("[^/]*")
+		case ch == '?':
+			expr.WriteString("[^/]")
+		case ch == '[':
+			end := strings.IndexByte(line[i+1:], ']')
+			if end == -1 {
+				expr.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := line[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case ch == '\\' && i+1 < len(line):
+			i++
+			expr.WriteString(regexp.QuoteMeta(string(line[i])))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	expr.WriteString("$")
+
+	rule.pattern, err = regexp.Compile(expr.String())
+	if err != nil {
+		return
+	}
+	ok = true
+	return
+}
@@ -0,0 +1,121 @@
+package deploy_test
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/oursky/pageship/internal/deploy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	m, err := deploy.NewIgnoreMatcher([]string{
+		"# comment",
+		"",
+		".DS_Store",
+		"*.map",
+		"!keep.js.map",
+		"/build/",
+		"docs/**/*.tmp",
+		"logs/",
+	})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		path     string
+		isDir    bool
+		excluded bool
+	}{
+		{".DS_Store", false, true},
+		{"assets/.DS_Store", false, true},
+		{"main.js", false, false},
+		{"main.js.map", false, true},
+		{"js/main.js.map", false, true},
+		{"js/keep.js.map", false, false},
+		{"build", true, true},
+		{"build", false, false},
+		{"build/index.html", false, true},
+		{"src/build", true, false},
+		{"docs/a.tmp", false, true},
+		{"docs/a/b/c.tmp", false, true},
+		{"a/docs/c.tmp", false, false},
+		{"logs", true, true},
+		{"a/logs/x.txt", false, true},
+		{"# comment", false, false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.excluded, m.Match(c.path, c.isDir), c.path)
+	}
+
+	var nilMatcher *deploy.IgnoreMatcher
+	assert.False(t, nilMatcher.Match("a", false))
+}
+
+func TestIgnoreMatcherBase(t *testing.T) {
+	m := &deploy.IgnoreMatcher{}
+	assert.NoError(t, m.Add("dist", []string{"dist/*.map", "/drafts/", "*.tmp"}))
+	assert.NoError(t, m.Add("", []string{"/drafts/"}))
+
+	cases := []struct {
+		path     string
+		isDir    bool
+		excluded bool
+	}{
+		{"main.js.map", false, true},
+		{"js/main.js.map", false, false},
+		{"drafts", true, true},
+		{"a/b.tmp", false, true},
+		{"index.html", false, false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.excluded, m.Match(c.path, c.isDir), c.path)
+	}
+
+	m = &deploy.IgnoreMatcher{}
+	assert.NoError(t, m.Add("./dist/", []string{"/drafts/", "dist/a/"}))
+	assert.False(t, m.Match("drafts", true))
+	assert.True(t, m.Match("a/b.html", false))
+}
+
+func TestCollectIgnore(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":          {Data: []byte("<html></html>")},
+		"main.js.map":         {Data: []byte("{}")},
+		".git/HEAD":           {Data: []byte("ref: refs/heads/main")},
+		".git/objects/ab/cd":  {Data: []byte("12345")},
+		"assets/.DS_Store":    {Data: []byte("x")},
+		"assets/style.css":    {Data: []byte("body {}")},
+		"assets/style.css.gz": {Data: []byte("zz")},
+	}
+
+	ignore, err := deploy.NewIgnoreMatcher([]string{".git/", ".DS_Store", "*.map"})
+	assert.NoError(t, err)
+
+	tarfile, err := os.CreateTemp(t.TempDir(), "*.tar.zst")
+	assert.NoError(t, err)
+	defer tarfile.Close()
+
+	coll, err := deploy.NewCollector(time.Now(), 0, tarfile)
+	assert.NoError(t, err)
+	coll.Ignore = ignore
+	assert.NoError(t, coll.Collect(fsys, "/public"))
+	coll.Close()
+
+	assert.Equal(t, []string{
+		"/public/",
+		"/public/assets/",
+		"/public/assets/style.css",
+		"/public/assets/style.css.gz",
+		"/public/index.html",
+	}, paths(coll.Files()))
+
+	excluded := coll.Excluded()
+	assert.Equal(t, []string{
+		"/public/.git/",
+		"/public/assets/.DS_Store",
+		"/public/main.js.map",
+	}, paths(excluded))
+	assert.Equal(t, int64(25), excluded[0].Size)
+}
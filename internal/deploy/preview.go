@@ -0,0 +1,44 @@
+package deploy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const maxDNSLabelLength = 63
+const previewHashLength = 8
+
+// PreviewDeploymentName maps a git branch to a stable deployment name that is
+// a valid DNS label. Branch names that are not valid labels as-is are
+// sanitized and suffixed with a hash of the branch, so different branches
+// would not map to same name.
+func PreviewDeploymentName(branch string) string {
+	branch = strings.TrimPrefix(branch, "refs/heads/")
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(branch) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteByte('-')
+		}
+	}
+	name := strings.TrimSuffix(b.String(), "-")
+	if name == branch && len(name) <= maxDNSLabelLength {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(branch))
+	hash := hex.EncodeToString(sum[:])[:previewHashLength]
+
+	maxLen := maxDNSLabelLength - 1 - previewHashLength
+	if len(name) > maxLen {
+		name = strings.TrimSuffix(name[:maxLen], "-")
+	}
+	if name == "" {
+		return hash
+	}
+	return name + "-" + hash
+}
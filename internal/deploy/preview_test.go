@@ -0,0 +1,36 @@
+package deploy_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/deploy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewDeploymentName(t *testing.T) {
+	assert.Equal(t, "feature-x", deploy.PreviewDeploymentName("feature-x"))
+	assert.Equal(t, "feature-x", deploy.PreviewDeploymentName("refs/heads/feature-x"))
+	assert.Equal(t, "123", deploy.PreviewDeploymentName("123"))
+
+	slash := deploy.PreviewDeploymentName("feature/login")
+	dash := deploy.PreviewDeploymentName("feature-Login")
+	assert.Regexp(t, `^feature-login-[0-9a-f]{8}$`, slash)
+	assert.Regexp(t, `^feature-login-[0-9a-f]{8}$`, dash)
+	assert.NotEqual(t, slash, dash)
+	assert.Equal(t, slash, deploy.PreviewDeploymentName("feature/login"))
+
+	assert.Regexp(t, `^fix-a-b-[0-9a-f]{8}$`, deploy.PreviewDeploymentName("--Fix__a..b--"))
+	assert.Regexp(t, `^[0-9a-f]{8}$`, deploy.PreviewDeploymentName("日本語"))
+
+	long := deploy.PreviewDeploymentName(strings.Repeat("a", 62) + "/b")
+	assert.Len(t, long, 63)
+	assert.Regexp(t, `^a{54}-[0-9a-f]{8}$`, long)
+
+	for _, branch := range []string{
+		"feature/login", "--Fix__a..b--", "日本語", strings.Repeat("a-", 40), "dependabot/npm_and_yarn/lodash-4.17.21",
+	} {
+		assert.True(t, config.ValidateDNSLabel(deploy.PreviewDeploymentName(branch)), branch)
+	}
+}
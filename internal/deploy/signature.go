@@ -0,0 +1,82 @@
+package deploy
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/models"
+	"golang.org/x/crypto/ssh"
+)
+
+var ErrInvalidSignature error = Error("invalid deployment signature")
+
+// signatureNamespace is prepended to signed data, so that signatures of
+// deployment manifests cannot be used in other contexts.
+const signatureNamespace = "pageship-deployment-v1\n"
+
+type manifest struct {
+	Files         []models.FileEntry `json:"files"`
+	HashAlgorithm string             `json:"hashAlgorithm"`
+	Config        config.SiteConfig  `json:"config"`
+}
+
+// ManifestDigest computes the digest of deployment manifest, i.e. the file
+// list, file hash algorithm and site config.
+func ManifestDigest(files []models.FileEntry, hashAlgorithm string, conf *config.SiteConfig) ([]byte, error) {
+	if hashAlgorithm == "" {
+		hashAlgorithm = DefaultHashAlgorithm
+	}
+
+	data, err := json.Marshal(manifest{
+		Files:         files,
+		HashAlgorithm: hashAlgorithm,
+		Config:        *conf,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(data)
+	return digest[:], nil
+}
+
+// SignManifest signs the manifest digest with SSH signer.
+func SignManifest(signer ssh.Signer, digest []byte) (*models.DeploymentSignature, error) {
+	sig, err := signer.Sign(rand.Reader, append([]byte(signatureNamespace), digest...))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DeploymentSignature{
+		PublicKey: string(bytes.TrimSpace(ssh.MarshalAuthorizedKey(signer.PublicKey()))),
+		Signature: base64.StdEncoding.EncodeToString(ssh.Marshal(sig)),
+	}, nil
+}
+
+// VerifyManifest verifies the signature of manifest digest, and returns the
+// signing public key.
+func VerifyManifest(signature *models.DeploymentSignature, digest []byte) (ssh.PublicKey, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(signature.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(signature.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	var sig ssh.Signature
+	if err := ssh.Unmarshal(data, &sig); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	if err := key.Verify(append([]byte(signatureNamespace), digest...), &sig); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	return key, nil
+}
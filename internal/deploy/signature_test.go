@@ -0,0 +1,85 @@
+package deploy_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/deploy"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func newSigner(t *testing.T) ssh.Signer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestManifestSignature(t *testing.T) {
+	files := []models.FileEntry{
+		{Path: "/", Size: 0, Hash: "", ContentType: ""},
+		{Path: "/index.html", Size: 13, Hash: "abc", ContentType: "text/html"},
+	}
+	conf := config.DefaultSiteConfig()
+
+	digest, err := deploy.ManifestDigest(files, "", &conf)
+	assert.NoError(t, err)
+
+	defaultDigest, err := deploy.ManifestDigest(files, deploy.DefaultHashAlgorithm, &conf)
+	assert.NoError(t, err)
+	assert.Equal(t, digest, defaultDigest)
+
+	signer := newSigner(t)
+	sig, err := deploy.SignManifest(signer, digest)
+	assert.NoError(t, err)
+
+	key, err := deploy.VerifyManifest(sig, digest)
+	assert.NoError(t, err)
+	assert.Equal(t, signer.PublicKey().Marshal(), key.Marshal())
+
+	t.Run("tampered files", func(t *testing.T) {
+		tampered := append([]models.FileEntry{}, files...)
+		tampered[1].Hash = "def"
+		digest, err := deploy.ManifestDigest(tampered, "", &conf)
+		assert.NoError(t, err)
+
+		_, err = deploy.VerifyManifest(sig, digest)
+		assert.ErrorIs(t, err, deploy.ErrInvalidSignature)
+	})
+
+	t.Run("tampered config", func(t *testing.T) {
+		tampered := conf
+		tampered.Public = "dist"
+		digest, err := deploy.ManifestDigest(files, "", &tampered)
+		assert.NoError(t, err)
+
+		_, err = deploy.VerifyManifest(sig, digest)
+		assert.ErrorIs(t, err, deploy.ErrInvalidSignature)
+	})
+
+	t.Run("substituted key", func(t *testing.T) {
+		other := newSigner(t)
+		substituted := *sig
+		substituted.PublicKey = string(ssh.MarshalAuthorizedKey(other.PublicKey()))
+
+		_, err = deploy.VerifyManifest(&substituted, digest)
+		assert.ErrorIs(t, err, deploy.ErrInvalidSignature)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err = deploy.VerifyManifest(&models.DeploymentSignature{
+			PublicKey: sig.PublicKey,
+			Signature: "invalid",
+		}, digest)
+		assert.ErrorIs(t, err, deploy.ErrInvalidSignature)
+	})
+}
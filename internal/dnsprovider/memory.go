@@ -0,0 +1,67 @@
+package dnsprovider
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Memory is a DNS provider storing records in memory. It serves as a stand-in
+// of real DNS provider in tests, and can be used as resolver of its records.
+type Memory struct {
+	lock    sync.RWMutex
+	records map[string][]string
+}
+
+func NewMemory() *Memory {
+	return &Memory{records: make(map[string][]string)}
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+func (m *Memory) SetTXTRecord(ctx context.Context, name string, value string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	name = normalizeName(name)
+	for _, v := range m.records[name] {
+		if v == value {
+			return nil
+		}
+	}
+	m.records[name] = append(m.records[name], value)
+	return nil
+}
+
+func (m *Memory) DeleteTXTRecord(ctx context.Context, name string, value string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	name = normalizeName(name)
+	var values []string
+	for _, v := range m.records[name] {
+		if v != value {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		delete(m.records, name)
+	} else {
+		m.records[name] = values
+	}
+	return nil
+}
+
+func (m *Memory) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	values, ok := m.records[normalizeName(name)]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return append([]string(nil), values...), nil
+}
@@ -0,0 +1,33 @@
+package dnsprovider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Provider manages DNS TXT records, used for solving ACME DNS-01 challenges.
+type Provider interface {
+	SetTXTRecord(ctx context.Context, name string, value string) error
+	DeleteTXTRecord(ctx context.Context, name string, value string) error
+}
+
+// New creates a DNS provider from URI; the scheme of URI selects the
+// provider:
+//   - rfc2136://: DNS UPDATE to name server; see NewRFC2136.
+//
+// In-memory provider (see NewMemory) cannot be configured, since records kept
+// in memory are not visible to ACME servers.
+func New(uri string) (Provider, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DNS provider URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "rfc2136":
+		return NewRFC2136(u)
+	default:
+		return nil, fmt.Errorf("unsupported DNS provider: %q", u.Scheme)
+	}
+}
@@ -0,0 +1,176 @@
+package dnsprovider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	rfc2136DefaultTTL     = 60
+	rfc2136DefaultTimeout = 10 * time.Second
+)
+
+// RFC2136 manages records through DNS UPDATE messages (RFC 2136), optionally
+// authenticated with TSIG (RFC 8945).
+type RFC2136 struct {
+	// Server is the address of the primary name server of the zone.
+	Server string
+	// Zone is the zone containing the records.
+	Zone string
+
+	TSIGKeyName   string
+	TSIGSecret    string
+	TSIGAlgorithm string
+
+	TTL     uint32
+	Timeout time.Duration
+}
+
+// NewRFC2136 creates RFC2136 provider from URL of form
+// 'rfc2136://<TSIG key name>:<TSIG secret>@<server>:<port>?zone=<zone>'.
+//
+// Supported query parameters:
+//   - zone: the zone to update (required)
+//   - algorithm: the TSIG algorithm (default to 'hmac-sha256')
+//   - ttl: the TTL of created records in seconds (default to 60)
+func NewRFC2136(u *url.URL) (*RFC2136, error) {
+	query := u.Query()
+
+	p := &RFC2136{
+		Server:        u.Host,
+		Zone:          query.Get("zone"),
+		TSIGAlgorithm: dns.HmacSHA256,
+		TTL:           rfc2136DefaultTTL,
+		Timeout:       rfc2136DefaultTimeout,
+	}
+	if p.Server == "" {
+		return nil, fmt.Errorf("rfc2136: server is required")
+	}
+	if _, _, err := net.SplitHostPort(p.Server); err != nil {
+		p.Server = net.JoinHostPort(p.Server, "53")
+	}
+	if p.Zone == "" {
+		return nil, fmt.Errorf("rfc2136: zone is required")
+	}
+
+	if u.User != nil {
+		p.TSIGKeyName = u.User.Username()
+		p.TSIGSecret, _ = u.User.Password()
+	}
+	if alg := query.Get("algorithm"); alg != "" {
+		p.TSIGAlgorithm = dns.Fqdn(alg)
+	}
+	if ttl := query.Get("ttl"); ttl != "" {
+		n, err := strconv.ParseUint(ttl, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("rfc2136: invalid TTL: %w", err)
+		}
+		p.TTL = uint32(n)
+	}
+
+	return p, nil
+}
+
+func (p *RFC2136) record(name string, value string) (*dns.TXT, error) {
+	name = dns.Fqdn(name)
+	if !dns.IsSubDomain(dns.Fqdn(p.Zone), name) {
+		return nil, fmt.Errorf("rfc2136: record %q is not in zone %q", name, p.Zone)
+	}
+	return &dns.TXT{
+		Hdr: dns.RR_Header{
+			Name:   name,
+			Rrtype: dns.TypeTXT,
+			Class:  dns.ClassINET,
+			Ttl:    p.TTL,
+		},
+		Txt: []string{value},
+	}, nil
+}
+
+func (p *RFC2136) client() *dns.Client {
+	c := &dns.Client{Net: "tcp", Timeout: p.Timeout}
+	if p.TSIGKeyName != "" {
+		c.TsigSecret = map[string]string{dns.Fqdn(p.TSIGKeyName): p.TSIGSecret}
+	}
+	return c
+}
+
+func (p *RFC2136) exchange(ctx context.Context, m *dns.Msg) (*dns.Msg, error) {
+	if p.TSIGKeyName != "" {
+		m.SetTsig(dns.Fqdn(p.TSIGKeyName), p.TSIGAlgorithm, 300, time.Now().Unix())
+	}
+
+	r, _, err := p.client().ExchangeContext(ctx, m, p.Server)
+	if err != nil {
+		return nil, fmt.Errorf("rfc2136: %w", err)
+	}
+	return r, nil
+}
+
+func (p *RFC2136) update(ctx context.Context, rr *dns.TXT, insert bool) error {
+	m := new(dns.Msg)
+	m.SetUpdate(dns.Fqdn(p.Zone))
+	if insert {
+		m.Insert([]dns.RR{rr})
+	} else {
+		m.Remove([]dns.RR{rr})
+	}
+
+	r, err := p.exchange(ctx, m)
+	if err != nil {
+		return err
+	}
+	if r.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("rfc2136: server responded %s", dns.RcodeToString[r.Rcode])
+	}
+	return nil
+}
+
+func (p *RFC2136) SetTXTRecord(ctx context.Context, name string, value string) error {
+	rr, err := p.record(name, value)
+	if err != nil {
+		return err
+	}
+	return p.update(ctx, rr, true)
+}
+
+func (p *RFC2136) DeleteTXTRecord(ctx context.Context, name string, value string) error {
+	rr, err := p.record(name, value)
+	if err != nil {
+		return err
+	}
+	return p.update(ctx, rr, false)
+}
+
+// LookupTXT queries the TXT records from the server directly.
+func (p *RFC2136) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), dns.TypeTXT)
+
+	r, err := p.exchange(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	if r.Rcode == dns.RcodeNameError {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	} else if r.Rcode != dns.RcodeSuccess {
+		return nil, fmt.Errorf("rfc2136: server responded %s", dns.RcodeToString[r.Rcode])
+	}
+
+	var values []string
+	for _, rr := range r.Answer {
+		if txt, ok := rr.(*dns.TXT); ok {
+			values = append(values, txt.Txt...)
+		}
+	}
+	if len(values) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return values, nil
+}
@@ -0,0 +1,121 @@
+package dnsprovider_test
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/oursky/pageship/internal/dnsprovider"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTSIGSecret = "c2VjcmV0LXNlY3JldC1zZWNyZXQ="
+
+type testZone struct {
+	lock    sync.Mutex
+	records map[string][]string
+}
+
+func (z *testZone) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+
+	switch req.Opcode {
+	case dns.OpcodeUpdate:
+		if req.IsTsig() == nil || w.TsigStatus() != nil {
+			resp.Rcode = dns.RcodeNotAuth
+			break
+		}
+		for _, rr := range req.Ns {
+			txt := rr.(*dns.TXT)
+			switch rr.Header().Class {
+			case dns.ClassINET:
+				z.records[txt.Hdr.Name] = append(z.records[txt.Hdr.Name], txt.Txt...)
+			case dns.ClassNONE:
+				delete(z.records, txt.Hdr.Name)
+			}
+		}
+
+	case dns.OpcodeQuery:
+		name := req.Question[0].Name
+		values, ok := z.records[name]
+		if !ok {
+			resp.Rcode = dns.RcodeNameError
+			break
+		}
+		resp.Answer = append(resp.Answer, &dns.TXT{
+			Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 60},
+			Txt: values,
+		})
+	}
+
+	if tsig := req.IsTsig(); tsig != nil {
+		resp.SetTsig(tsig.Hdr.Name, dns.HmacSHA256, 300, int64(tsig.TimeSigned))
+	}
+	w.WriteMsg(resp)
+}
+
+func startTestServer(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	server := &dns.Server{
+		Listener:   ln,
+		Handler:    &testZone{records: make(map[string][]string)},
+		TsigSecret: map[string]string{"pageship.": testTSIGSecret},
+		MsgAcceptFunc: func(dh dns.Header) dns.MsgAcceptAction {
+			return dns.MsgAccept
+		},
+	}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+
+	return ln.Addr().String()
+}
+
+func TestRFC2136(t *testing.T) {
+	ctx := context.Background()
+	addr := startTestServer(t)
+
+	u := &url.URL{
+		Scheme:   "rfc2136",
+		User:     url.UserPassword("pageship", testTSIGSecret),
+		Host:     addr,
+		RawQuery: "zone=example.com",
+	}
+	p, err := dnsprovider.New(u.String())
+	assert.NoError(t, err)
+	provider := p.(*dnsprovider.RFC2136)
+	assert.Equal(t, "example.com", provider.Zone)
+	assert.Equal(t, "pageship", provider.TSIGKeyName)
+	assert.Equal(t, testTSIGSecret, provider.TSIGSecret)
+
+	err = provider.SetTXTRecord(ctx, "_acme-challenge.example.com", "value")
+	assert.NoError(t, err)
+
+	records, err := provider.LookupTXT(ctx, "_acme-challenge.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"value"}, records)
+
+	err = provider.DeleteTXTRecord(ctx, "_acme-challenge.example.com", "value")
+	assert.NoError(t, err)
+
+	_, err = provider.LookupTXT(ctx, "_acme-challenge.example.com")
+	assert.Error(t, err)
+
+	err = provider.SetTXTRecord(ctx, "_acme-challenge.other.com", "value")
+	assert.Error(t, err)
+
+	provider.TSIGSecret = "d3Jvbmc="
+	err = provider.SetTXTRecord(ctx, "_acme-challenge.example.com", "value")
+	assert.Error(t, err)
+
+	_, err = dnsprovider.New("rfc2136://127.0.0.1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package dnsprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/mholt/acmez"
+	"github.com/mholt/acmez/acme"
+)
+
+const (
+	propagationTimeout  = 2 * time.Minute
+	propagationInterval = 2 * time.Second
+)
+
+// TXTResolver is implemented by providers able to query their records, used
+// for checking propagation of challenge records.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Solver solves ACME DNS-01 challenges using the DNS provider.
+type Solver struct {
+	Provider Provider
+}
+
+var _ acmez.Solver = &Solver{}
+var _ acmez.Waiter = &Solver{}
+
+func (s *Solver) Present(ctx context.Context, chal acme.Challenge) error {
+	return s.Provider.SetTXTRecord(ctx, chal.DNS01TXTRecordName(), chal.DNS01KeyAuthorization())
+}
+
+func (s *Solver) CleanUp(ctx context.Context, chal acme.Challenge) error {
+	return s.Provider.DeleteTXTRecord(ctx, chal.DNS01TXTRecordName(), chal.DNS01KeyAuthorization())
+}
+
+// Wait waits until the challenge record is visible from the provider.
+func (s *Solver) Wait(ctx context.Context, chal acme.Challenge) error {
+	resolver, ok := s.Provider.(TXTResolver)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, propagationTimeout)
+	defer cancel()
+
+	name, value := chal.DNS01TXTRecordName(), chal.DNS01KeyAuthorization()
+	var lastErr error
+	for {
+		records, err := resolver.LookupTXT(ctx, name)
+		var dnsErr *net.DNSError
+		if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+			lastErr = err
+		}
+		for _, r := range records {
+			if r == value {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("challenge record not propagated: %w", lastErr)
+			}
+			return errors.New("challenge record not propagated")
+		case <-time.After(propagationInterval):
+		}
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/oursky/pageship/internal/db"
 	"github.com/oursky/pageship/internal/domain"
 	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Resolver struct {
@@ -17,22 +19,94 @@ type Resolver struct {
 
 func (r *Resolver) Kind() string { return "database" }
 
-func (r *Resolver) Resolve(ctx context.Context, hostname string) (string, error) {
+func (r *Resolver) Resolve(ctx context.Context, hostname string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "domain.db.Resolve", attribute.String("host", hostname))
+	defer func() { tracing.End(span, err) }()
+
 	dom, err := r.DB.GetDomainByName(ctx, hostname)
+	label := ""
+	if errors.Is(err, models.ErrDomainNotFound) {
+		// Fallback to wildcard domain matching the subdomain label.
+		wildcard, l, ok := config.WildcardDomainOf(hostname)
+		if !ok {
+			return "", domain.ErrDomainNotFound
+		}
+		dom, err = r.DB.GetDomainByName(ctx, wildcard)
+		label = l
+	}
 	if errors.Is(err, models.ErrDomainNotFound) {
 		return "", domain.ErrDomainNotFound
+	} else if err != nil {
+		return "", err
+	}
+
+	// Only domains with verified ownership are served.
+	verification, err := r.DB.GetDomainVerification(ctx, dom.Domain, dom.AppID)
+	if errors.Is(err, models.ErrDomainVerificationNotFound) {
+		return "", domain.ErrDomainNotFound
+	} else if err != nil {
+		return "", err
+	}
+	if !verification.IsVerified() {
+		return "", domain.ErrDomainNotFound
+	}
+
+	if dom.AliasOf != nil {
+		// Alias domain is served only if its canonical domain is active.
+		canonical, err := r.DB.GetDomainByName(ctx, *dom.AliasOf)
+		if errors.Is(err, models.ErrDomainNotFound) {
+			return "", domain.ErrDomainNotFound
+		} else if err != nil {
+			return "", err
+		}
+		if canonical.AppID != dom.AppID || canonical.SiteName != dom.SiteName {
+			return "", domain.ErrDomainNotFound
+		}
 	}
 
 	app, err := r.DB.GetApp(ctx, dom.AppID)
 	if errors.Is(err, models.ErrAppNotFound) {
 		return "", domain.ErrDomainNotFound
+	} else if err != nil {
+		return "", err
+	}
+
+	siteName := dom.SiteName
+	if siteName == models.DomainSiteWildcard {
+		if label == "" {
+			// Wildcard domain name itself is not served.
+			return "", domain.ErrDomainNotFound
+		}
+		siteName = label
 	}
 
-	sub := dom.SiteName
-	if dom.SiteName == app.Config.DefaultSite {
+	sub := siteName
+	if siteName == app.Config.DefaultSite {
 		sub = ""
 	}
 	id := r.HostIDScheme.Make(dom.AppID, sub)
 
 	return id, nil
 }
+
+// WildcardDomains returns the names of active & verified wildcard domains.
+func (r *Resolver) WildcardDomains(ctx context.Context) ([]string, error) {
+	domains, err := r.DB.ListWildcardDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, d := range domains {
+		verification, err := r.DB.GetDomainVerification(ctx, d.Domain, d.AppID)
+		if errors.Is(err, models.ErrDomainVerificationNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if verification.IsVerified() {
+			names = append(names, d.Domain)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+)
+
+const ChallengeRecordPrefix = "_pageship-challenge."
+
+// TXTResolver looks up DNS TXT records; *net.Resolver satisfies it.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+type HostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+type DNSResolver interface {
+	TXTResolver
+	HostResolver
+}
+
+var DefaultDNSResolver DNSResolver = net.DefaultResolver
+
+// ChallengeRecordName returns the name of challenge TXT record of the domain;
+// wildcard domains are verified through the parent domain.
+func ChallengeRecordName(domain string) string {
+	domain = strings.TrimPrefix(domain, "*.")
+	return ChallengeRecordPrefix + strings.TrimSuffix(domain, ".")
+}
+
+// VerifyChallenge checks whether the challenge TXT record of the domain
+// contains the expected value.
+func VerifyChallenge(ctx context.Context, resolver TXTResolver, domain string, value string) (bool, error) {
+	records, err := resolver.LookupTXT(ctx, ChallengeRecordName(domain))
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	for _, r := range records {
+		if strings.TrimSpace(r) == value {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,53 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/oursky/pageship/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTXTResolver map[string][]string
+
+func (r fakeTXTResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	records, ok := r[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+type failingTXTResolver struct{}
+
+func (failingTXTResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return nil, errors.New("server failure")
+}
+
+func TestVerifyChallenge(t *testing.T) {
+	ctx := context.Background()
+	resolver := fakeTXTResolver{
+		"_pageship-challenge.example.com": {"v=spf1 -all", " token-1 "},
+		"_pageship-challenge.other.com":   {"token-2"},
+	}
+
+	assert.Equal(t, "_pageship-challenge.example.com", domain.ChallengeRecordName("example.com."))
+	assert.Equal(t, "_pageship-challenge.preview.example.com", domain.ChallengeRecordName("*.preview.example.com"))
+
+	ok, err := domain.VerifyChallenge(ctx, resolver, "example.com", "token-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = domain.VerifyChallenge(ctx, resolver, "example.com", "token-2")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = domain.VerifyChallenge(ctx, resolver, "missing.com", "token-1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = domain.VerifyChallenge(ctx, failingTXTResolver{}, "example.com", "token-1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var ErrNotInActions = errors.New("not running in GitHub Actions")
+
+// ActionsContext is the context of current GitHub Actions workflow run.
+type ActionsContext struct {
+	APIURL     string
+	Token      string
+	Repository string
+	// SHA is the commit to report status on; it is the head commit of pull
+	// request for pull request events.
+	SHA string
+	// PullRequest is the pull request number; zero if not triggered by pull
+	// request.
+	PullRequest int
+	RunURL      string
+}
+
+func (c *ActionsContext) Client() *Client {
+	return &Client{APIURL: c.APIURL, Token: c.Token}
+}
+
+// LoadActionsContext loads the context from environment variables of GitHub
+// Actions. GITHUB_TOKEN is not exposed by default, and must be passed
+// explicitly by the workflow.
+func LoadActionsContext() (*ActionsContext, error) {
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		return nil, ErrNotInActions
+	}
+
+	ctx := &ActionsContext{
+		APIURL:     os.Getenv("GITHUB_API_URL"),
+		Token:      os.Getenv("GITHUB_TOKEN"),
+		Repository: os.Getenv("GITHUB_REPOSITORY"),
+		SHA:        os.Getenv("GITHUB_SHA"),
+	}
+	if ctx.APIURL == "" {
+		ctx.APIURL = DefaultAPIURL
+	}
+	if ctx.Token == "" {
+		return nil, errors.New("GITHUB_TOKEN is not set")
+	}
+	if ctx.Repository == "" || ctx.SHA == "" {
+		return nil, errors.New("GITHUB_REPOSITORY or GITHUB_SHA is not set")
+	}
+	if server, runID := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_RUN_ID"); server != "" && runID != "" {
+		ctx.RunURL = fmt.Sprintf("%s/%s/actions/runs/%s", strings.TrimSuffix(server, "/"), ctx.Repository, runID)
+	}
+
+	if eventPath := os.Getenv("GITHUB_EVENT_PATH"); eventPath != "" {
+		if err := ctx.loadEvent(eventPath); err != nil {
+			return nil, fmt.Errorf("failed to load event payload: %w", err)
+		}
+	}
+
+	return ctx, nil
+}
+
+func (c *ActionsContext) loadEvent(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var event struct {
+		PullRequest *struct {
+			Number int `json:"number"`
+			Head   struct {
+				SHA string `json:"sha"`
+			} `json:"head"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+
+	if event.PullRequest != nil {
+		c.PullRequest = event.PullRequest.Number
+		// GITHUB_SHA is the merge commit for pull request events.
+		if event.PullRequest.Head.SHA != "" {
+			c.SHA = event.PullRequest.Head.SHA
+		}
+	}
+	return nil
+}
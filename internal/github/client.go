@@ -0,0 +1,229 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const DefaultAPIURL = "https://api.github.com"
+
+const commentsPerPage = 100
+
+// ActionsBotLogin is the login of account authenticated by GITHUB_TOKEN of
+// GitHub Actions.
+const ActionsBotLogin = "github-actions[bot]"
+
+// integrationForbiddenMessage is the error message of APIs not accessible by
+// installation tokens.
+const integrationForbiddenMessage = "Resource not accessible by integration"
+
+type CommitState string
+
+const (
+	CommitStatePending CommitState = "pending"
+	CommitStateSuccess CommitState = "success"
+	CommitStateFailure CommitState = "failure"
+	CommitStateError   CommitState = "error"
+)
+
+type CommitStatus struct {
+	State       CommitState `json:"state"`
+	TargetURL   string      `json:"target_url,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Context     string      `json:"context"`
+}
+
+type User struct {
+	Login string `json:"login"`
+}
+
+type IssueComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+	User User   `json:"user"`
+}
+
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("GitHub API error %d: %s", e.StatusCode, e.Message)
+}
+
+// Client is a minimal GitHub REST API client.
+type Client struct {
+	APIURL     string
+	Token      string
+	HTTPClient *http.Client
+
+	loginMutex sync.Mutex
+	login      string
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body any, result any) error {
+	apiURL := c.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	endpoint := strings.TrimSuffix(apiURL, "/") + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var respBody struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil || respBody.Message == "" {
+			respBody.Message = http.StatusText(resp.StatusCode)
+		}
+		return &APIError{StatusCode: resp.StatusCode, Message: respBody.Message}
+	}
+
+	if result != nil {
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+	return nil
+}
+
+// Login returns the login of the authenticated account. Installation tokens
+// (e.g. GITHUB_TOKEN of GitHub Actions) cannot query the account; GitHub
+// Actions bot is assumed for them.
+func (c *Client) Login(ctx context.Context) (string, error) {
+	c.loginMutex.Lock()
+	defer c.loginMutex.Unlock()
+
+	if c.login != "" {
+		return c.login, nil
+	}
+
+	var user User
+	err := c.do(ctx, "GET", "/user", nil, nil, &user)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden &&
+		apiErr.Message == integrationForbiddenMessage {
+		user.Login = ActionsBotLogin
+	} else if err != nil {
+		return "", err
+	}
+
+	c.login = user.Login
+	return c.login, nil
+}
+
+// CreateCommitStatus sets the status of commit; statuses of same context
+// replace previous ones.
+func (c *Client) CreateCommitStatus(ctx context.Context, repo string, sha string, status *CommitStatus) error {
+	return c.do(ctx, "POST", "/repos/"+repo+"/statuses/"+sha, nil, status, nil)
+}
+
+func (c *Client) ListIssueComments(ctx context.Context, repo string, number int) ([]IssueComment, error) {
+	var comments []IssueComment
+	for page := 1; ; page++ {
+		var result []IssueComment
+		query := url.Values{
+			"per_page": []string{strconv.Itoa(commentsPerPage)},
+			"page":     []string{strconv.Itoa(page)},
+		}
+		err := c.do(ctx, "GET", fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number), query, nil, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, result...)
+		if len(result) < commentsPerPage {
+			return comments, nil
+		}
+	}
+}
+
+func (c *Client) CreateIssueComment(ctx context.Context, repo string, number int, body string) (*IssueComment, error) {
+	var result IssueComment
+	err := c.do(ctx, "POST", fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number), nil, map[string]string{"body": body}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) UpdateIssueComment(ctx context.Context, repo string, id int64, body string) (*IssueComment, error) {
+	var result IssueComment
+	err := c.do(ctx, "PATCH", fmt.Sprintf("/repos/%s/issues/comments/%d", repo, id), nil, map[string]string{"body": body}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UpsertStickyComment updates the comment of issue/PR identified by the
+// marker, or creates one if not found. The marker is embedded in the comment
+// as a hidden HTML comment; only comments of the authenticated account are
+// updated.
+func (c *Client) UpsertStickyComment(ctx context.Context, repo string, number int, marker string, body string) (*IssueComment, error) {
+	tag := fmt.Sprintf("<!-- %s -->", marker)
+	body = tag + "\n" + body
+
+	login, err := c.Login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := c.ListIssueComments(ctx, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, comment := range comments {
+		if !strings.EqualFold(comment.User.Login, login) {
+			continue
+		}
+		if strings.HasPrefix(comment.Body, tag+"\n") {
+			if comment.Body == body {
+				return &comment, nil
+			}
+			return c.UpdateIssueComment(ctx, repo, comment.ID, body)
+		}
+	}
+	return c.CreateIssueComment(ctx, repo, number, body)
+}
@@ -0,0 +1,179 @@
+package github_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oursky/pageship/internal/github"
+	"github.com/oursky/pageship/internal/github/githubtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitStatus(t *testing.T) {
+	ctx := context.Background()
+	server := githubtest.NewServer("token")
+	defer server.Close()
+
+	client := &github.Client{APIURL: server.URL, Token: "token"}
+	err := client.CreateCommitStatus(ctx, "oursky/pageship", "abc123", &github.CommitStatus{
+		State:     github.CommitStateSuccess,
+		TargetURL: "https://main.example.com",
+		Context:   "pageship/main",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []github.CommitStatus{{
+		State:     github.CommitStateSuccess,
+		TargetURL: "https://main.example.com",
+		Context:   "pageship/main",
+	}}, server.Statuses("oursky/pageship", "abc123"))
+
+	client.Token = "invalid"
+	err = client.CreateCommitStatus(ctx, "oursky/pageship", "abc123", &github.CommitStatus{
+		State: github.CommitStateSuccess,
+	})
+	var apiErr *github.APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, 401, apiErr.StatusCode)
+		assert.Equal(t, "Bad credentials", apiErr.Message)
+	}
+}
+
+func TestStickyComment(t *testing.T) {
+	ctx := context.Background()
+	server := githubtest.NewServer("token")
+	defer server.Close()
+
+	client := &github.Client{APIURL: server.URL, Token: "token"}
+
+	// Comments span multiple pages
+	for i := 0; i < 150; i++ {
+		_, err := client.CreateIssueComment(ctx, "oursky/pageship", 1, fmt.Sprintf("comment %d", i))
+		assert.NoError(t, err)
+	}
+
+	comment, err := client.UpsertStickyComment(ctx, "oursky/pageship", 1, "pageship:app", "first")
+	assert.NoError(t, err)
+	assert.Equal(t, "<!-- pageship:app -->\nfirst", comment.Body)
+
+	other, err := client.UpsertStickyComment(ctx, "oursky/pageship", 1, "pageship:app/main", "other")
+	assert.NoError(t, err)
+	assert.NotEqual(t, comment.ID, other.ID)
+
+	updated, err := client.UpsertStickyComment(ctx, "oursky/pageship", 1, "pageship:app", "second")
+	assert.NoError(t, err)
+	assert.Equal(t, comment.ID, updated.ID)
+	assert.Equal(t, "<!-- pageship:app -->\nsecond", updated.Body)
+
+	comments := server.Comments("oursky/pageship", 1)
+	assert.Len(t, comments, 152)
+	assert.Equal(t, updated.Body, comments[150].Body)
+	assert.Equal(t, other.Body, comments[151].Body)
+
+	// Other pull requests are not affected
+	assert.Empty(t, server.Comments("oursky/pageship", 2))
+}
+
+func TestStickyCommentAuthor(t *testing.T) {
+	ctx := context.Background()
+	server := githubtest.NewServer("token")
+	defer server.Close()
+
+	client := &github.Client{APIURL: server.URL, Token: "token"}
+
+	// Installation tokens are assumed to be GitHub Actions bot
+	login, err := client.Login(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, github.ActionsBotLogin, login)
+
+	// Comments of other accounts with same marker are not updated
+	foreign := server.AddComment("oursky/pageship", 1, "octocat", "<!-- pageship:app -->\nforeign")
+
+	comment, err := client.UpsertStickyComment(ctx, "oursky/pageship", 1, "pageship:app", "first")
+	assert.NoError(t, err)
+	assert.NotEqual(t, foreign.ID, comment.ID)
+	assert.Equal(t, github.ActionsBotLogin, comment.User.Login)
+
+	updated, err := client.UpsertStickyComment(ctx, "oursky/pageship", 1, "pageship:app", "second")
+	assert.NoError(t, err)
+	assert.Equal(t, comment.ID, updated.ID)
+
+	comments := server.Comments("oursky/pageship", 1)
+	assert.Len(t, comments, 2)
+	assert.Equal(t, foreign, comments[0])
+	assert.Equal(t, updated.Body, comments[1].Body)
+
+	// User tokens query the authenticated account
+	userServer := githubtest.NewServer("token")
+	defer userServer.Close()
+	userServer.Login = "octocat"
+	userServer.AddComment("oursky/pageship", 1, "octocat", "<!-- pageship:app -->\nmine")
+
+	userClient := &github.Client{APIURL: userServer.URL, Token: "token"}
+	login, err = userClient.Login(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "octocat", login)
+
+	_, err = userClient.UpsertStickyComment(ctx, "oursky/pageship", 1, "pageship:app", "updated")
+	assert.NoError(t, err)
+	comments = userServer.Comments("oursky/pageship", 1)
+	assert.Len(t, comments, 1)
+	assert.Equal(t, "<!-- pageship:app -->\nupdated", comments[0].Body)
+	// Other forbidden errors are not assumed to be installation tokens
+	forbidden := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"API rate limit exceeded"}`))
+	}))
+	defer forbidden.Close()
+
+	_, err = (&github.Client{APIURL: forbidden.URL, Token: "token"}).Login(ctx)
+	var apiErr *github.APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	}
+}
+
+func TestLoadActionsContext(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	_, err := github.LoadActionsContext()
+	assert.ErrorIs(t, err, github.ErrNotInActions)
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_API_URL", "")
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_REPOSITORY", "oursky/pageship")
+	t.Setenv("GITHUB_SHA", "merge123")
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("GITHUB_EVENT_PATH", "")
+	_, err = github.LoadActionsContext()
+	assert.ErrorContains(t, err, "GITHUB_TOKEN")
+
+	t.Setenv("GITHUB_TOKEN", "token")
+	ctx, err := github.LoadActionsContext()
+	assert.NoError(t, err)
+	assert.Equal(t, &github.ActionsContext{
+		APIURL:      github.DefaultAPIURL,
+		Token:       "token",
+		Repository:  "oursky/pageship",
+		SHA:         "merge123",
+		PullRequest: 0,
+		RunURL:      "https://github.com/oursky/pageship/actions/runs/42",
+	}, ctx)
+
+	eventPath := filepath.Join(t.TempDir(), "event.json")
+	event := `{"pull_request": {"number": 7, "head": {"sha": "head123"}}}`
+	if err := os.WriteFile(eventPath, []byte(event), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_EVENT_PATH", eventPath)
+	ctx, err = github.LoadActionsContext()
+	assert.NoError(t, err)
+	assert.Equal(t, 7, ctx.PullRequest)
+	assert.Equal(t, "head123", ctx.SHA)
+}
@@ -0,0 +1,222 @@
+// Package githubtest provides a fake GitHub API server for testing.
+package githubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oursky/pageship/internal/github"
+)
+
+// Server implements the subset of GitHub REST API used by pageship, keeping
+// states in memory. Requests must be authenticated with Token, as the account
+// Login; like installation tokens, bot accounts cannot query the
+// authenticated user.
+type Server struct {
+	*httptest.Server
+	Token string
+	Login string
+
+	mutex    sync.Mutex
+	statuses map[string][]github.CommitStatus
+	comments map[string][]github.IssueComment
+	nextID   int64
+}
+
+func NewServer(token string) *Server {
+	s := &Server{
+		Token:    token,
+		Login:    github.ActionsBotLogin,
+		statuses: make(map[string][]github.CommitStatus),
+		comments: make(map[string][]github.IssueComment),
+		nextID:   1,
+	}
+	s.Server = httptest.NewServer(s.Handler())
+	return s
+}
+
+func (s *Server) Handler() http.Handler {
+	r := chi.NewRouter()
+	r.Use(s.requireToken)
+	r.Get("/user", s.handleGetUser)
+	r.Route("/repos/{owner}/{repo}", func(r chi.Router) {
+		r.Post("/statuses/{sha}", s.handleCreateStatus)
+		r.Get("/issues/{number}/comments", s.handleListComments)
+		r.Post("/issues/{number}/comments", s.handleCreateComment)
+		r.Patch("/issues/comments/{id}", s.handleUpdateComment)
+	})
+	return r
+}
+
+// Statuses returns commit statuses of the commit, in order of creation.
+func (s *Server) Statuses(repo string, sha string) []github.CommitStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]github.CommitStatus(nil), s.statuses[repo+"@"+sha]...)
+}
+
+// Comments returns comments of the issue/PR, in order of creation.
+func (s *Server) Comments(repo string, number int) []github.IssueComment {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]github.IssueComment(nil), s.comments[fmt.Sprintf("%s#%d", repo, number)]...)
+}
+
+// AddComment adds a comment of the account to the issue/PR.
+func (s *Server) AddComment(repo string, number int, login string, body string) github.IssueComment {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addComment(fmt.Sprintf("%s#%d", repo, number), login, body)
+}
+
+func (s *Server) addComment(key string, login string, body string) github.IssueComment {
+	comment := github.IssueComment{ID: s.nextID, Body: body, User: github.User{Login: login}}
+	s.nextID++
+	s.comments[key] = append(s.comments[key], comment)
+	return comment
+}
+
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+s.Token {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func repoName(r *http.Request) string {
+	return chi.URLParam(r, "owner") + "/" + chi.URLParam(r, "repo")
+}
+
+func (s *Server) issueKey(r *http.Request) (string, bool) {
+	number, err := strconv.Atoi(chi.URLParam(r, "number"))
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%s#%d", repoName(r), number), true
+}
+
+func (s *Server) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	login := s.Login
+	s.mutex.Unlock()
+
+	if strings.HasSuffix(login, "[bot]") {
+		writeJSON(w, http.StatusForbidden, map[string]string{"message": "Resource not accessible by integration"})
+		return
+	}
+	writeJSON(w, http.StatusOK, github.User{Login: login})
+}
+
+func (s *Server) handleCreateStatus(w http.ResponseWriter, r *http.Request) {
+	var status github.CommitStatus
+	if err := json.NewDecoder(r.Body).Decode(&status); err != nil || status.State == "" {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Validation Failed"})
+		return
+	}
+	if status.Context == "" {
+		status.Context = "default"
+	}
+
+	s.mutex.Lock()
+	key := repoName(r) + "@" + chi.URLParam(r, "sha")
+	s.statuses[key] = append(s.statuses[key], status)
+	s.mutex.Unlock()
+
+	writeJSON(w, http.StatusCreated, status)
+}
+
+func (s *Server) handleListComments(w http.ResponseWriter, r *http.Request) {
+	key, ok := s.issueKey(r)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage <= 0 {
+		perPage = 30
+	}
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	s.mutex.Lock()
+	comments := s.comments[key]
+	start := min((page-1)*perPage, len(comments))
+	end := min(start+perPage, len(comments))
+	result := append([]github.IssueComment{}, comments[start:end]...)
+	s.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleCreateComment(w http.ResponseWriter, r *http.Request) {
+	key, ok := s.issueKey(r)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	var request struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Body == "" {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Validation Failed"})
+		return
+	}
+
+	s.mutex.Lock()
+	comment := s.addComment(key, s.Login, request.Body)
+	s.mutex.Unlock()
+
+	writeJSON(w, http.StatusCreated, comment)
+}
+
+func (s *Server) handleUpdateComment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+
+	var request struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Body == "" {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Validation Failed"})
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	prefix := repoName(r) + "#"
+	for key, comments := range s.comments {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		for i := range comments {
+			if comments[i].ID == id {
+				comments[i].Body = request.Body
+				writeJSON(w, http.StatusOK, comments[i])
+				return
+			}
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
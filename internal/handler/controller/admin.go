@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/models"
+)
+
+func (c *Controller) handleAdminCertificates(w http.ResponseWriter, r *http.Request) {
+	respond(w, func() (any, error) {
+		if c.CertStorage == nil {
+			return nil, models.ErrTLSNotEnabled
+		}
+
+		report, err := httputil.LoadCertReport(r.Context(), c.CertStorage)
+		if err != nil {
+			return nil, err
+		}
+		if report == nil {
+			report = &httputil.CertReport{Certificates: []httputil.CertStatus{}}
+		}
+		return report, nil
+	})
+}
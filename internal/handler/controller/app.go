@@ -4,8 +4,10 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/httputil"
 	"github.com/oursky/pageship/internal/models"
 	"go.uber.org/zap"
 )
@@ -33,6 +35,10 @@ func (c *Controller) middlewareLoadApp() func(http.Handler) http.Handler {
 			return nil, err
 		}
 
+		if entry, ok := middleware.GetLogEntry(r).(*httputil.LogEntry); ok {
+			entry.App = app.ID
+		}
+
 		return app, nil
 	})
 }
@@ -61,6 +67,15 @@ func (c *Controller) handleAppCreate(w http.ResponseWriter, r *http.Request) {
 
 		log(r).Info("creating app", zap.String("app", app.ID))
 
+		authz, err := app.CheckAuthz(config.AccessLevelAdmin, userID, nil)
+		if err != nil {
+			return nil, err
+		}
+		err = c.audit(set(r, authz), tx, app.ID, models.AuditAppCreate, app.ID, nil, app.Config)
+		if err != nil {
+			return nil, err
+		}
+
 		return c.makeAPIApp(app), nil
 	}))
 }
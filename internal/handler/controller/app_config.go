@@ -29,7 +29,9 @@ func (c *Controller) handleAppConfigSet(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+	var deleted []string
+	result, err := withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+		oldConfig := app.Config
 		app.Config = request.Config
 		now := c.Clock.Now().UTC()
 		app.UpdatedAt = now
@@ -41,14 +43,26 @@ func (c *Controller) handleAppConfigSet(w http.ResponseWriter, r *http.Request)
 
 		log(r).Info("updating config")
 
-		// Deactivated removed domains; added domains need manual activation.
+		err = c.audit(r, tx, app.ID, models.AuditAppConfigUpdate, app.ID, oldConfig, app.Config)
+		if err != nil {
+			return nil, err
+		}
+
+		// Deactivated removed domains (or aliases moved to other domain); added
+		// domains need manual activation.
 		domains, err := tx.ListDomains(r.Context(), app.ID)
 		if err != nil {
 			return nil, err
 		}
 		for _, d := range domains {
-			if _, exists := app.Config.ResolveDomain(d.Domain); exists {
-				continue
+			if dconf, exists := app.Config.ResolveDomain(d.Domain); exists {
+				canonical := d.Domain
+				if d.AliasOf != nil {
+					canonical = *d.AliasOf
+				}
+				if dconf.Domain == canonical {
+					continue
+				}
 			}
 
 			err = tx.DeleteDomain(r.Context(), d.ID, now)
@@ -57,8 +71,18 @@ func (c *Controller) handleAppConfigSet(w http.ResponseWriter, r *http.Request)
 			}
 
 			log(r).Info("deleting domain", zap.String("domain", d.Domain))
+			deleted = append(deleted, d.Domain)
+
+			err = c.audit(r, tx, app.ID, models.AuditDomainDelete, d.Domain, d, nil)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		return app.Config, nil
-	}))
+	})()
+	if err == nil {
+		c.deleteDomainCerts(r, deleted)
+	}
+	writeResponse(w, result, err)
 }
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/oursky/pageship/internal/accesslog"
+	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultLogsSince = time.Hour
+	maxLogsRange     = 31 * 24 * time.Hour
+	// logsFlushDelay covers duration of request, since entries are
+	// timestamped when request starts.
+	logsFlushDelay = time.Minute
+)
+
+func parseLogsTime(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func (c *Controller) handleAppLogs(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+	now := c.Clock.Now().UTC()
+
+	since, err := parseLogsTime(r.URL.Query().Get("since"), now.Add(-defaultLogsSince))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Error: err})
+		return
+	}
+	until, err := parseLogsTime(r.URL.Query().Get("until"), now)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Error: err})
+		return
+	}
+	if until.Sub(since) > maxLogsRange {
+		writeJSON(w, http.StatusBadRequest, response{Error: errors.New("time range is too long")})
+		return
+	}
+
+	// Entries are written to objects flushed after them.
+	flushedUntil := until.Add(c.Config.AccessLogFlushInterval + logsFlushDelay)
+	keys, err := accesslog.List(r.Context(), c.Storage, c.Config.StorageKeyPrefix, app.ID, since, flushedUntil)
+	if err != nil {
+		panic(err)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	writer := httputil.NewTimeoutResponseWriter(w, requestIOTimeout)
+	for _, key := range keys {
+		if err := c.copyLogObject(writer, r, key, since, until); err != nil {
+			log(r).Warn("failed to read access logs", zap.String("key", key), zap.Error(err))
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+func (c *Controller) copyLogObject(w http.ResponseWriter, r *http.Request, key string, since time.Time, until time.Time) error {
+	reader, err := c.Storage.OpenRead(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		// Deleted by lifecycle rules since listed.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return accesslog.CopyEntries(w, reader, accesslog.KeyFormat(key), since, until)
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+)
+
+// auditDeployment is the audited state of deployment; file list is omitted.
+type auditDeployment struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Files  int    `json:"files"`
+	Size   int64  `json:"size"`
+	Signed bool   `json:"signed"`
+	Method string `json:"method,omitempty"`
+}
+
+func makeAuditDeployment(d *models.Deployment, method string) *auditDeployment {
+	var size int64
+	for _, entry := range d.Metadata.Files {
+		size += entry.Size
+	}
+	return &auditDeployment{
+		ID:     d.ID,
+		Name:   d.Name,
+		Files:  len(d.Metadata.Files),
+		Size:   size,
+		Signed: d.Metadata.Signature != nil,
+		Method: method,
+	}
+}
+
+// audit records an audit event of the mutation performed by the request
+// actor, usually in the transaction of mutation. before & after are the
+// states of target, and are omitted from event if nil.
+func (c *Controller) audit(
+	r *http.Request,
+	q db.DBQuery,
+	appID string,
+	action models.AuditAction,
+	target string,
+	before any,
+	after any,
+) error {
+	event := models.NewAuditEvent(c.Clock.Now().UTC(), &appID, action, target)
+	if authn, ok := r.Context().Value(valueContextKey[*authnInfo]{}).(*authnInfo); ok && authn != nil {
+		event.ActorID = authn.Subject
+		event.ActorName = authn.Name
+	}
+	if authz, ok := r.Context().Value(valueContextKey[*models.AppAuthzResult]{}).(*models.AppAuthzResult); ok && authz != nil {
+		event.CredentialID = authz.CredentialID
+		event.CredentialRule = authz.MatchedRule()
+	}
+
+	return c.recordAudit(r, q, event, before, after)
+}
+
+func (c *Controller) recordAudit(r *http.Request, q db.DBQuery, event *models.AuditEvent, before any, after any) error {
+	event.RequestID = requestID(r)
+	if before != nil || after != nil {
+		diff, err := models.NewAuditDiff(before, after)
+		if err != nil {
+			return err
+		}
+		event.Diff = diff
+	}
+
+	return q.CreateAuditEvent(r.Context(), event)
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+var errInvalidAuditCursor = errors.New("invalid cursor")
+
+type apiAuditEvents struct {
+	Events []*models.AuditEvent `json:"events"`
+	// Next is the cursor to list following events; empty if no more events.
+	Next string `json:"next,omitempty"`
+}
+
+func encodeAuditCursor(e *models.AuditEvent) string {
+	return fmt.Sprintf("%d.%s", e.CreatedAt.UnixNano(), e.ID)
+}
+
+func decodeAuditCursor(cursor string) (time.Time, string, error) {
+	ts, id, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return time.Time{}, "", errInvalidAuditCursor
+	}
+	nsec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, "", errInvalidAuditCursor
+	}
+	return time.Unix(0, nsec).UTC(), id, nil
+}
+
+func parseAuditQuery(r *http.Request, now time.Time) (*db.AuditEventQuery, error) {
+	query := &db.AuditEventQuery{
+		Until:     now,
+		AfterTime: time.Unix(0, 0).UTC(),
+		Limit:     defaultAuditLimit,
+	}
+
+	values := r.URL.Query()
+	if since := values.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return nil, err
+		}
+		query.AfterTime = t.UTC()
+	}
+	if until := values.Get("until"); until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return nil, err
+		}
+		query.Until = t.UTC()
+	}
+	if after := values.Get("after"); after != "" {
+		t, id, err := decodeAuditCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		query.AfterTime, query.AfterID = t, id
+	}
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxAuditLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxAuditLimit)
+		}
+		query.Limit = n
+	}
+	return query, nil
+}
+
+func (c *Controller) listAuditEvents(w http.ResponseWriter, r *http.Request, appID *string) {
+	query, err := parseAuditQuery(r, c.Clock.Now().UTC())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Error: err})
+		return
+	}
+	query.AppID = appID
+
+	respond(w, func() (any, error) {
+		events, err := c.DB.ListAuditEvents(r.Context(), *query)
+		if err != nil {
+			return nil, err
+		}
+
+		result := &apiAuditEvents{Events: events}
+		if result.Events == nil {
+			result.Events = []*models.AuditEvent{}
+		}
+		if len(events) == query.Limit {
+			result.Next = encodeAuditCursor(events[len(events)-1])
+		}
+		return result, nil
+	})
+}
+
+func (c *Controller) handleAppAudit(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+	c.listAuditEvents(w, r, &app.ID)
+}
+
+func (c *Controller) handleAdminAudit(w http.ResponseWriter, r *http.Request) {
+	var appID *string
+	if id := r.URL.Query().Get("app"); id != "" {
+		appID = &id
+	}
+	c.listAuditEvents(w, r, appID)
+}
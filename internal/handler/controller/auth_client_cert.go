@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"crypto/x509"
+	"net/http"
+
+	"github.com/oursky/pageship/internal/models"
+	"go.uber.org/zap"
+)
+
+// verifyClientCert authenticates the request using TLS client certificate;
+// nil is returned if no client certificate is presented.
+func (c *Controller) verifyClientCert(r *http.Request) (*authnInfo, error) {
+	if c.Config.ClientCAs == nil || r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil, nil
+	}
+
+	cert := r.TLS.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, ic := range r.TLS.PeerCertificates[1:] {
+		intermediates.AddCert(ic)
+	}
+
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         c.Config.ClientCAs,
+		Intermediates: intermediates,
+		CurrentTime:   c.Clock.Now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		log(r).Debug("invalid client certificate",
+			zap.String("subject", cert.Subject.String()),
+			zap.Error(err))
+		return nil, models.ErrInvalidCredentials
+	}
+
+	credentials := models.ClientCertCredentials(cert)
+	if err := c.checkACL(r, credentials); err != nil {
+		return nil, models.ErrInvalidCredentials
+	}
+
+	return &authnInfo{
+		Subject:       string(models.CredentialIDClientCert) + ":" + cert.Subject.String(),
+		Name:          cert.Subject.CommonName,
+		IsBot:         true,
+		CredentialIDs: appendRequestCredentials(r, credentials),
+	}, nil
+}
@@ -71,6 +71,17 @@ func (c *Controller) handleAuthGithubOIDC(w http.ResponseWriter, r *http.Request
 	claims := models.NewTokenClaims(models.TokenSubjectGitHubActions(oidcClaims.ID), oidcClaims.Subject)
 	claims.Credentials = credentials
 
+	event := models.NewAuditEvent(c.Clock.Now().UTC(), nil, models.AuditUserLogin, oidcClaims.Subject)
+	event.ActorID = string(models.TokenSubjectGitHubActions(oidcClaims.ID))
+	event.ActorName = oidcClaims.Subject
+	if len(credentials) > 0 {
+		event.CredentialID = credentials[0]
+	}
+	if err := c.recordAudit(r, c.DB, event, nil, nil); err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
 	token, err := c.issueToken(claims)
 	writeResponse(w, token, err)
 }
@@ -89,7 +89,7 @@ func (c *Controller) handleAuthGithubSSHConn(conn *websocket.Conn) {
 
 		username := sshConn.User()
 		token, err := c.generateUserToken(
-			conn.Request().Context(),
+			conn.Request(),
 			username,
 			models.CredentialGitHubUser(username),
 			&models.UserCredentialData{
@@ -83,11 +83,12 @@ func ensureUser(
 }
 
 func (c *Controller) generateUserToken(
-	ctx context.Context,
+	r *http.Request,
 	name string,
 	credentialID models.CredentialID,
 	data *models.UserCredentialData,
 ) (string, error) {
+	ctx := r.Context()
 	now := c.Clock.Now().UTC()
 
 	user, err := withTx(ctx, c.DB, func(tx db.Tx) (*models.User, error) {
@@ -104,6 +105,15 @@ func (c *Controller) generateUserToken(
 			return nil, err
 		}
 
+		event := models.NewAuditEvent(now, nil, models.AuditUserLogin, user.ID)
+		event.ActorID = user.ID
+		event.ActorName = user.Name
+		event.CredentialID = credentialID
+		err = c.recordAudit(r, tx, event, nil, data)
+		if err != nil {
+			return nil, err
+		}
+
 		return user, nil
 	})()
 	if err != nil {
@@ -130,6 +140,13 @@ func (c *Controller) middlewareAuthn(next http.Handler) http.Handler {
 				return
 			}
 			authn = info
+		} else {
+			info, err := c.verifyClientCert(r)
+			if err != nil {
+				writeResponse(w, nil, err)
+				return
+			}
+			authn = info
 		}
 
 		r = set(r, authn)
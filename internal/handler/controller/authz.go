@@ -33,7 +33,7 @@ func (c *Controller) requireAccess(level config.AccessLevel) func(next http.Hand
 			loggers := get[*loggers](r)
 			loggers.Logger = loggers.authn.With(fields...) // Replace authz logger fields
 
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, set(r, authz))
 		})
 	}
 }
@@ -50,6 +50,42 @@ func (c *Controller) requireAccessReader() func(next http.Handler) http.Handler
 	return c.requireAccess(config.AccessLevelReader)
 }
 
+// requireServerAdmin allows only server administrators matching the admin
+// ACL to access server-wide administrative endpoints.
+func (c *Controller) requireServerAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := get[*authnInfo](r)
+		if info == nil {
+			writeResponse(w, nil, models.ErrInvalidCredentials)
+			return
+		}
+		if c.Config.AdminACL == nil {
+			writeResponse(w, nil, models.ErrAccessDenied)
+			return
+		}
+
+		acl, err := c.Config.AdminACL.Get(r.Context())
+		if err != nil {
+			writeResponse(w, nil, err)
+			return
+		}
+		authz, err := models.CheckACLAuthz(acl, info.CredentialIDs)
+		if err != nil {
+			log(r).Info("admin rejected", zap.Any("credentials", info.CredentialIDs))
+			writeResponse(w, nil, err)
+			return
+		}
+
+		loggers := get[*loggers](r)
+		loggers.Logger = loggers.authn.With(
+			zap.String("credential", string(authz.CredentialID)),
+			zap.String("credential_rule", authz.MatchedRule()),
+		)
+
+		next.ServeHTTP(w, set(r, authz))
+	})
+}
+
 func denyBot(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		info := get[*authnInfo](r)
@@ -1,6 +1,9 @@
 package controller
 
 import (
+	"crypto/x509"
+	"time"
+
 	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/watch"
 )
@@ -14,6 +17,14 @@ type Config struct {
 	TokenAuthority    string
 	TokenSigningKey   []byte
 	ACL               *watch.File[config.ACL]
+	AdminACL          *watch.File[config.ACL]
+	// ClientCAs enables authentication using client certificates issued by
+	// the CAs.
+	ClientCAs *x509.CertPool
+	// ControllerURL is the public base URL of the controller API.
+	ControllerURL string
+	// AccessLogFlushInterval is the flush interval of exported access logs.
+	AccessLogFlushInterval time.Duration
 
 	ServerVersion       string
 	CustomDomainMessage string
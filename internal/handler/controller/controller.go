@@ -4,9 +4,11 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/caddyserver/certmagic"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/domain"
 	"github.com/oursky/pageship/internal/oidc"
 	"github.com/oursky/pageship/internal/sshkey"
 	"github.com/oursky/pageship/internal/storage"
@@ -22,6 +24,12 @@ type Controller struct {
 	Storage *storage.Storage
 	DB      db.DB
 
+	DNSResolver domain.DNSResolver
+	CertStorage certmagic.Storage
+	// CertIssuerKey is the key of ACME issuer of managed certificates in
+	// CertStorage.
+	CertIssuerKey string
+
 	githubKeys *sshkey.GitHubKeys
 	oidcKeys   *oidc.Keys
 }
@@ -30,6 +38,9 @@ func (c *Controller) Handler() http.Handler {
 	if c.Clock == nil {
 		c.Clock = apptime.SystemClock
 	}
+	if c.DNSResolver == nil {
+		c.DNSResolver = domain.DefaultDNSResolver
+	}
 	if c.githubKeys == nil {
 		keys, err := sshkey.NewGitHubKeys(c.Context)
 		if err != nil {
@@ -69,6 +80,14 @@ func (c *Controller) Handler() http.Handler {
 				r.Get("/", c.handleAppGet)
 				r.Get("/config", c.handleAppConfigGet)
 				r.With(c.requireAccessAdmin()).Put("/config", c.handleAppConfigSet)
+				r.With(c.requireAccessAdmin()).Get("/logs", c.handleAppLogs)
+				r.With(c.requireAccessAdmin()).Get("/audit", c.handleAppAudit)
+
+				r.With(c.requireAccessAdmin()).Route("/webhooks", func(r chi.Router) {
+					r.Get("/deliveries", c.handleWebhookDeliveries)
+					r.Get("/secret", c.handleWebhookSecretGet)
+					r.Post("/secret", c.handleWebhookSecretRotate)
+				})
 
 				r.Route("/sites", func(r chi.Router) {
 					r.Get("/", c.handleSiteList)
@@ -76,6 +95,7 @@ func (c *Controller) Handler() http.Handler {
 
 					r.With(c.middlewareLoadSite()).Route("/{site-name}", func(r chi.Router) {
 						r.With(c.requireAccessDeployer()).Patch("/", c.handleSiteUpdate)
+						r.Get("/analytics", c.handleSiteAnalytics)
 					})
 				})
 
@@ -85,7 +105,15 @@ func (c *Controller) Handler() http.Handler {
 
 					r.With(c.middlewareLoadDeployment()).Route("/{deployment-name}", func(r chi.Router) {
 						r.With(c.requireAccessDeployer()).Get("/", c.handleDeploymentGet)
+						r.With(c.requireAccessDeployer()).Patch("/", c.handleDeploymentRename)
+						r.With(c.requireAccessDeployer()).Delete("/", c.handleDeploymentDelete)
+						r.With(c.requireAccessDeployer()).Get("/files", c.handleDeploymentFiles)
 						r.With(c.requireAccessDeployer()).Put("/tarball", c.handleDeploymentUpload)
+						r.With(c.requireAccessDeployer()).Get("/upload", c.handleDeploymentUploadStatus)
+						r.With(c.requireAccessDeployer()).Put("/upload", c.handleDeploymentUploadChunk)
+						r.With(c.requireAccessDeployer()).Post("/upload/complete", c.handleDeploymentUploadComplete)
+						r.With(c.requireAccessDeployer()).Post("/upload-urls", c.handleDeploymentUploadURLs)
+						r.With(c.requireAccessDeployer()).Post("/finalize", c.handleDeploymentFinalize)
 					})
 				})
 
@@ -95,12 +123,22 @@ func (c *Controller) Handler() http.Handler {
 					r.With(c.requireAccessAdmin()).Route("/{domain-name}", func(r chi.Router) {
 						r.With(c.requireAccessDeployer()).Post("/", c.handleDomainCreate)
 						r.With(c.requireAccessDeployer()).Delete("/", c.handleDomainDelete)
+						r.Post("/verify", c.handleDomainVerify)
+						r.Get("/check", c.handleDomainCheck)
+						r.Put("/cert", c.handleDomainCertSet)
+						r.Delete("/cert", c.handleDomainCertDelete)
 					})
 				})
 			})
 		})
 
+		r.With(c.requireServerAdmin).Route("/admin", func(r chi.Router) {
+			r.Get("/certificates", c.handleAdminCertificates)
+			r.Get("/audit", c.handleAdminAudit)
+		})
+
 		r.With(requireAuth).Get("/manifest", c.handleManifest)
+		r.Put("/upload", c.handleUpload)
 
 		r.With(requireAuth).Get("/auth/me", c.handleMe)
 		r.Get("/auth/github-ssh", c.handleAuthGithubSSH)
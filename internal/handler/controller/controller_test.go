@@ -0,0 +1,279 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+	migratefs "github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/db"
+	_ "github.com/oursky/pageship/internal/db/sqlite"
+	"github.com/oursky/pageship/internal/deploy"
+	"github.com/oursky/pageship/internal/handler/controller"
+	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/storage"
+	apptime "github.com/oursky/pageship/internal/time"
+	"github.com/oursky/pageship/migrations"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+)
+
+const testAppID = "test"
+
+// testServer is a controller backed by a migrated SQLite database and
+// in-memory storage, with an app created by the authenticated test user.
+type testServer struct {
+	t          *testing.T
+	Controller *controller.Controller
+	Clock      *apptime.FakeClock
+	DB         db.DB
+	Storage    *storage.Storage
+
+	handler http.Handler
+	token   string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	ctx := context.Background()
+
+	dbURL := "sqlite://" + filepath.Join(t.TempDir(), "pageship.db")
+	source, err := migratefs.New(migrations.FS, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.NewWithSourceInstance("sqlite", source, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	m.Close()
+
+	database, err := db.New(dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := storage.New(ctx, "mem://")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	hostPattern := config.NewHostPattern("http://*.localhost")
+
+	clock := apptime.NewFakeClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	ctrl := &controller.Controller{
+		Context: ctx,
+		Logger:  zap.NewNop(),
+		Clock:   clock,
+		Config: controller.Config{
+			MaxDeploymentSize: 10 * 1024 * 1024,
+			StorageKeyPrefix:  "",
+			HostIDScheme:      config.HostIDSchemeSubdomain,
+			HostPattern:       hostPattern,
+			TokenAuthority:    "pageship",
+			TokenSigningKey:   []byte("test"),
+			ControllerURL:     "http://api.localhost",
+		},
+		Storage: store,
+		DB:      database,
+	}
+
+	s := &testServer{
+		t:          t,
+		Controller: ctrl,
+		Clock:      clock,
+		DB:         database,
+		Storage:    store,
+		handler: chi.Chain(
+			httputil.RequestId,
+			middleware.RequestLogger(httputil.LogFormatter{Logger: ctrl.Logger}),
+		).Handler(ctrl.Handler()),
+	}
+
+	user := models.NewUser(clock.Now(), "tester")
+	if err := database.CreateUser(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	cred := models.NewUserCredential(clock.Now(), user.ID, models.CredentialUserID(user.ID), &models.UserCredentialData{})
+	if err := database.AddCredential(ctx, cred); err != nil {
+		t.Fatal(err)
+	}
+	claims := models.NewTokenClaims(models.TokenSubjectUser(user.ID), user.Name)
+	claims.Audience = jwt.ClaimStrings{"pageship"}
+	claims.ExpiresAt = jwt.NewNumericDate(clock.Now().Add(24 * time.Hour))
+	s.token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ctrl.Config.TokenSigningKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.mustJSON("POST", "/api/v1/apps", map[string]any{"id": testAppID}, nil)
+	return s
+}
+
+// Request performs an authenticated request to the controller; the request is
+// anonymous if Authorization header is set to nil.
+func (s *testServer) Request(method string, path string, body io.Reader, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, body)
+	for key, values := range header {
+		r.Header[key] = values
+	}
+	if _, ok := r.Header["Authorization"]; !ok {
+		r.Header.Set("Authorization", "Bearer "+s.token)
+	} else if len(r.Header["Authorization"]) == 0 {
+		r.Header.Del("Authorization")
+	}
+
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, r)
+	return w
+}
+
+// JSON performs a request with JSON body, and decodes result into result if
+// not nil.
+func (s *testServer) JSON(method string, path string, body any, result any) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	w := s.Request(method, path, reader, http.Header{"Content-Type": []string{"application/json"}})
+	if result != nil && w.Code == http.StatusOK {
+		var resp struct {
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			s.t.Fatal(err)
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	return w
+}
+
+// mustJSON is JSON, failing the test if the request is not successful.
+func (s *testServer) mustJSON(method string, path string, body any, result any) {
+	s.t.Helper()
+	if w := s.JSON(method, path, body, result); w.Code != http.StatusOK {
+		s.t.Fatalf("%s %s: %d %s", method, path, w.Code, w.Body.String())
+	}
+}
+
+// SetAppConfig updates config of the app to default config modified by
+// modify.
+func (s *testServer) SetAppConfig(appID string, modify func(conf *config.AppConfig)) {
+	s.t.Helper()
+
+	conf := config.DefaultAppConfig()
+	conf.ID = appID
+	modify(&conf)
+	conf.SetDefaults()
+	s.mustJSON("PUT", "/api/v1/apps/"+appID+"/config", map[string]any{"config": conf}, nil)
+}
+
+// ErrorOf returns the error message of a failed response.
+func ErrorOf(w *httptest.ResponseRecorder) string {
+	var resp struct {
+		Error string `json:"error"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.Error
+}
+
+// testDeployment is a deployment set up with its tarball, but not uploaded.
+type testDeployment struct {
+	Name    string
+	Files   []models.FileEntry
+	Tarball []byte
+}
+
+// CreateDeployment sets up a deployment of the files, keyed by path relative
+// to public directory; the deployment is signed if signer is not nil.
+func (s *testServer) CreateDeployment(name string, files map[string]string, signer ssh.Signer) *testDeployment {
+	s.t.Helper()
+
+	dir := s.t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			s.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+
+	tarfile, err := os.CreateTemp(s.t.TempDir(), "*.tar.zst")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	defer tarfile.Close()
+
+	coll, err := deploy.NewCollector(s.Clock.Now(), 0, tarfile)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	coll.AddDir("/")
+	if err := coll.Collect(os.DirFS(dir), "/public"); err != nil {
+		s.t.Fatal(err)
+	}
+	coll.Close()
+
+	tarball, err := os.ReadFile(tarfile.Name())
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	siteConfig := config.DefaultSiteConfig()
+	var signature *models.DeploymentSignature
+	if signer != nil {
+		digest, err := deploy.ManifestDigest(coll.Files(), "", &siteConfig)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		signature, err = deploy.SignManifest(signer, digest)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+	}
+
+	s.mustJSON("POST", "/api/v1/apps/test/deployments", map[string]any{
+		"name":        name,
+		"files":       coll.Files(),
+		"site_config": siteConfig,
+		"signature":   signature,
+	}, nil)
+
+	return &testDeployment{Name: name, Files: coll.Files(), Tarball: tarball}
+}
+
+// UploadDeployment uploads the tarball of deployment in single request.
+func (s *testServer) UploadDeployment(d *testDeployment) {
+	s.t.Helper()
+
+	w := s.Request("PUT", "/api/v1/apps/test/deployments/"+d.Name+"/tarball", bytes.NewReader(d.Tarball), nil)
+	if w.Code != http.StatusOK {
+		s.t.Fatalf("upload %s: %d %s", d.Name, w.Code, w.Body.String())
+	}
+}
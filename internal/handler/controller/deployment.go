@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -13,19 +14,32 @@ import (
 	"github.com/oursky/pageship/internal/db"
 	"github.com/oursky/pageship/internal/deploy"
 	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/metrics"
 	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/storage"
 	"go.uber.org/zap"
 )
 
+const rollbackTimeout = 30 * time.Second
+
 type apiDeployment struct {
 	*models.Deployment
 	FirstSiteName *string `json:"siteName"`
 	URL           string  `json:"url,omitempty"`
 }
 
+// apiDeploymentCreated is a created deployment, with upload URLs of its files
+// if direct upload is requested.
+type apiDeploymentCreated struct {
+	*apiDeployment
+	UploadURLs *apiDeploymentUploadURLs `json:"uploadURLs,omitempty"`
+}
+
 func (c *Controller) makeAPIDeployment(app *models.App, d db.DeploymentInfo) *apiDeployment {
 	deployment := *d.Deployment
-	deployment.Metadata.Files = nil // Avoid large file list
+	metadata := *deployment.Metadata
+	metadata.Files = nil // Avoid large file list
+	deployment.Metadata = &metadata
 
 	siteName := ""
 	if d.FirstSiteName != nil {
@@ -82,13 +96,151 @@ func (c *Controller) handleDeploymentGet(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+func (c *Controller) handleDeploymentFiles(w http.ResponseWriter, r *http.Request) {
+	deployment := get[*models.Deployment](r)
+
+	writeResponse(w, deployment.Metadata.Files, nil)
+}
+
+// deleteDeployment deletes the deployment; deployments assigned to sites
+// cannot be deleted.
+func (c *Controller) deleteDeployment(r *http.Request, tx db.Tx, appID string, deployment *models.Deployment, now time.Time) error {
+	sites, err := tx.GetDeploymentSiteNames(r.Context(), deployment)
+	if err != nil {
+		return err
+	}
+	if len(sites) > 0 {
+		return models.ErrDeploymentActive
+	}
+
+	err = tx.DeleteDeployment(r.Context(), deployment.ID, now)
+	if err != nil {
+		return err
+	}
+
+	log(r).Info("deleting deployment", zap.String("deployment", deployment.ID))
+
+	return c.audit(r, tx, appID, models.AuditDeploymentDelete, deployment.Name, makeAuditDeployment(deployment, ""), nil)
+}
+
+// handleDeploymentRename renames an uploaded deployment. With replace, the
+// existing deployment of the name is deleted in same transaction, so that the
+// name points to the new content atomically.
+func (c *Controller) handleDeploymentRename(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+	deployment := get[*models.Deployment](r)
+
+	var request struct {
+		Name    string `json:"name" binding:"required,dnsLabel"`
+		Replace bool   `json:"replace"`
+	}
+	if !bindJSON(w, r, &request) {
+		return
+	}
+
+	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+		app, err := tx.GetApp(r.Context(), app.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		deployment, err := tx.GetDeployment(r.Context(), app.ID, deployment.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Replacing with deployment not yet uploaded would serve empty
+		// content under the name.
+		now := c.Clock.Now().UTC()
+		if err := deployment.CheckAlive(now); err != nil {
+			return nil, err
+		}
+
+		if deployment.Name != request.Name {
+			// Sites are resolved before deployments; deployment would be
+			// unreachable.
+			if _, ok := app.Config.ResolveSite(request.Name); ok {
+				return nil, models.ErrDeploymentNameIsSite
+			}
+
+			existing, err := tx.GetDeploymentByName(r.Context(), app.ID, request.Name)
+			switch {
+			case errors.Is(err, models.ErrDeploymentNotFound):
+				break
+			case err != nil:
+				return nil, err
+			case !request.Replace:
+				return nil, models.ErrDeploymentUsedName
+			default:
+				if err := c.deleteDeployment(r, tx, app.ID, existing, now); err != nil {
+					return nil, err
+				}
+			}
+
+			before := makeAuditDeployment(deployment, "")
+			deployment.Name = request.Name
+			deployment.UpdatedAt = now
+			err = tx.RenameDeployment(r.Context(), deployment)
+			if err != nil {
+				return nil, err
+			}
+
+			log(r).Info("renaming deployment",
+				zap.String("deployment", deployment.ID),
+				zap.String("old_name", before.Name),
+				zap.String("new_name", deployment.Name))
+
+			err = c.audit(r, tx, app.ID, models.AuditDeploymentRename, deployment.Name, before, makeAuditDeployment(deployment, ""))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		sites, err := tx.GetDeploymentSiteNames(r.Context(), deployment)
+		if err != nil {
+			return nil, err
+		}
+		var siteName *string
+		if len(sites) > 0 {
+			siteName = &sites[0]
+		}
+
+		return c.makeAPIDeployment(app, db.DeploymentInfo{
+			Deployment:    deployment,
+			FirstSiteName: siteName,
+		}), nil
+	}))
+}
+
+func (c *Controller) handleDeploymentDelete(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+	deployment := get[*models.Deployment](r)
+
+	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+		deployment, err := tx.GetDeployment(r.Context(), app.ID, deployment.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.deleteDeployment(r, tx, app.ID, deployment, c.Clock.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+
+		return struct{}{}, nil
+	}))
+}
+
 func (c *Controller) handleDeploymentCreate(w http.ResponseWriter, r *http.Request) {
 	app := get[*models.App](r)
 
 	var request struct {
-		Name       string             `json:"name" binding:"required,dnsLabel"`
-		Files      []models.FileEntry `json:"files" binding:"required"`
-		SiteConfig *config.SiteConfig `json:"site_config" binding:"required"`
+		Name          string                      `json:"name" binding:"required,dnsLabel"`
+		Files         []models.FileEntry          `json:"files" binding:"required"`
+		SiteConfig    *config.SiteConfig          `json:"site_config" binding:"required"`
+		HashAlgorithm string                      `json:"hash_algorithm"`
+		Signature     *models.DeploymentSignature `json:"signature,omitempty"`
+		DirectUpload  bool                        `json:"direct_upload"`
 	}
 	if !bindJSON(w, r, &request) {
 		return
@@ -96,6 +248,15 @@ func (c *Controller) handleDeploymentCreate(w http.ResponseWriter, r *http.Reque
 	name := request.Name
 	files := request.Files
 	siteConfig := request.SiteConfig
+	hashAlgorithm := request.HashAlgorithm
+	if hashAlgorithm == "" {
+		hashAlgorithm = deploy.DefaultHashAlgorithm
+	}
+
+	if _, err := deploy.NewFileHashWithAlgorithm(hashAlgorithm); err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Error: err})
+		return
+	}
 
 	if len(files) > models.MaxFiles {
 		writeJSON(w, http.StatusBadRequest, response{Error: deploy.ErrTooManyFiles})
@@ -107,6 +268,17 @@ func (c *Controller) handleDeploymentCreate(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if request.Signature != nil {
+		digest, err := deploy.ManifestDigest(files, hashAlgorithm, siteConfig)
+		if err != nil {
+			panic(err)
+		}
+		if _, err := deploy.VerifyManifest(request.Signature, digest); err != nil {
+			writeJSON(w, http.StatusBadRequest, response{Error: err})
+			return
+		}
+	}
+
 	var totalSize int64 = 0
 	for _, entry := range files {
 		totalSize += entry.Size
@@ -122,6 +294,7 @@ func (c *Controller) handleDeploymentCreate(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	var created *models.Deployment
 	deployment, err := withTx(r.Context(), c.DB, func(tx db.Tx) (*apiDeployment, error) {
 		app, err := tx.GetApp(r.Context(), app.ID)
 		if err != nil {
@@ -131,8 +304,10 @@ func (c *Controller) handleDeploymentCreate(w http.ResponseWriter, r *http.Reque
 		now := c.Clock.Now().UTC()
 
 		metadata := &models.DeploymentMetadata{
-			Files:  files,
-			Config: *siteConfig,
+			Files:         files,
+			Config:        *siteConfig,
+			HashAlgorithm: hashAlgorithm,
+			Signature:     request.Signature,
 		}
 		deployment := models.NewDeployment(now, name, app.ID, c.Config.StorageKeyPrefix, metadata)
 
@@ -150,70 +325,92 @@ func (c *Controller) handleDeploymentCreate(w http.ResponseWriter, r *http.Reque
 
 		log(r).Info("creating deployment", zap.String("deployment", deployment.ID))
 
+		err = c.audit(r, tx, app.ID, models.AuditDeploymentCreate, deployment.Name, nil, makeAuditDeployment(deployment, ""))
+		if err != nil {
+			return nil, err
+		}
+
+		created = deployment
 		return c.makeAPIDeployment(app, db.DeploymentInfo{
 			Deployment:    deployment,
 			FirstSiteName: nil,
 		}), nil
 	})()
+	if err != nil || !request.DirectUpload {
+		writeResponse(w, deployment, err)
+		return
+	}
 
-	writeResponse(w, deployment, err)
+	uploadURLs, err := c.makeDeploymentUploadURLs(r.Context(), created, true)
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+	writeResponse(w, &apiDeploymentCreated{
+		apiDeployment: deployment,
+		UploadURLs:    uploadURLs,
+	}, nil)
 }
 
-func (c *Controller) handleDeploymentUpload(w http.ResponseWriter, r *http.Request) {
-	app := get[*models.App](r)
-	deployment := get[*models.Deployment](r)
-
+func (c *Controller) checkDeploymentUploadable(deployment *models.Deployment) error {
 	if deployment.IsExpired(c.Clock.Now().UTC()) {
-		writeResponse(w, nil, models.ErrDeploymentExpired)
-		return
+		return models.ErrDeploymentExpired
 	} else if deployment.UploadedAt != nil {
-		writeResponse(w, nil, models.ErrDeploymentAlreadyUploaded)
-		return
-	}
-
-	log(r).Info("uploading deployment", zap.String("deployment", deployment.ID))
-
-	// Extract tarball to object stoarge
-
-	if r.ContentLength == -1 || r.ContentLength > c.Config.MaxDeploymentSize {
-		writeJSON(w, http.StatusBadRequest, response{
-			Error: fmt.Errorf(
-				"deployment too large: %s > %s",
-				humanize.Bytes(uint64(r.ContentLength)),
-				humanize.Bytes(uint64(c.Config.MaxDeploymentSize)),
-			),
-		})
-		return
+		return models.ErrDeploymentAlreadyUploaded
 	}
+	return nil
+}
 
+func (c *Controller) extractDeployment(r *http.Request, deployment *models.Deployment, reader io.Reader) error {
+	var keys []string
 	handleFile := func(e models.FileEntry, reader io.Reader) error {
 		key := deployment.StorageKeyPrefix + e.Path
+		keys = append(keys, key)
 		return c.Storage.Upload(r.Context(), key, reader)
 	}
 
-	reader := io.LimitReader(
-		httputil.NewTimeoutReader(
-			r.Body,
-			http.NewResponseController(w),
-			10*time.Second,
-		),
-		c.Config.MaxDeploymentSize,
-	)
-	err := deploy.ExtractFiles(reader, deployment.Metadata.Files, handleFile)
-	if errors.As(err, new(deploy.Error)) {
-		writeJSON(w, http.StatusBadRequest, response{Error: err})
-		return
-	} else if err != nil {
-		writeResponse(w, nil, err)
-		return
+	reader = io.LimitReader(reader, c.Config.MaxDeploymentSize)
+	err := deploy.ExtractFiles(reader, deployment.Metadata.HashAlgorithm, deployment.Metadata.Files, handleFile)
+	if err != nil {
+		c.rollbackObjects(r, deployment, keys)
+		return err
 	}
+	return nil
+}
 
+// rollbackObjects deletes objects written by a failed upload, so that invalid
+// content is not served. Deletion continues even if the request is cancelled.
+func (c *Controller) rollbackObjects(r *http.Request, deployment *models.Deployment, keys []string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), rollbackTimeout)
+	defer cancel()
+
+	for _, key := range keys {
+		err := c.Storage.Delete(ctx, key)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log(r).Warn("failed to delete uploaded object",
+				zap.String("deployment", deployment.ID),
+				zap.String("key", key),
+				zap.Error(err))
+		}
+	}
+}
+
+// completeDeploymentUpload marks the deployment as uploaded; method is the
+// upload method (tarball/chunked/direct) for metrics.
+func (c *Controller) completeDeploymentUpload(r *http.Request, app *models.App, deployment *models.Deployment, method string) (*apiDeployment, error) {
 	log(r).Info("upload deployment complete", zap.String("deployment", deployment.ID))
 
 	now := c.Clock.Now().UTC()
 
+	var size int64
+	for _, entry := range deployment.Metadata.Files {
+		size += entry.Size
+	}
+	elapsed := now.Sub(deployment.CreatedAt)
+
 	// Mark deployment as completed, but inactive
-	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+	audited := makeAuditDeployment(deployment, method)
+	result, err := withTx(r.Context(), c.DB, func(tx db.Tx) (*apiDeployment, error) {
 		app, err := tx.GetApp(r.Context(), app.ID)
 		if err != nil {
 			return nil, err
@@ -224,10 +421,8 @@ func (c *Controller) handleDeploymentUpload(w http.ResponseWriter, r *http.Reque
 			return nil, err
 		}
 
-		if deployment.IsExpired(c.Clock.Now().UTC()) {
-			return nil, models.ErrDeploymentExpired
-		} else if deployment.UploadedAt != nil {
-			return nil, models.ErrDeploymentAlreadyUploaded
+		if err := c.checkDeploymentUploadable(deployment); err != nil {
+			return nil, err
 		}
 
 		err = tx.MarkDeploymentUploaded(r.Context(), now, deployment)
@@ -235,11 +430,73 @@ func (c *Controller) handleDeploymentUpload(w http.ResponseWriter, r *http.Reque
 			return nil, err
 		}
 
-		return c.makeAPIDeployment(app, db.DeploymentInfo{
+		err = c.audit(r, tx, app.ID, models.AuditDeploymentUpload, deployment.Name, nil, audited)
+		if err != nil {
+			return nil, err
+		}
+
+		result := c.makeAPIDeployment(app, db.DeploymentInfo{
 			Deployment:    deployment,
 			FirstSiteName: nil,
-		}), nil
-	}))
+		})
+
+		err = c.notifyDeploymentUploaded(r, tx, app, result)
+		if err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	})()
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.ObserveUpload(method, size, elapsed)
+
+	return result, nil
+}
+
+func (c *Controller) handleDeploymentUpload(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+	deployment := get[*models.Deployment](r)
+
+	if err := c.checkDeploymentUploadable(deployment); err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	log(r).Info("uploading deployment", zap.String("deployment", deployment.ID))
+
+	// Extract tarball to object stoarge
+
+	if r.ContentLength == -1 || r.ContentLength > c.Config.MaxDeploymentSize {
+		writeJSON(w, http.StatusBadRequest, response{
+			Error: fmt.Errorf(
+				"deployment too large: %s > %s",
+				humanize.Bytes(uint64(r.ContentLength)),
+				humanize.Bytes(uint64(c.Config.MaxDeploymentSize)),
+			),
+		})
+		return
+	}
+
+	reader := httputil.NewTimeoutReader(
+		r.Body,
+		http.NewResponseController(w),
+		10*time.Second,
+	)
+	err := c.extractDeployment(r, deployment, reader)
+	if errors.As(err, new(deploy.Error)) {
+		writeJSON(w, http.StatusBadRequest, response{Error: err})
+		return
+	} else if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	respond(w, func() (any, error) {
+		return c.completeDeploymentUpload(r, app, deployment, "tarball")
+	})
 }
 
 func (c *Controller) handleDeploymentList(w http.ResponseWriter, r *http.Request) {
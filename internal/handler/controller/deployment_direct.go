@@ -0,0 +1,328 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/oursky/pageship/internal/deploy"
+	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/storage"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+const directUploadURLExpiry = 1 * time.Hour
+const directUploadConcurrency = 16
+
+type apiDeploymentUploadURLs struct {
+	URLs     map[string]string `json:"urls"`
+	ExpireAt time.Time         `json:"expireAt"`
+}
+
+// uploadTokenClaims authorizes a single file upload of a deployment to the
+// controller, as a fallback for storage without presigned URL support. The
+// subject is the file path.
+type uploadTokenClaims struct {
+	AppID        string `json:"app"`
+	DeploymentID string `json:"deployment"`
+	Size         int64  `json:"size"`
+	jwt.RegisteredClaims
+}
+
+func (c *Controller) uploadTokenAudience() string {
+	return c.Config.TokenAuthority + "/upload"
+}
+
+func (c *Controller) signUploadURL(ctx context.Context, deployment *models.Deployment, entry models.FileEntry, expireAt time.Time) (string, error) {
+	expiry := expireAt.Sub(c.Clock.Now().UTC())
+	contentMD5, _ := base64.StdEncoding.DecodeString(entry.MD5)
+	signedURL, err := c.Storage.SignedUploadURL(ctx, deployment.StagingKey(entry.Path), expiry, contentMD5)
+	if !errors.Is(err, storage.ErrSignedURLUnsupported) {
+		return signedURL, err
+	}
+
+	claims := &uploadTokenClaims{
+		AppID:        deployment.AppID,
+		DeploymentID: deployment.ID,
+		Size:         entry.Size,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    c.Config.TokenAuthority,
+			Audience:  jwt.ClaimStrings{c.uploadTokenAudience()},
+			Subject:   entry.Path,
+			ExpiresAt: jwt.NewNumericDate(expireAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(c.Config.TokenSigningKey)
+	if err != nil {
+		return "", fmt.Errorf("sign token: %w", err)
+	}
+
+	endpoint, err := url.JoinPath(c.Config.ControllerURL, "api", "v1", "upload")
+	if err != nil {
+		return "", err
+	}
+	return endpoint + "?" + url.Values{"token": {token}}.Encode(), nil
+}
+
+// makeDeploymentUploadURLs issues upload URLs of files in the deployment;
+// files already staged are skipped unless all files are requested.
+func (c *Controller) makeDeploymentUploadURLs(ctx context.Context, deployment *models.Deployment, all bool) (*apiDeploymentUploadURLs, error) {
+	now := c.Clock.Now().UTC()
+	expireAt := now.Add(directUploadURLExpiry)
+
+	// Record the staging before issuing URLs, so that staged objects are
+	// cleaned up if the upload is abandoned.
+	if err := c.DB.AddDeploymentUploadStaging(ctx, deployment.ID, now); err != nil {
+		return nil, err
+	}
+
+	var mutex sync.Mutex
+	urls := make(map[string]string)
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(directUploadConcurrency)
+	for _, entry := range deployment.Metadata.Files {
+		entry := entry
+		if strings.HasSuffix(entry.Path, "/") {
+			continue
+		}
+
+		g.Go(func() error {
+			if !all {
+				exists, err := c.Storage.Exists(ctx, deployment.StagingKey(entry.Path))
+				if err != nil {
+					return err
+				} else if exists {
+					return nil
+				}
+			}
+
+			signedURL, err := c.signUploadURL(ctx, deployment, entry, expireAt)
+			if err != nil {
+				return err
+			}
+
+			mutex.Lock()
+			urls[entry.Path] = signedURL
+			mutex.Unlock()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return &apiDeploymentUploadURLs{URLs: urls, ExpireAt: expireAt}, nil
+}
+
+func (c *Controller) handleDeploymentUploadURLs(w http.ResponseWriter, r *http.Request) {
+	deployment := get[*models.Deployment](r)
+
+	respond(w, func() (any, error) {
+		if err := c.checkDeploymentUploadable(deployment); err != nil {
+			return nil, err
+		}
+		return c.makeDeploymentUploadURLs(r.Context(), deployment, false)
+	})
+}
+
+func (c *Controller) handleUpload(w http.ResponseWriter, r *http.Request) {
+	claims := &uploadTokenClaims{}
+	_, err := jwt.ParseWithClaims(
+		r.URL.Query().Get("token"),
+		claims,
+		func(t *jwt.Token) (any, error) { return c.Config.TokenSigningKey, nil },
+		jwt.WithValidMethods([]string{"HS256"}),
+		jwt.WithAudience(c.uploadTokenAudience()),
+		jwt.WithTimeFunc(c.Clock.Now),
+	)
+	if err != nil {
+		writeResponse(w, nil, models.ErrInvalidCredentials)
+		return
+	}
+
+	deployment, err := c.DB.GetDeployment(r.Context(), claims.AppID, claims.DeploymentID)
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+	if err := c.checkDeploymentUploadable(deployment); err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	if r.ContentLength != claims.Size {
+		writeJSON(w, http.StatusBadRequest, response{Error: deploy.ErrUnexpectedFileSize})
+		return
+	}
+
+	reader := &countReader{
+		r: io.LimitReader(
+			httputil.NewTimeoutReader(
+				r.Body,
+				http.NewResponseController(w),
+				10*time.Second,
+			),
+			claims.Size,
+		),
+	}
+	key := deployment.StagingKey(claims.Subject)
+	if err := c.Storage.Upload(r.Context(), key, reader); err != nil {
+		c.Storage.Delete(r.Context(), key)
+		writeResponse(w, nil, err)
+		return
+	}
+	if reader.n != claims.Size {
+		c.Storage.Delete(r.Context(), key)
+		writeJSON(w, http.StatusBadRequest, response{Error: deploy.ErrUnexpectedFileSize})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// placeDeploymentObject verifies the staged object of the file entry, and
+// then copies it to its final key within the storage. Mismatched objects are
+// deleted so that it would be uploaded again.
+func (c *Controller) placeDeploymentObject(ctx context.Context, deployment *models.Deployment, entry models.FileEntry) error {
+	stagingKey := deployment.StagingKey(entry.Path)
+	err := c.verifyStagedObject(ctx, deployment, entry)
+	if errors.Is(err, deploy.ErrUnexpectedFileSize) || errors.Is(err, deploy.ErrUnexpectedFileHash) {
+		if err := c.Storage.Delete(ctx, stagingKey); err != nil {
+			return err
+		}
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	return c.Storage.Copy(ctx, deployment.StorageKeyPrefix+entry.Path, stagingKey)
+}
+
+// verifyStagedObject checks the size and checksum of the staged object. The
+// checksum is verified using the attributes recorded by the storage if
+// possible; otherwise the object is read to compute its hash.
+func (c *Controller) verifyStagedObject(ctx context.Context, deployment *models.Deployment, entry models.FileEntry) error {
+	attrs, err := c.Storage.Attributes(ctx, deployment.StagingKey(entry.Path))
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("%w: %s", deploy.ErrMissingFile, entry.Path)
+	} else if err != nil {
+		return err
+	}
+
+	if attrs.Size != entry.Size {
+		return fmt.Errorf("%w: %s", deploy.ErrUnexpectedFileSize, entry.Path)
+	}
+
+	// Recorded MD5 may not be the content checksum (e.g. S3 ETag of
+	// encrypted objects), so fallback to reading the object on mismatch.
+	contentMD5, _ := base64.StdEncoding.DecodeString(entry.MD5)
+	if len(contentMD5) > 0 && bytes.Equal(attrs.MD5, contentMD5) {
+		return nil
+	}
+
+	reader, err := c.Storage.OpenRead(ctx, deployment.StagingKey(entry.Path))
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("%w: %s", deploy.ErrMissingFile, entry.Path)
+	} else if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	hash, err := deploy.NewFileHashWithAlgorithm(deployment.Metadata.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(hash, io.LimitReader(reader, entry.Size+1))
+	if err != nil {
+		return err
+	}
+
+	if n != entry.Size {
+		return fmt.Errorf("%w: %s", deploy.ErrUnexpectedFileSize, entry.Path)
+	} else if hash.Sum() != entry.Hash {
+		return fmt.Errorf("%w: %s", deploy.ErrUnexpectedFileHash, entry.Path)
+	}
+	return nil
+}
+
+func (c *Controller) deleteStagedObjects(ctx context.Context, deployment *models.Deployment) {
+	for _, entry := range deployment.Metadata.Files {
+		if strings.HasSuffix(entry.Path, "/") {
+			continue
+		}
+		err := c.Storage.Delete(ctx, deployment.StagingKey(entry.Path))
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			c.Logger.Warn("failed to delete staged object",
+				zap.String("deployment", deployment.ID),
+				zap.String("path", entry.Path),
+				zap.Error(err))
+		}
+	}
+}
+
+func (c *Controller) handleDeploymentFinalize(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+	deployment := get[*models.Deployment](r)
+
+	if err := c.checkDeploymentUploadable(deployment); err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	log(r).Info("finalizing deployment", zap.String("deployment", deployment.ID))
+
+	var mutex sync.Mutex
+	var keys []string
+	g, ctx := errgroup.WithContext(r.Context())
+	g.SetLimit(directUploadConcurrency)
+	for _, entry := range deployment.Metadata.Files {
+		entry := entry
+		g.Go(func() error {
+			key := deployment.StorageKeyPrefix + entry.Path
+			var err error
+			if strings.HasSuffix(entry.Path, "/") {
+				err = c.Storage.Upload(ctx, key, strings.NewReader(""))
+			} else {
+				err = c.placeDeploymentObject(ctx, deployment, entry)
+			}
+			if err != nil {
+				return err
+			}
+
+			mutex.Lock()
+			keys = append(keys, key)
+			mutex.Unlock()
+			return nil
+		})
+	}
+	err := g.Wait()
+	if err != nil {
+		c.rollbackObjects(r, deployment, keys)
+	}
+	if errors.As(err, new(deploy.Error)) {
+		writeJSON(w, http.StatusBadRequest, response{Error: err})
+		return
+	} else if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	result, err := c.completeDeploymentUpload(r, app, deployment, "direct")
+	if err == nil {
+		c.deleteStagedObjects(r.Context(), deployment)
+	}
+	writeResponse(w, result, err)
+}
@@ -0,0 +1,282 @@
+package controller_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/cron"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func (s *testServer) uploadURLs(name string) map[string]string {
+	var result struct {
+		URLs map[string]string `json:"urls"`
+	}
+	s.mustJSON("POST", "/api/v1/apps/test/deployments/"+name+"/upload-urls", nil, &result)
+	return result.URLs
+}
+
+func (s *testServer) directUpload(uploadURL string, content string) (int, string) {
+	u, err := url.Parse(uploadURL)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	assert.Equal(s.t, "http://api.localhost/api/v1/upload", u.Scheme+"://"+u.Host+u.Path)
+
+	w := s.Request("PUT", u.RequestURI(), strings.NewReader(content), http.Header{
+		"Authorization": nil,
+	})
+	return w.Code, ErrorOf(w)
+}
+
+func (s *testServer) readObject(key string) (string, bool) {
+	r, err := s.Storage.OpenRead(context.Background(), key)
+	if err != nil {
+		return "", false
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return string(data), true
+}
+
+func (s *testServer) signUploadToken(claims jwt.Claims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.Controller.Config.TokenSigningKey)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return token
+}
+
+func TestDeploymentDirectUpload(t *testing.T) {
+	s := newTestServer(t)
+	files := map[string]string{
+		"index.html":  "<html>hello</html>",
+		"assets/a.js": "console.log(1);",
+	}
+	d := s.CreateDeployment("direct", files, nil)
+	deployment, err := s.DB.GetDeploymentByName(context.Background(), testAppID, d.Name)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	urls := s.uploadURLs(d.Name)
+	assert.Len(t, urls, 2)
+	for path, content := range files {
+		code, _ := s.directUpload(urls["/public/"+path], content)
+		assert.Equal(t, http.StatusOK, code)
+	}
+
+	// Uploaded files are staged until finalized
+	_, ok := s.readObject(deployment.StorageKeyPrefix + "/public/index.html")
+	assert.False(t, ok)
+	content, ok := s.readObject(deployment.StagingKey("/public/index.html"))
+	assert.True(t, ok)
+	assert.Equal(t, files["index.html"], content)
+
+	// Staged files are not requested again
+	assert.Empty(t, s.uploadURLs(d.Name))
+
+	s.mustJSON("POST", "/api/v1/apps/test/deployments/direct/finalize", nil, nil)
+
+	for path, content := range files {
+		live, ok := s.readObject(deployment.StorageKeyPrefix + "/public/" + path)
+		assert.True(t, ok, path)
+		assert.Equal(t, content, live, path)
+
+		_, ok = s.readObject(deployment.StagingKey("/public/" + path))
+		assert.False(t, ok, path)
+	}
+
+	// Issued upload URLs cannot be used after finalize
+	code, errMsg := s.directUpload(urls["/public/index.html"], "<html>evil</html>")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, models.ErrDeploymentAlreadyUploaded.Error(), errMsg)
+
+	live, _ := s.readObject(deployment.StorageKeyPrefix + "/public/index.html")
+	assert.Equal(t, files["index.html"], live)
+	_, ok = s.readObject(deployment.StagingKey("/public/index.html"))
+	assert.False(t, ok)
+
+	w := s.JSON("POST", "/api/v1/apps/test/deployments/direct/upload-urls", nil, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeploymentDirectUploadOnCreate(t *testing.T) {
+	s := newTestServer(t)
+	d := s.CreateDeployment("direct", map[string]string{"index.html": "<html>hello</html>"}, nil)
+
+	var result struct {
+		Name       string `json:"name"`
+		UploadURLs *struct {
+			URLs map[string]string `json:"urls"`
+		} `json:"uploadURLs"`
+	}
+	s.mustJSON("POST", "/api/v1/apps/test/deployments", map[string]any{
+		"name":          "direct-create",
+		"files":         d.Files,
+		"site_config":   config.DefaultSiteConfig(),
+		"direct_upload": true,
+	}, &result)
+	assert.Equal(t, "direct-create", result.Name)
+	if !assert.NotNil(t, result.UploadURLs) {
+		return
+	}
+	assert.Len(t, result.UploadURLs.URLs, 1)
+
+	code, _ := s.directUpload(result.UploadURLs.URLs["/public/index.html"], "<html>hello</html>")
+	assert.Equal(t, http.StatusOK, code)
+	s.mustJSON("POST", "/api/v1/apps/test/deployments/direct-create/finalize", nil, nil)
+}
+
+func TestDeploymentDirectUploadMismatch(t *testing.T) {
+	s := newTestServer(t)
+	d := s.CreateDeployment("mismatch", map[string]string{
+		"index.html": "<html>hello</html>",
+		"a.js":       "console.log(1);",
+	}, nil)
+	deployment, err := s.DB.GetDeploymentByName(context.Background(), testAppID, d.Name)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	urls := s.uploadURLs(d.Name)
+
+	// Content length must match declared file size
+	code, _ := s.directUpload(urls["/public/index.html"], "<html>hi</html>")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Same size, different content
+	code, _ = s.directUpload(urls["/public/index.html"], "<html>HELLO</html>")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = s.directUpload(urls["/public/a.js"], "console.log(1);")
+	assert.Equal(t, http.StatusOK, code)
+
+	w := s.JSON("POST", "/api/v1/apps/test/deployments/mismatch/finalize", nil, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "unexpected file hash: /public/index.html", ErrorOf(w))
+
+	_, ok := s.readObject(deployment.StorageKeyPrefix + "/public/index.html")
+	assert.False(t, ok)
+	_, ok = s.readObject(deployment.StagingKey("/public/index.html"))
+	assert.False(t, ok)
+
+	// Objects placed before the failure are rolled back
+	_, ok = s.readObject(deployment.StorageKeyPrefix + "/public/a.js")
+	assert.False(t, ok)
+	_, ok = s.readObject(deployment.StorageKeyPrefix + "/public/")
+	assert.False(t, ok)
+
+	urls = s.uploadURLs(d.Name)
+	code, _ = s.directUpload(urls["/public/index.html"], "<html>hello</html>")
+	assert.Equal(t, http.StatusOK, code)
+	s.mustJSON("POST", "/api/v1/apps/test/deployments/mismatch/finalize", nil, nil)
+}
+
+func TestDeploymentDirectUploadToken(t *testing.T) {
+	s := newTestServer(t)
+	d := s.CreateDeployment("token", map[string]string{"index.html": "<html>hello</html>"}, nil)
+	deployment, err := s.DB.GetDeploymentByName(context.Background(), testAppID, d.Name)
+	if !assert.NoError(t, err) {
+		return
+	}
+	content := "<html>hello</html>"
+
+	upload := func(token string) (int, string) {
+		return s.directUpload("http://api.localhost/api/v1/upload?"+url.Values{"token": {token}}.Encode(), content)
+	}
+	claims := func(modify func(c jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss":        "pageship",
+			"aud":        "pageship/upload",
+			"sub":        "/public/index.html",
+			"exp":        s.Clock.Now().Add(time.Hour).Unix(),
+			"app":        testAppID,
+			"deployment": deployment.ID,
+			"size":       len(content),
+		}
+		if modify != nil {
+			modify(c)
+		}
+		return c
+	}
+
+	code, _ := upload(s.signUploadToken(claims(nil)))
+	assert.Equal(t, http.StatusOK, code)
+	staged, _ := s.readObject(deployment.StagingKey("/public/index.html"))
+	assert.Equal(t, content, staged)
+	assert.NoError(t, s.Storage.Delete(context.Background(), deployment.StagingKey("/public/index.html")))
+
+	// User API token is not an upload token
+	code, _ = upload(s.token)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = upload(s.signUploadToken(claims(func(c jwt.MapClaims) { c["aud"] = "pageship" })))
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims(nil)).SignedString([]byte("other"))
+	assert.NoError(t, err)
+	code, _ = upload(forged)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = upload(s.signUploadToken(claims(func(c jwt.MapClaims) { c["deployment"] = "deployment_unknown" })))
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = upload(s.signUploadToken(claims(func(c jwt.MapClaims) { c["app"] = "other" })))
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// Issued upload URLs expire
+	urls := s.uploadURLs(d.Name)
+	s.Clock.Advance(2 * time.Hour)
+	code, _ = s.directUpload(urls["/public/index.html"], content)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	keys, err := s.Storage.List(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestCleanupUploadStagings(t *testing.T) {
+	s := newTestServer(t)
+	d := s.CreateDeployment("abandoned", map[string]string{"index.html": "<html>hello</html>"}, nil)
+	deployment, err := s.DB.GetDeploymentByName(context.Background(), testAppID, d.Name)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	urls := s.uploadURLs(d.Name)
+	code, _ := s.directUpload(urls["/public/index.html"], "<html>hello</html>")
+	assert.Equal(t, http.StatusOK, code)
+
+	job := &cron.CleanupExpired{
+		Clock:   s.Clock,
+		DB:      s.DB,
+		Storage: s.Storage,
+	}
+
+	// Staged objects of pending upload are kept
+	assert.NoError(t, job.Run(context.Background(), zap.NewNop()))
+	_, ok := s.readObject(deployment.StagingKey("/public/index.html"))
+	assert.True(t, ok)
+
+	s.Clock.Advance(25 * time.Hour)
+	assert.NoError(t, job.Run(context.Background(), zap.NewNop()))
+	_, ok = s.readObject(deployment.StagingKey("/public/index.html"))
+	assert.False(t, ok)
+
+	stagings, err := s.DB.ListStaleDeploymentUploadStagings(context.Background(), s.Clock.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, stagings)
+}
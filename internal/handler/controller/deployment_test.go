@@ -0,0 +1,74 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeploymentRenameReplace(t *testing.T) {
+	s := newTestServer(t)
+	s.SetAppConfig(testAppID, func(conf *config.AppConfig) {
+		conf.Sites = []config.AppSiteConfig{{Name: "main"}}
+	})
+	s.mustJSON("POST", "/api/v1/apps/test/sites", map[string]any{"name": "main"}, nil)
+
+	for _, name := range []string{"preview", "next", "live"} {
+		s.UploadDeployment(s.CreateDeployment(name, map[string]string{"index.html": name}, nil))
+	}
+	s.mustJSON("PATCH", "/api/v1/apps/test/sites/main", map[string]any{"deploymentName": "live"}, nil)
+
+	lookup := func(name string) *models.Deployment {
+		deployment, err := s.DB.GetDeploymentByName(context.Background(), testAppID, name)
+		if err != nil {
+			return nil
+		}
+		return deployment
+	}
+	rename := func(name string, newName string, replace bool) (int, string) {
+		w := s.JSON("PATCH", "/api/v1/apps/test/deployments/"+name, map[string]any{"name": newName, "replace": replace}, nil)
+		return w.Code, ErrorOf(w)
+	}
+
+	previous := lookup("preview")
+	next := lookup("next")
+
+	// Existing name is not replaced implicitly
+	code, err := rename("next", "preview", false)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, models.ErrDeploymentUsedName.Error(), err)
+	assert.Equal(t, previous.ID, lookup("preview").ID)
+
+	code, _ = rename("next", "preview", true)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, next.ID, lookup("preview").ID)
+	assert.Nil(t, lookup("next"))
+
+	replaced, gerr := s.DB.GetDeployment(context.Background(), testAppID, previous.ID)
+	assert.ErrorIs(t, gerr, models.ErrDeploymentNotFound)
+	assert.Nil(t, replaced)
+
+	// Deployments assigned to site are not replaced
+	code, err = rename("preview", "live", true)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, models.ErrDeploymentActive.Error(), err)
+	assert.Equal(t, next.ID, lookup("preview").ID)
+	assert.NotNil(t, lookup("live"))
+
+	// Deployments not uploaded do not replace existing one
+	s.CreateDeployment("pending", map[string]string{"index.html": "pending"}, nil)
+	code, err = rename("pending", "preview", true)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, models.ErrDeploymentNotUploaded.Error(), err)
+	assert.Equal(t, next.ID, lookup("preview").ID)
+
+	// Site names are not usable as deployment name
+	code, err = rename("preview", "main", false)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, models.ErrDeploymentNameIsSite.Error(), err)
+	assert.Equal(t, next.ID, lookup("preview").ID)
+}
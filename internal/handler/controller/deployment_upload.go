@@ -0,0 +1,316 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/oursky/pageship/internal/deploy"
+	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/storage"
+	"go.uber.org/zap"
+)
+
+var errInvalidContentRange = errors.New("invalid content range")
+var errIncompleteChunk = errors.New("incomplete upload chunk")
+var errMissingChunk = errors.New("missing upload chunk")
+var errUploadSizeMismatch = errors.New("upload size mismatch")
+var errIncompleteUpload = errors.New("incomplete upload")
+
+type apiDeploymentUpload struct {
+	Offset int64 `json:"offset"`
+}
+
+func uploadOffset(chunks []*models.DeploymentUploadChunk) int64 {
+	if len(chunks) == 0 {
+		return 0
+	}
+	last := chunks[len(chunks)-1]
+	return last.Offset + last.Size
+}
+
+// uploadTotal returns the total upload size declared by chunks; nil if
+// unknown.
+func uploadTotal(chunks []*models.DeploymentUploadChunk) *int64 {
+	for _, chunk := range chunks {
+		if chunk.Total != nil {
+			return chunk.Total
+		}
+	}
+	return nil
+}
+
+// parseContentRange parses header value of form 'bytes <start>-<end>/<total>';
+// total is -1 if it is unknown ('*').
+func parseContentRange(value string) (start int64, end int64, total int64, err error) {
+	err = errInvalidContentRange
+
+	value, ok := strings.CutPrefix(value, "bytes ")
+	if !ok {
+		return
+	}
+	byteRange, totalValue, ok := strings.Cut(value, "/")
+	if !ok {
+		return
+	}
+	startValue, endValue, ok := strings.Cut(byteRange, "-")
+	if !ok {
+		return
+	}
+
+	var perr error
+	if start, perr = strconv.ParseInt(startValue, 10, 64); perr != nil || start < 0 {
+		return
+	}
+	if end, perr = strconv.ParseInt(endValue, 10, 64); perr != nil || end < start {
+		return
+	}
+	if totalValue == "*" {
+		total = -1
+	} else if total, perr = strconv.ParseInt(totalValue, 10, 64); perr != nil || end >= total {
+		return
+	}
+
+	err = nil
+	return
+}
+
+func (c *Controller) handleDeploymentUploadStatus(w http.ResponseWriter, r *http.Request) {
+	deployment := get[*models.Deployment](r)
+
+	respond(w, func() (any, error) {
+		if err := c.checkDeploymentUploadable(deployment); err != nil {
+			return nil, err
+		}
+
+		chunks, err := c.DB.ListDeploymentUploadChunks(r.Context(), deployment.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &apiDeploymentUpload{Offset: uploadOffset(chunks)}, nil
+	})
+}
+
+func (c *Controller) handleDeploymentUploadChunk(w http.ResponseWriter, r *http.Request) {
+	deployment := get[*models.Deployment](r)
+
+	if err := c.checkDeploymentUploadable(deployment); err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	start, end, total, err := parseContentRange(r.Header.Get("Content-Range"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Error: err})
+		return
+	}
+	size := end + 1
+	if total > size {
+		size = total
+	}
+	if size > c.Config.MaxDeploymentSize {
+		writeJSON(w, http.StatusBadRequest, response{
+			Error: fmt.Errorf(
+				"deployment too large: %s > %s",
+				humanize.Bytes(uint64(size)),
+				humanize.Bytes(uint64(c.Config.MaxDeploymentSize)),
+			),
+		})
+		return
+	}
+
+	chunks, err := c.DB.ListDeploymentUploadChunks(r.Context(), deployment.ID)
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+	if offset := uploadOffset(chunks); start != offset {
+		writeResponse(w, nil, models.ErrDeploymentUploadOffset)
+		return
+	}
+
+	var totalSize *int64
+	if total >= 0 {
+		// Total size must be consistent with previous chunks.
+		if declared := uploadTotal(chunks); declared != nil && *declared != total {
+			writeJSON(w, http.StatusBadRequest, response{Error: errUploadSizeMismatch})
+			return
+		}
+		totalSize = &total
+	}
+
+	chunk := models.NewDeploymentUploadChunk(c.Clock.Now().UTC(), deployment.ID, start, end-start+1, totalSize)
+	key := chunk.StorageKey(deployment.StorageKeyPrefix)
+
+	reader := &countReader{
+		r: io.LimitReader(
+			httputil.NewTimeoutReader(
+				r.Body,
+				http.NewResponseController(w),
+				10*time.Second,
+			),
+			chunk.Size,
+		),
+	}
+	if err := c.Storage.Upload(r.Context(), key, reader); err != nil {
+		c.Storage.Delete(r.Context(), key)
+		writeResponse(w, nil, err)
+		return
+	}
+	if reader.n != chunk.Size {
+		c.Storage.Delete(r.Context(), key)
+		writeJSON(w, http.StatusBadRequest, response{Error: errIncompleteChunk})
+		return
+	}
+
+	if err := c.DB.AddDeploymentUploadChunk(r.Context(), chunk); err != nil {
+		c.Storage.Delete(r.Context(), key)
+		writeResponse(w, nil, err)
+		return
+	}
+
+	log(r).Debug("uploaded deployment chunk",
+		zap.String("deployment", deployment.ID),
+		zap.Int64("offset", chunk.Offset),
+		zap.Int64("size", chunk.Size))
+
+	writeResponse(w, &apiDeploymentUpload{Offset: chunk.Offset + chunk.Size}, nil)
+}
+
+func (c *Controller) handleDeploymentUploadComplete(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+	deployment := get[*models.Deployment](r)
+
+	if err := c.checkDeploymentUploadable(deployment); err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	chunks, err := c.DB.ListDeploymentUploadChunks(r.Context(), deployment.ID)
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	var offset int64 = 0
+	for _, chunk := range chunks {
+		if chunk.Offset != offset {
+			writeJSON(w, http.StatusBadRequest, response{Error: errMissingChunk})
+			return
+		}
+		offset += chunk.Size
+	}
+	if total := uploadTotal(chunks); len(chunks) == 0 || (total != nil && *total != offset) {
+		writeJSON(w, http.StatusBadRequest, response{Error: errIncompleteUpload})
+		return
+	}
+
+	log(r).Info("uploading deployment from chunks",
+		zap.String("deployment", deployment.ID),
+		zap.Int("chunks", len(chunks)),
+		zap.Int64("size", offset))
+
+	reader := &chunksReader{
+		ctx:        r.Context(),
+		storage:    c.Storage,
+		deployment: deployment,
+		chunks:     chunks,
+	}
+	err = c.extractDeployment(r, deployment, reader)
+	reader.Close()
+	if errors.As(err, new(deploy.Error)) {
+		// Uploaded content is invalid; discard it to allow restarting upload.
+		c.deleteUploadChunks(r.Context(), deployment, chunks)
+		writeJSON(w, http.StatusBadRequest, response{Error: err})
+		return
+	} else if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	c.deleteUploadChunks(r.Context(), deployment, chunks)
+
+	respond(w, func() (any, error) {
+		return c.completeDeploymentUpload(r, app, deployment, "chunked")
+	})
+}
+
+func (c *Controller) deleteUploadChunks(ctx context.Context, deployment *models.Deployment, chunks []*models.DeploymentUploadChunk) {
+	for _, chunk := range chunks {
+		if err := c.Storage.Delete(ctx, chunk.StorageKey(deployment.StorageKeyPrefix)); err != nil {
+			c.Logger.Warn("failed to delete upload chunk",
+				zap.String("deployment", deployment.ID),
+				zap.String("chunk", chunk.ID),
+				zap.Error(err))
+		}
+	}
+	if err := c.DB.DeleteDeploymentUploadChunks(ctx, deployment.ID); err != nil {
+		c.Logger.Warn("failed to delete upload chunks",
+			zap.String("deployment", deployment.ID),
+			zap.Error(err))
+	}
+}
+
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// chunksReader reads the staged chunks sequentially, opening each chunk
+// only when needed.
+type chunksReader struct {
+	ctx        context.Context
+	storage    *storage.Storage
+	deployment *models.Deployment
+	chunks     []*models.DeploymentUploadChunk
+	current    io.ReadCloser
+}
+
+func (r *chunksReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.chunks) == 0 {
+				return 0, io.EOF
+			}
+
+			reader, err := r.storage.OpenRead(r.ctx, r.chunks[0].StorageKey(r.deployment.StorageKeyPrefix))
+			if err != nil {
+				return 0, err
+			}
+			r.current = reader
+			r.chunks = r.chunks[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if errors.Is(err, io.EOF) {
+			r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *chunksReader) Close() error {
+	if r.current != nil {
+		return r.current.Close()
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/cron"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func (s *testServer) uploadChunk(name string, data []byte, contentRange string) (int64, int, string) {
+	w := s.Request("PUT", "/api/v1/apps/test/deployments/"+name+"/upload", bytes.NewReader(data), http.Header{
+		"Content-Range": []string{contentRange},
+	})
+	if w.Code != http.StatusOK {
+		return 0, w.Code, ErrorOf(w)
+	}
+
+	var result struct {
+		Offset int64 `json:"offset"`
+	}
+	s.mustJSON("GET", "/api/v1/apps/test/deployments/"+name+"/upload", nil, &result)
+	return result.Offset, w.Code, ""
+}
+
+func (s *testServer) stagedChunkKeys() []string {
+	keys, err := s.Storage.List(context.Background(), "")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	var chunks []string
+	for _, key := range keys {
+		if strings.Contains(key, "/.upload/") {
+			chunks = append(chunks, key)
+		}
+	}
+	return chunks
+}
+
+func TestDeploymentUploadChunks(t *testing.T) {
+	s := newTestServer(t)
+	d := s.CreateDeployment("chunked", map[string]string{
+		"index.html":  "<html>hello</html>",
+		"assets/a.js": strings.Repeat("console.log(1);\n", 100),
+	}, nil)
+	data := d.Tarball
+	total := int64(len(data))
+	contentRange := func(start, end, total int64) string {
+		return fmt.Sprintf("bytes %d-%d/%d", start, end, total)
+	}
+
+	for _, value := range []string{
+		"",
+		"items 0-1/2",
+		"bytes 0-1",
+		"bytes 2-1/10",
+		"bytes 0-10/10",
+		"bytes a-1/10",
+	} {
+		_, code, err := s.uploadChunk(d.Name, data[:2], value)
+		assert.Equal(t, http.StatusBadRequest, code, value)
+		assert.Equal(t, "invalid content range", err, value)
+	}
+
+	// Body shorter than declared range
+	_, code, err := s.uploadChunk(d.Name, data[:5], contentRange(0, 9, total))
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "incomplete upload chunk", err)
+
+	offset, code, _ := s.uploadChunk(d.Name, data[:10], contentRange(0, 9, total))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int64(10), offset)
+
+	// Not continuing from current offset
+	_, code, _ = s.uploadChunk(d.Name, data[:10], contentRange(0, 9, total))
+	assert.Equal(t, http.StatusConflict, code)
+
+	// Total size differs from previous chunks
+	_, code, err = s.uploadChunk(d.Name, data[10:20], contentRange(10, 19, total+1))
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "upload size mismatch", err)
+
+	offset, code, _ = s.uploadChunk(d.Name, data[10:20], contentRange(10, 19, total))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int64(20), offset)
+
+	// Short upload cannot complete
+	w := s.JSON("POST", "/api/v1/apps/test/deployments/chunked/upload/complete", nil, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "incomplete upload", ErrorOf(w))
+
+	offset, code, _ = s.uploadChunk(d.Name, data[20:], contentRange(20, total-1, total))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, total, offset)
+	assert.Len(t, s.stagedChunkKeys(), 3)
+
+	s.mustJSON("POST", "/api/v1/apps/test/deployments/chunked/upload/complete", nil, nil)
+	assert.Empty(t, s.stagedChunkKeys())
+
+	deployment, gerr := s.DB.GetDeploymentByName(context.Background(), testAppID, d.Name)
+	if !assert.NoError(t, gerr) {
+		return
+	}
+	assert.NotNil(t, deployment.UploadedAt)
+
+	// Extracted content is assembled from all chunks
+	r, gerr := s.Storage.OpenRead(context.Background(), deployment.StorageKeyPrefix+"/public/assets/a.js")
+	if assert.NoError(t, gerr) {
+		buf := new(bytes.Buffer)
+		buf.ReadFrom(r)
+		r.Close()
+		assert.Equal(t, strings.Repeat("console.log(1);\n", 100), buf.String())
+	}
+
+	_, code, err = s.uploadChunk(d.Name, data[:10], contentRange(0, 9, total))
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "deployment is already uploaded", err)
+}
+
+func TestCleanupUploadChunks(t *testing.T) {
+	s := newTestServer(t)
+	d := s.CreateDeployment("abandoned", map[string]string{"index.html": "<html>hello</html>"}, nil)
+	total := int64(len(d.Tarball))
+
+	_, code, _ := s.uploadChunk(d.Name, d.Tarball[:10], fmt.Sprintf("bytes 0-9/%d", total))
+	assert.Equal(t, http.StatusOK, code)
+
+	deployment, err := s.DB.GetDeploymentByName(context.Background(), testAppID, d.Name)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	job := &cron.CleanupExpired{
+		Clock:   s.Clock,
+		DB:      s.DB,
+		Storage: s.Storage,
+	}
+
+	// Chunks of pending upload are kept
+	assert.NoError(t, job.Run(context.Background(), zap.NewNop()))
+	assert.Len(t, s.stagedChunkKeys(), 1)
+
+	s.Clock.Advance(25 * time.Hour)
+	assert.NoError(t, job.Run(context.Background(), zap.NewNop()))
+	assert.Empty(t, s.stagedChunkKeys())
+
+	chunks, err := s.DB.ListDeploymentUploadChunks(context.Background(), deployment.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, chunks)
+}
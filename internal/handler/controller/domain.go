@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/domain"
 	"github.com/oursky/pageship/internal/models"
 	"go.uber.org/zap"
 )
@@ -14,6 +15,11 @@ type apiDomain struct {
 	*models.Domain
 }
 
+type apiDomainVerification struct {
+	*models.DomainVerification
+	RecordName string `json:"recordName"`
+}
+
 func (c *Controller) makeAPIDomain(domain *models.Domain) *apiDomain {
 	return &apiDomain{Domain: domain}
 }
@@ -24,13 +30,15 @@ func (c *Controller) handleDomainList(w http.ResponseWriter, r *http.Request) {
 	respond(w, func() (any, error) {
 		var domains []*models.Domain
 		for _, dconf := range app.Config.Domains {
-			domain, err := c.DB.GetDomainByName(r.Context(), dconf.Domain)
-			if errors.Is(err, models.ErrDomainNotFound) {
-				continue
-			} else if err != nil {
-				return nil, err
+			for _, name := range append([]string{dconf.Domain}, dconf.Aliases...) {
+				domain, err := c.DB.GetDomainByName(r.Context(), name)
+				if errors.Is(err, models.ErrDomainNotFound) {
+					continue
+				} else if err != nil {
+					return nil, err
+				}
+				domains = append(domains, domain)
 			}
-			domains = append(domains, domain)
 		}
 
 		return mapModels(domains, func(d *models.Domain) *apiDomain {
@@ -45,12 +53,25 @@ func (c *Controller) handleDomainCreate(w http.ResponseWriter, r *http.Request)
 	domainName := chi.URLParam(r, "domain-name")
 	replaceApp := r.URL.Query().Get("replaceApp")
 
-	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+	// Domain of replaced app is recorded as before state
+	var replaced *models.Domain
+
+	result, err := withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+
 		config, ok := app.Config.ResolveDomain(domainName)
 		if !ok {
 			return nil, models.ErrUndefinedDomain
 		}
 
+		verification, err := tx.GetDomainVerification(r.Context(), domainName, app.ID)
+		if errors.Is(err, models.ErrDomainVerificationNotFound) {
+			return nil, models.ErrDomainNotVerified
+		} else if err != nil {
+			return nil, err
+		} else if !verification.IsVerified() {
+			return nil, models.ErrDomainNotVerified
+		}
+
 		domain, err := tx.GetDomainByName(r.Context(), domainName)
 		if errors.Is(err, models.ErrDomainNotFound) {
 			// Continue create new domain.
@@ -67,9 +88,20 @@ func (c *Controller) handleDomainCreate(w http.ResponseWriter, r *http.Request)
 			if err != nil {
 				return nil, err
 			}
+			replaced = domain
+		}
+
+		var aliasOf *string
+		if config.Domain != domainName {
+			aliasOf = &config.Domain
+		}
+
+		siteName := config.Site
+		if config.IsWildcard() {
+			siteName = models.DomainSiteWildcard
 		}
 
-		domain = models.NewDomain(c.Clock.Now().UTC(), domainName, app.ID, config.Site)
+		domain = models.NewDomain(c.Clock.Now().UTC(), domainName, app.ID, siteName, aliasOf)
 		err = tx.CreateDomain(r.Context(), domain)
 		if err != nil {
 			return nil, err
@@ -79,8 +111,23 @@ func (c *Controller) handleDomainCreate(w http.ResponseWriter, r *http.Request)
 			zap.String("domain", domain.Domain),
 			zap.String("site", domain.SiteName))
 
+		err = c.audit(r, tx, app.ID, models.AuditDomainCreate, domain.Domain, replaced, domain)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.notifyDomainActivated(r, tx, app, domain)
+		if err != nil {
+			return nil, err
+		}
+
 		return c.makeAPIDomain(domain), nil
-	}))
+	})()
+	if err == nil && replaced != nil {
+		// Uploaded certificate belongs to the replaced app.
+		c.deleteDomainCerts(r, []string{domainName})
+	}
+	writeResponse(w, result, err)
 }
 
 func (c *Controller) handleDomainDelete(w http.ResponseWriter, r *http.Request) {
@@ -88,7 +135,7 @@ func (c *Controller) handleDomainDelete(w http.ResponseWriter, r *http.Request)
 
 	domainName := chi.URLParam(r, "domain-name")
 
-	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+	result, err := withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
 		domain, err := tx.GetDomainByName(r.Context(), domainName)
 		if err != nil {
 			return nil, err
@@ -106,6 +153,72 @@ func (c *Controller) handleDomainDelete(w http.ResponseWriter, r *http.Request)
 			zap.String("domain", domain.Domain),
 			zap.String("site", domain.SiteName))
 
+		err = c.audit(r, tx, app.ID, models.AuditDomainDelete, domain.Domain, domain, nil)
+		if err != nil {
+			return nil, err
+		}
+
 		return struct{}{}, nil
-	}))
+	})()
+	if err == nil {
+		c.deleteDomainCerts(r, []string{domainName})
+	}
+	writeResponse(w, result, err)
+}
+
+func (c *Controller) handleDomainVerify(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+
+	domainName := chi.URLParam(r, "domain-name")
+
+	respond(w, func() (any, error) {
+		if _, ok := app.Config.ResolveDomain(domainName); !ok {
+			return nil, models.ErrUndefinedDomain
+		}
+
+		verification, err := withTx(r.Context(), c.DB, func(tx db.Tx) (*models.DomainVerification, error) {
+			verification, err := tx.GetDomainVerification(r.Context(), domainName, app.ID)
+			if errors.Is(err, models.ErrDomainVerificationNotFound) {
+				verification = models.NewDomainVerification(c.Clock.Now().UTC(), domainName, app.ID)
+				err = tx.CreateDomainVerification(r.Context(), verification)
+			}
+			return verification, err
+		})()
+		if err != nil {
+			return nil, err
+		}
+
+		if !verification.IsVerified() {
+			ok, err := domain.VerifyChallenge(r.Context(), c.DNSResolver, domainName, verification.Value)
+			if err != nil {
+				log(r).Warn("failed to lookup domain challenge",
+					zap.String("domain", domainName),
+					zap.Error(err))
+			}
+
+			now := c.Clock.Now().UTC()
+			verification.UpdatedAt = now
+			verification.LastCheckedAt = &now
+			if ok {
+				verification.VerifiedAt = &now
+				log(r).Info("verified domain",
+					zap.String("domain", domainName),
+					zap.String("app", app.ID))
+			}
+			if err := c.DB.UpdateDomainVerification(r.Context(), verification); err != nil {
+				return nil, err
+			}
+			if ok {
+				err := c.audit(r, c.DB, app.ID, models.AuditDomainVerify, domainName, nil, verification)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		return &apiDomainVerification{
+			DomainVerification: verification,
+			RecordName:         domain.ChallengeRecordName(domainName),
+		}, nil
+	})
 }
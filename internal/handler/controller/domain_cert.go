@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/models"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) checkDomainCertAccess(r *http.Request, app *models.App, domainName string) error {
+	if c.CertStorage == nil {
+		return models.ErrTLSNotEnabled
+	}
+	if _, ok := app.Config.ResolveDomain(domainName); !ok {
+		return models.ErrUndefinedDomain
+	}
+
+	verification, err := c.DB.GetDomainVerification(r.Context(), domainName, app.ID)
+	if errors.Is(err, models.ErrDomainVerificationNotFound) {
+		return models.ErrDomainNotVerified
+	} else if err != nil {
+		return err
+	} else if !verification.IsVerified() {
+		return models.ErrDomainNotVerified
+	}
+	return nil
+}
+
+// deleteDomainCerts deletes certificates uploaded for deactivated domains, so
+// that they would not be served for the domain again.
+func (c *Controller) deleteDomainCerts(r *http.Request, domainNames []string) {
+	if c.CertStorage == nil {
+		return
+	}
+	for _, domainName := range domainNames {
+		err := httputil.DeleteCustomCert(r.Context(), c.CertStorage, domainName)
+		if err != nil {
+			log(r).Warn("failed to delete domain certificate",
+				zap.String("domain", domainName),
+				zap.Error(err))
+		}
+	}
+}
+
+func (c *Controller) handleDomainCertSet(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+
+	domainName := chi.URLParam(r, "domain-name")
+
+	var request struct {
+		Certificate string `json:"certificate" binding:"required"`
+		PrivateKey  string `json:"privateKey" binding:"required"`
+	}
+	if !bindJSON(w, r, &request) {
+		return
+	}
+
+	respond(w, func() (any, error) {
+		if err := c.checkDomainCertAccess(r, app, domainName); err != nil {
+			return nil, err
+		}
+
+		certPEM, keyPEM := []byte(request.Certificate), []byte(request.PrivateKey)
+		cert, err := httputil.ParseCustomCert(domainName, certPEM, keyPEM, c.Clock.Now())
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", models.ErrInvalidCertificate, err)
+		}
+
+		err = httputil.StoreCustomCert(r.Context(), c.CertStorage, domainName, certPEM, keyPEM)
+		if err != nil {
+			return nil, err
+		}
+
+		log(r).Info("uploaded domain certificate",
+			zap.String("domain", domainName),
+			zap.String("issuer", cert.Leaf.Issuer.String()),
+			zap.Time("not_after", cert.Leaf.NotAfter))
+
+		// Private key is never recorded.
+		err = c.audit(r, c.DB, app.ID, models.AuditDomainCertSet, domainName, nil, map[string]any{
+			"issuer":   cert.Leaf.Issuer.String(),
+			"dnsNames": cert.Leaf.DNSNames,
+			"notAfter": cert.Leaf.NotAfter,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return c.checkDomainCertificate(r.Context(), domainName)
+	})
+}
+
+func (c *Controller) handleDomainCertDelete(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+
+	domainName := chi.URLParam(r, "domain-name")
+
+	respond(w, func() (any, error) {
+		if err := c.checkDomainCertAccess(r, app, domainName); err != nil {
+			return nil, err
+		}
+
+		err := httputil.DeleteCustomCert(r.Context(), c.CertStorage, domainName)
+		if err != nil {
+			return nil, err
+		}
+
+		log(r).Info("deleted domain certificate", zap.String("domain", domainName))
+
+		err = c.audit(r, c.DB, app.ID, models.AuditDomainCertDelete, domainName, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return struct{}{}, nil
+	})
+}
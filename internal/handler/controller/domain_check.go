@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caddyserver/certmagic"
+	"github.com/go-chi/chi/v5"
+	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/models"
+)
+
+type apiDomainCheck struct {
+	Domain         string                    `json:"domain"`
+	Active         bool                      `json:"active"`
+	AppID          string                    `json:"appID,omitempty"`
+	SiteName       string                    `json:"siteName,omitempty"`
+	Verified       bool                      `json:"verified"`
+	DeploymentName *string                   `json:"deploymentName"`
+	DNS            apiDomainCheckDNS         `json:"dns"`
+	Certificate    apiDomainCheckCertificate `json:"certificate"`
+}
+
+type apiDomainCheckDNS struct {
+	CNAME             string   `json:"cname,omitempty"`
+	Addresses         []string `json:"addresses"`
+	ExpectedAddresses []string `json:"expectedAddresses"`
+	PointsToServer    bool     `json:"pointsToServer"`
+	Error             string   `json:"error,omitempty"`
+}
+
+type apiDomainCheckCertificate struct {
+	Managed   bool                `json:"managed"`
+	Custom    bool                `json:"custom"`
+	Issuer    string              `json:"issuer,omitempty"`
+	DNSNames  []string            `json:"dnsNames,omitempty"`
+	NotBefore *time.Time          `json:"notBefore,omitempty"`
+	NotAfter  *time.Time          `json:"notAfter,omitempty"`
+	LastError *httputil.CertError `json:"lastError,omitempty"`
+}
+
+func (c *Controller) handleDomainCheck(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+
+	domainName := chi.URLParam(r, "domain-name")
+
+	respond(w, func() (any, error) {
+		config, ok := app.Config.ResolveDomain(domainName)
+		if !ok {
+			return nil, models.ErrUndefinedDomain
+		}
+
+		result := &apiDomainCheck{Domain: domainName, SiteName: config.Site}
+
+		verification, err := c.DB.GetDomainVerification(r.Context(), domainName, app.ID)
+		if err != nil && !errors.Is(err, models.ErrDomainVerificationNotFound) {
+			return nil, err
+		}
+		result.Verified = verification != nil && verification.IsVerified()
+
+		dom, err := c.DB.GetDomainByName(r.Context(), domainName)
+		if errors.Is(err, models.ErrDomainNotFound) {
+			// Not activated.
+		} else if err != nil {
+			return nil, err
+		} else {
+			result.AppID = dom.AppID
+			result.SiteName = dom.SiteName
+			result.Active = dom.AppID == app.ID
+		}
+
+		if result.Active && result.SiteName != models.DomainSiteWildcard {
+			deployment, err := c.DB.GetSiteDeployment(r.Context(), app.ID, result.SiteName)
+			if errors.Is(err, models.ErrDeploymentNotFound) {
+				// No active deployment.
+			} else if err != nil {
+				return nil, err
+			} else {
+				result.DeploymentName = &deployment.Name
+			}
+		}
+
+		sub := config.Site
+		if sub == app.Config.DefaultSite {
+			sub = ""
+		}
+		siteHost := c.Config.HostPattern.MakeDomain(c.Config.HostIDScheme.Make(app.ID, sub))
+		dnsName := domainName
+		if config.IsWildcard() {
+			// Check DNS of the default site under wildcard domain.
+			dnsName = app.Config.DefaultSite + strings.TrimPrefix(domainName, "*")
+		}
+		result.DNS = c.checkDomainDNS(r.Context(), dnsName, siteHost)
+
+		result.Certificate, err = c.checkDomainCertificate(r.Context(), domainName)
+		if err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	})
+}
+
+func (c *Controller) checkDomainDNS(ctx context.Context, domainName string, siteHost string) apiDomainCheckDNS {
+	result := apiDomainCheckDNS{}
+
+	if cname, err := c.DNSResolver.LookupCNAME(ctx, domainName); err == nil {
+		cname = strings.TrimSuffix(cname, ".")
+		if cname != domainName {
+			result.CNAME = cname
+		}
+	}
+
+	addrs, err := c.DNSResolver.LookupHost(ctx, domainName)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	sort.Strings(addrs)
+	result.Addresses = addrs
+
+	expected, err := c.DNSResolver.LookupHost(ctx, siteHost)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	sort.Strings(expected)
+	result.ExpectedAddresses = expected
+
+	expectedAddrs := make(map[string]struct{})
+	for _, a := range expected {
+		expectedAddrs[a] = struct{}{}
+	}
+	result.PointsToServer = len(addrs) > 0
+	for _, a := range addrs {
+		if _, ok := expectedAddrs[a]; !ok {
+			result.PointsToServer = false
+		}
+	}
+
+	return result
+}
+
+func (c *Controller) checkDomainCertificate(ctx context.Context, domainName string) (apiDomainCheckCertificate, error) {
+	result := apiDomainCheckCertificate{}
+	if c.CertStorage == nil {
+		return result, nil
+	}
+	result.Managed = true
+
+	// Uploaded certificate is served in preference to issued certificate.
+	custom, err := httputil.LoadCustomCert(ctx, c.CertStorage, domainName)
+	if err != nil {
+		return result, err
+	}
+	if custom != nil {
+		result.Custom = true
+		result.Issuer = custom.Issuer.String()
+		result.DNSNames = custom.DNSNames
+		result.NotBefore = &custom.NotBefore
+		result.NotAfter = &custom.NotAfter
+		return result, nil
+	}
+
+	certErr, err := httputil.LoadCertError(ctx, c.CertStorage, domainName)
+	if err != nil {
+		return result, err
+	}
+	result.LastError = certErr
+
+	key := certmagic.StorageKeys.SiteCert(c.CertIssuerKey, domainName)
+	data, err := c.CertStorage.Load(ctx, key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, nil
+	} else if err != nil {
+		return result, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return result, nil
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return result, nil
+	}
+	result.Issuer = cert.Issuer.String()
+	result.DNSNames = cert.DNSNames
+	result.NotBefore = &cert.NotBefore
+	result.NotAfter = &cert.NotAfter
+
+	return result, nil
+}
@@ -0,0 +1,230 @@
+package controller_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/certmagic"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainCreateNotVerified(t *testing.T) {
+	s := newTestServer(t)
+
+	s.SetAppConfig(testAppID, func(conf *config.AppConfig) {
+		conf.Domains = []config.AppDomainConfig{{Domain: "example.com", Site: "main"}}
+	})
+
+	w := s.JSON("POST", "/api/v1/apps/test/domains/example.com", nil, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var resp struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, models.ErrDomainNotVerified.Error(), resp.Error)
+	assert.Equal(t, models.ErrorCodeDomainNotVerified, resp.Code)
+
+	// Other errors have no code
+	w = s.JSON("POST", "/api/v1/apps/test/domains/undefined.com", nil, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NotContains(t, w.Body.String(), `"code"`)
+}
+
+type fakeResolver struct {
+	hosts map[string][]string
+	cname map[string]string
+}
+
+func (r fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	addrs, ok := r.hosts[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addrs, nil
+}
+
+func (r fakeResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	if cname, ok := r.cname[host]; ok {
+		return cname + ".", nil
+	}
+	return host + ".", nil
+}
+
+func makeCertPEM(t *testing.T, name string, notAfter time.Time) (certPEM []byte, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		Issuer:       pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return
+}
+
+func TestDomainCheck(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	certStorage := &certmagic.FileStorage{Path: t.TempDir()}
+	s.Controller.CertStorage = certStorage
+	s.Controller.CertIssuerKey = "acme.example.net-directory"
+	s.Controller.DNSResolver = fakeResolver{
+		hosts: map[string][]string{
+			"example.com":     {"192.0.2.1"},
+			"www.example.com": {"192.0.2.1"},
+			"test.localhost":  {"192.0.2.1"},
+			"other.com":       {"198.51.100.1"},
+		},
+		cname: map[string]string{"www.example.com": "test.localhost"},
+	}
+
+	s.SetAppConfig(testAppID, func(conf *config.AppConfig) {
+		conf.Domains = []config.AppDomainConfig{{
+			Domain:  "example.com",
+			Site:    "main",
+			Aliases: []string{"www.example.com", "other.com", "missing.com"},
+		}}
+	})
+
+	notAfter := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	certPEM, _ := makeCertPEM(t, "example.com", notAfter)
+	err := certStorage.Store(ctx, certmagic.StorageKeys.SiteCert("acme.example.net-directory", "example.com"), certPEM)
+	assert.NoError(t, err)
+	// Certificates of other issuers are not reported
+	certPEM, _ = makeCertPEM(t, "other.com", notAfter)
+	err = certStorage.Store(ctx, certmagic.StorageKeys.SiteCert("other-issuer", "other.com"), certPEM)
+	assert.NoError(t, err)
+
+	type result struct {
+		DNS struct {
+			CNAME             string   `json:"cname"`
+			Addresses         []string `json:"addresses"`
+			ExpectedAddresses []string `json:"expectedAddresses"`
+			PointsToServer    bool     `json:"pointsToServer"`
+			Error             string   `json:"error"`
+		} `json:"dns"`
+		Certificate struct {
+			Managed  bool       `json:"managed"`
+			DNSNames []string   `json:"dnsNames"`
+			NotAfter *time.Time `json:"notAfter"`
+		} `json:"certificate"`
+	}
+	check := func(domain string) result {
+		var r result
+		s.mustJSON("GET", "/api/v1/apps/test/domains/"+domain+"/check", nil, &r)
+		return r
+	}
+
+	r := check("example.com")
+	assert.True(t, r.DNS.PointsToServer)
+	assert.Equal(t, "", r.DNS.CNAME)
+	assert.True(t, r.Certificate.Managed)
+	assert.Equal(t, []string{"example.com"}, r.Certificate.DNSNames)
+	if assert.NotNil(t, r.Certificate.NotAfter) {
+		assert.True(t, notAfter.Equal(*r.Certificate.NotAfter))
+	}
+
+	r = check("www.example.com")
+	assert.True(t, r.DNS.PointsToServer)
+	assert.Equal(t, "test.localhost", r.DNS.CNAME)
+	assert.Nil(t, r.Certificate.NotAfter)
+
+	r = check("other.com")
+	assert.False(t, r.DNS.PointsToServer)
+	assert.Equal(t, []string{"198.51.100.1"}, r.DNS.Addresses)
+	assert.Equal(t, []string{"192.0.2.1"}, r.DNS.ExpectedAddresses)
+	assert.True(t, r.Certificate.Managed)
+	assert.Nil(t, r.Certificate.NotAfter)
+
+	r = check("missing.com")
+	assert.False(t, r.DNS.PointsToServer)
+	assert.NotEmpty(t, r.DNS.Error)
+}
+
+func TestDomainCertDeletedWithDomain(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	s.Controller.CertStorage = &certmagic.FileStorage{Path: t.TempDir()}
+
+	setConfig := func(appID string) {
+		s.SetAppConfig(appID, func(conf *config.AppConfig) {
+			conf.Domains = []config.AppDomainConfig{{Domain: "example.com", Site: "main"}}
+		})
+	}
+	setupApp := func(appID string) {
+		setConfig(appID)
+
+		verification := models.NewDomainVerification(s.Clock.Now(), "example.com", appID)
+		now := s.Clock.Now()
+		verification.VerifiedAt = &now
+		assert.NoError(t, s.DB.CreateDomainVerification(ctx, verification))
+	}
+	setCert := func() {
+		certPEM, keyPEM := makeCertPEM(t, "example.com", s.Clock.Now().Add(30*24*time.Hour))
+		s.mustJSON("PUT", "/api/v1/apps/test/domains/example.com/cert", map[string]any{
+			"certificate": string(certPEM),
+			"privateKey":  string(keyPEM),
+		}, nil)
+	}
+	hasCert := func() bool {
+		cert, err := httputil.LoadCustomCert(ctx, s.Controller.CertStorage, "example.com")
+		assert.NoError(t, err)
+		return cert != nil
+	}
+
+	setupApp(testAppID)
+	s.mustJSON("POST", "/api/v1/apps/test/domains/example.com", nil, nil)
+	setCert()
+	assert.True(t, hasCert())
+
+	// Deactivated
+	s.mustJSON("DELETE", "/api/v1/apps/test/domains/example.com", nil, nil)
+	assert.False(t, hasCert())
+
+	// Removed from config
+	s.mustJSON("POST", "/api/v1/apps/test/domains/example.com", nil, nil)
+	setCert()
+	s.SetAppConfig(testAppID, func(conf *config.AppConfig) {})
+	assert.False(t, hasCert())
+
+	// Replaced by other app
+	setConfig(testAppID)
+	s.mustJSON("POST", "/api/v1/apps/test/domains/example.com", nil, nil)
+	setCert()
+	s.mustJSON("POST", "/api/v1/apps", map[string]any{"id": "other"}, nil)
+	setupApp("other")
+	s.mustJSON("POST", "/api/v1/apps/other/domains/example.com?replaceApp=test", nil, nil)
+	assert.False(t, hasCert())
+}
@@ -7,11 +7,13 @@ import (
 type apiManifest struct {
 	Version             string `json:"version"`
 	CustomDomainMessage string `json:"customDomainMessage,omitempty"`
+	MaxDeploymentSize   int64  `json:"maxDeploymentSize,omitempty"`
 }
 
 func (c *Controller) handleManifest(w http.ResponseWriter, r *http.Request) {
 	writeResponse(w, &apiManifest{
 		Version:             c.Config.ServerVersion,
 		CustomDomainMessage: c.Config.CustomDomainMessage,
+		MaxDeploymentSize:   c.Config.MaxDeploymentSize,
 	}, nil)
 }
@@ -3,11 +3,13 @@ package controller
 import (
 	"context"
 	"net/http"
+	"reflect"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/deploy"
 	"github.com/oursky/pageship/internal/models"
 	"go.uber.org/zap"
 )
@@ -82,6 +84,11 @@ func (c *Controller) handleSiteCreate(w http.ResponseWriter, r *http.Request) {
 			zap.String("site", info.ID),
 			zap.String("name", info.Name))
 
+		err = c.audit(r, tx, app.ID, models.AuditSiteCreate, info.Name, nil, info.Site)
+		if err != nil {
+			return nil, err
+		}
+
 		return c.makeAPISite(app, *info), nil
 	}))
 }
@@ -159,6 +166,10 @@ func (c *Controller) siteUpdateDeploymentName(
 			return err
 		}
 
+		if err := checkDeploymentSignature(conf, site.Name, d); err != nil {
+			return err
+		}
+
 		site.DeploymentID = &d.ID
 		err = tx.SetSiteDeployment(ctx, site)
 		if err != nil {
@@ -188,6 +199,33 @@ func (c *Controller) siteUpdateDeploymentName(
 	return nil
 }
 
+// checkDeploymentSignature checks the deployment is signed by a trusted key,
+// if required by the site config.
+func checkDeploymentSignature(conf *config.AppConfig, siteName string, d *models.Deployment) error {
+	siteConf, ok := conf.ResolveSite(siteName)
+	if !ok || !siteConf.RequireSignature {
+		return nil
+	}
+
+	if d.Metadata.Signature == nil {
+		return models.ErrDeploymentNotSigned
+	}
+
+	digest, err := deploy.ManifestDigest(d.Metadata.Files, d.Metadata.HashAlgorithm, &d.Metadata.Config)
+	if err != nil {
+		return err
+	}
+	key, err := deploy.VerifyManifest(d.Metadata.Signature, digest)
+	if err != nil {
+		return models.ErrDeploymentUntrustedSignature
+	}
+
+	if !conf.IsTrustedKey(key) {
+		return models.ErrDeploymentUntrustedSignature
+	}
+	return nil
+}
+
 func (c *Controller) handleSiteUpdate(w http.ResponseWriter, r *http.Request) {
 	app := get[*models.App](r)
 	site := get[*models.Site](r)
@@ -202,6 +240,7 @@ func (c *Controller) handleSiteUpdate(w http.ResponseWriter, r *http.Request) {
 	now := c.Clock.Now().UTC()
 
 	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+		var updated *models.Site
 		if request.DeploymentName != nil {
 			oldDeployment := ""
 			if site.DeploymentID != nil {
@@ -214,16 +253,32 @@ func (c *Controller) handleSiteUpdate(w http.ResponseWriter, r *http.Request) {
 				zap.String("new_deployment", *request.DeploymentName),
 			)
 
+			before := *site
 			if err := c.siteUpdateDeploymentName(r.Context(), tx, now, app.Config, site, *request.DeploymentName); err != nil {
 				return nil, err
 			}
+
+			if !reflect.DeepEqual(before.DeploymentID, site.DeploymentID) {
+				err := c.audit(r, tx, app.ID, models.AuditSiteUpdate, site.Name, before, site)
+				if err != nil {
+					return nil, err
+				}
+				updated = &before
+			}
 		}
 
 		info, err := tx.GetSiteInfo(r.Context(), app.ID, site.ID)
 		if err != nil {
 			return nil, err
 		}
+		result := c.makeAPISite(app, *info)
 
-		return c.makeAPISite(app, *info), nil
+		if updated != nil {
+			if err := c.notifySiteUpdated(r, tx, app, updated, result); err != nil {
+				return nil, err
+			}
+		}
+
+		return result, nil
 	}))
 }
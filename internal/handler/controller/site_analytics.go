@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/oursky/pageship/internal/models"
+)
+
+const (
+	defaultAnalyticsDays = 30
+	maxAnalyticsDays     = 366
+	analyticsTopN        = 20
+)
+
+type apiSiteAnalytics struct {
+	Since     string                      `json:"since"`
+	Daily     []models.SiteAnalyticsDaily `json:"daily"`
+	Paths     []models.SiteAnalyticsEntry `json:"paths"`
+	Referrers []models.SiteAnalyticsEntry `json:"referrers"`
+	Statuses  []models.SiteAnalyticsEntry `json:"statuses"`
+}
+
+func (c *Controller) handleSiteAnalytics(w http.ResponseWriter, r *http.Request) {
+	site := get[*models.Site](r)
+
+	days := defaultAnalyticsDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxAnalyticsDays {
+			writeJSON(w, http.StatusBadRequest, response{
+				Error: fmt.Errorf("days must be between 1 and %d", maxAnalyticsDays),
+			})
+			return
+		}
+		days = n
+	}
+
+	respond(w, func() (any, error) {
+		since := models.SiteAnalyticsDate(c.Clock.Now().AddDate(0, 0, 1-days))
+
+		daily, err := c.DB.GetSiteAnalyticsDaily(r.Context(), site.AppID, site.Name, since)
+		if err != nil {
+			return nil, err
+		}
+
+		top := func(kind models.SiteAnalyticsKind) ([]models.SiteAnalyticsEntry, error) {
+			return c.DB.GetSiteAnalyticsTop(r.Context(), site.AppID, site.Name, kind, since, analyticsTopN)
+		}
+		paths, err := top(models.SiteAnalyticsPath)
+		if err != nil {
+			return nil, err
+		}
+		referrers, err := top(models.SiteAnalyticsReferrer)
+		if err != nil {
+			return nil, err
+		}
+		statuses, err := top(models.SiteAnalyticsStatus)
+		if err != nil {
+			return nil, err
+		}
+
+		return &apiSiteAnalytics{
+			Since:     since,
+			Daily:     daily,
+			Paths:     paths,
+			Referrers: referrers,
+			Statuses:  statuses,
+		}, nil
+	})
+}
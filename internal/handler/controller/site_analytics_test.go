@@ -0,0 +1,44 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oursky/pageship/internal/analytics"
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSiteAnalyticsVisitorOverflow(t *testing.T) {
+	s := newTestServer(t)
+	s.SetAppConfig(testAppID, func(conf *config.AppConfig) {
+		conf.Sites = []config.AppSiteConfig{{Name: "main"}}
+	})
+	s.mustJSON("POST", "/api/v1/apps/test/sites", map[string]any{"name": "main"}, nil)
+
+	c := &analytics.Collector{Clock: s.Clock, DB: s.DB}
+	html := http.Header{"Content-Type": []string{"text/html"}}
+
+	// Visitors beyond the limit of collector are counted as "(other)"
+	const maxVisitors = 100000
+	for i := 0; i < maxVisitors+10; i++ {
+		r := httptest.NewRequest("GET", "http://main.test.localhost/", nil)
+		r.RemoteAddr = fmt.Sprintf("10.%d.%d.%d:12345", i>>16&0xff, i>>8&0xff, i&0xff)
+		c.Record(testAppID, "main", r, "/", http.StatusOK, html)
+	}
+	if !assert.NoError(t, c.Flush(context.Background())) {
+		return
+	}
+
+	var result struct {
+		Daily []models.SiteAnalyticsDaily `json:"daily"`
+	}
+	s.mustJSON("GET", "/api/v1/apps/test/sites/main/analytics?days=1", nil, &result)
+	assert.Equal(t, []models.SiteAnalyticsDaily{
+		{Date: "2024-03-01", PageViews: maxVisitors + 10, Visitors: maxVisitors},
+	}, result.Daily)
+}
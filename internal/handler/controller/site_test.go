@@ -0,0 +1,91 @@
+package controller_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"testing"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func newSigner(t *testing.T) ssh.Signer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestSiteDeploymentSignature(t *testing.T) {
+	s := newTestServer(t)
+	trusted := newSigner(t)
+	untrusted := newSigner(t)
+
+	s.SetAppConfig(testAppID, func(conf *config.AppConfig) {
+		conf.Sites = []config.AppSiteConfig{
+			{Name: "main", RequireSignature: true},
+			{Name: "dev"},
+		}
+		conf.TrustedKeys = []string{string(ssh.MarshalAuthorizedKey(trusted.PublicKey()))}
+	})
+	s.mustJSON("POST", "/api/v1/apps/test/sites", map[string]any{"name": "main"}, nil)
+	s.mustJSON("POST", "/api/v1/apps/test/sites", map[string]any{"name": "dev"}, nil)
+
+	files := map[string]string{"index.html": "<html>hello</html>"}
+	for name, signer := range map[string]ssh.Signer{
+		"unsigned":  nil,
+		"untrusted": untrusted,
+		"trusted":   trusted,
+	} {
+		s.UploadDeployment(s.CreateDeployment(name, files, signer))
+	}
+
+	assign := func(site string, deployment string) (int, string) {
+		w := s.JSON("PATCH", "/api/v1/apps/test/sites/"+site, map[string]any{"deploymentName": deployment}, nil)
+		return w.Code, ErrorOf(w)
+	}
+
+	code, err := assign("main", "unsigned")
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, models.ErrDeploymentNotSigned.Error(), err)
+
+	code, err = assign("main", "untrusted")
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, models.ErrDeploymentUntrustedSignature.Error(), err)
+
+	code, _ = assign("main", "trusted")
+	assert.Equal(t, http.StatusOK, code)
+
+	// Signature is not required for other sites
+	code, _ = assign("dev", "unsigned")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = assign("dev", "untrusted")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestDeploymentCreateInvalidSignature(t *testing.T) {
+	s := newTestServer(t)
+	signer := newSigner(t)
+
+	// Signature of other manifest is rejected on creation
+	d := s.CreateDeployment("signed", map[string]string{"index.html": "a"}, signer)
+	var deployment models.Deployment
+	s.mustJSON("GET", "/api/v1/apps/test/deployments/"+d.Name, nil, &deployment)
+	assert.NotNil(t, deployment.Metadata.Signature)
+
+	w := s.JSON("POST", "/api/v1/apps/test/deployments", map[string]any{
+		"name":        "tampered",
+		"files":       append(d.Files, models.FileEntry{Path: "/public/extra.html", Size: 1, Hash: "x"}),
+		"site_config": config.DefaultSiteConfig(),
+		"signature":   deployment.Metadata.Signature,
+	}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -59,12 +59,26 @@ type response struct {
 
 func (r response) MarshalJSON() ([]byte, error) {
 	if r.Error != nil {
-		return json.Marshal(map[string]any{"error": r.Error.Error()})
+		value := map[string]any{"error": r.Error.Error()}
+		if code := errorCode(r.Error); code != "" {
+			value["code"] = code
+		}
+		return json.Marshal(value)
 	} else {
 		return json.Marshal(map[string]any{"result": r.Result})
 	}
 }
 
+// errorCode returns the machine-readable code of the error, for clients to
+// handle specific errors without matching messages.
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, models.ErrDomainNotVerified):
+		return models.ErrorCodeDomainNotVerified
+	}
+	return ""
+}
+
 func writeJSON(w http.ResponseWriter, statusCode int, value any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -101,12 +115,30 @@ func writeResponse(w http.ResponseWriter, result any, err error) {
 		writeJSON(w, http.StatusBadRequest, response{Error: err})
 	case errors.Is(err, models.ErrDeploymentExpired):
 		writeJSON(w, http.StatusBadRequest, response{Error: err})
+	case errors.Is(err, models.ErrDeploymentUploadOffset):
+		writeJSON(w, http.StatusConflict, response{Error: err})
+	case errors.Is(err, models.ErrDeploymentNotSigned):
+		writeJSON(w, http.StatusForbidden, response{Error: err})
+	case errors.Is(err, models.ErrDeploymentUntrustedSignature):
+		writeJSON(w, http.StatusForbidden, response{Error: err})
+	case errors.Is(err, models.ErrDeploymentActive):
+		writeJSON(w, http.StatusConflict, response{Error: err})
+	case errors.Is(err, models.ErrDeploymentNameIsSite):
+		writeJSON(w, http.StatusConflict, response{Error: err})
 	case errors.Is(err, models.ErrUndefinedDomain):
 		writeJSON(w, http.StatusBadRequest, response{Error: err})
 	case errors.Is(err, models.ErrDomainNotFound):
 		writeJSON(w, http.StatusNotFound, response{Error: err})
 	case errors.Is(err, models.ErrDomainUsedName):
 		writeJSON(w, http.StatusConflict, response{Error: err})
+	case errors.Is(err, models.ErrDomainNotVerified):
+		writeJSON(w, http.StatusForbidden, response{Error: err})
+	case errors.Is(err, models.ErrDomainVerificationNotFound):
+		writeJSON(w, http.StatusNotFound, response{Error: err})
+	case errors.Is(err, models.ErrTLSNotEnabled):
+		writeJSON(w, http.StatusBadRequest, response{Error: err})
+	case errors.Is(err, models.ErrInvalidCertificate):
+		writeJSON(w, http.StatusBadRequest, response{Error: err})
 	case errors.Is(err, models.ErrUserNotFound):
 		writeJSON(w, http.StatusNotFound, response{Error: err})
 	case errors.Is(err, models.ErrAccessDenied):
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/webhook"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultWebhookDeliveriesLimit = 50
+	maxWebhookDeliveriesLimit     = 500
+)
+
+type apiWebhookSecret struct {
+	Secret string `json:"secret"`
+}
+
+// notify queues deliveries of the event to subscribed webhooks of the app,
+// usually in the transaction of mutation so that events are delivered only
+// if the mutation is committed.
+func (c *Controller) notify(r *http.Request, q db.DBQuery, app *models.App, eventType config.WebhookEvent, data any, text string) error {
+	now := c.Clock.Now().UTC()
+	event := &webhook.Event{
+		ID:        models.NewWebhookEventID(),
+		Event:     eventType,
+		CreatedAt: now,
+		App:       app.ID,
+		Data:      data,
+		Text:      text,
+	}
+
+	for _, hook := range app.Config.Webhooks {
+		if !hook.Subscribes(eventType) {
+			continue
+		}
+
+		payload, err := event.Payload(hook.Format)
+		if err != nil {
+			return err
+		}
+
+		delivery := models.NewWebhookDelivery(now, app.ID, hook.URL, eventType, string(payload))
+		if err := q.CreateWebhookDelivery(r.Context(), delivery); err != nil {
+			return err
+		}
+
+		log(r).Debug("queued webhook delivery",
+			zap.String("delivery", delivery.ID),
+			zap.String("event", string(eventType)))
+	}
+	return nil
+}
+
+func (c *Controller) notifyDeploymentUploaded(r *http.Request, q db.DBQuery, app *models.App, deployment *apiDeployment) error {
+	text := fmt.Sprintf("Deployment `%s` of app `%s` is uploaded", deployment.Name, app.ID)
+	if deployment.URL != "" {
+		text += ": " + deployment.URL
+	}
+
+	return c.notify(r, q, app, config.WebhookEventDeploymentUploaded, &webhook.DeploymentUploadedData{
+		Deployment: webhook.Deployment{
+			ID:   deployment.ID,
+			Name: deployment.Name,
+			URL:  deployment.URL,
+		},
+	}, text)
+}
+
+func (c *Controller) notifySiteUpdated(r *http.Request, q db.DBQuery, app *models.App, before *models.Site, site *apiSite) error {
+	previous := ""
+	if before.DeploymentID != nil {
+		d, err := q.GetDeployment(r.Context(), app.ID, *before.DeploymentID)
+		if err != nil {
+			return err
+		}
+		previous = d.Name
+	}
+
+	current := ""
+	if site.DeploymentName != nil {
+		current = *site.DeploymentName
+	}
+
+	var text string
+	if current != "" {
+		text = fmt.Sprintf("Site `%s` of app `%s` is updated to deployment `%s`: %s", site.Name, app.ID, current, site.URL)
+	} else {
+		text = fmt.Sprintf("Site `%s` of app `%s` is unpublished", site.Name, app.ID)
+	}
+
+	return c.notify(r, q, app, config.WebhookEventSiteUpdated, &webhook.SiteUpdatedData{
+		Site: webhook.Site{
+			Name:           site.Name,
+			URL:            site.URL,
+			DeploymentName: current,
+		},
+		PreviousDeploymentName: previous,
+	}, text)
+}
+
+func (c *Controller) notifyDomainActivated(r *http.Request, q db.DBQuery, app *models.App, domain *models.Domain) error {
+	url := c.Config.HostPattern.LeadingScheme + domain.Domain
+	text := fmt.Sprintf("Domain `%s` of app `%s` is activated: %s", domain.Domain, app.ID, url)
+
+	return c.notify(r, q, app, config.WebhookEventDomainActivated, &webhook.DomainActivatedData{
+		Domain: webhook.Domain{
+			Domain: domain.Domain,
+			Site:   domain.SiteName,
+			URL:    url,
+		},
+	}, text)
+}
+
+func (c *Controller) handleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+
+	limit := defaultWebhookDeliveriesLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxWebhookDeliveriesLimit {
+			writeJSON(w, http.StatusBadRequest, response{
+				Error: fmt.Errorf("limit must be between 1 and %d", maxWebhookDeliveriesLimit),
+			})
+			return
+		}
+		limit = n
+	}
+
+	respond(w, func() (any, error) {
+		deliveries, err := c.DB.ListWebhookDeliveries(r.Context(), app.ID, limit)
+		if err != nil {
+			return nil, err
+		}
+		if deliveries == nil {
+			deliveries = []*models.WebhookDelivery{}
+		}
+		return deliveries, nil
+	})
+}
+
+func (c *Controller) handleWebhookSecretGet(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+
+	respond(w, func() (any, error) {
+		secret, err := c.DB.GetOrCreateWebhookSecret(r.Context(), app.ID, c.Clock.Now().UTC(), webhook.NewSecret())
+		if err != nil {
+			return nil, err
+		}
+		return &apiWebhookSecret{Secret: secret}, nil
+	})
+}
+
+func (c *Controller) handleWebhookSecretRotate(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+
+	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+		secret := webhook.NewSecret()
+		err := tx.SetWebhookSecret(r.Context(), app.ID, c.Clock.Now().UTC(), secret)
+		if err != nil {
+			return nil, err
+		}
+
+		log(r).Info("rotated webhook secret")
+
+		// Secret is never recorded.
+		err = c.audit(r, tx, app.ID, models.AuditWebhookSecretRotate, app.ID, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return &apiWebhookSecret{Secret: secret}, nil
+	}))
+}
@@ -9,12 +9,16 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/oursky/pageship/internal/accesslog"
+	"github.com/oursky/pageship/internal/analytics"
 	"github.com/oursky/pageship/internal/cache"
 	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/domain"
 	"github.com/oursky/pageship/internal/httputil"
 	"github.com/oursky/pageship/internal/models"
 	"github.com/oursky/pageship/internal/site"
+	"github.com/oursky/pageship/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -26,6 +30,13 @@ const (
 type HandlerConfig struct {
 	HostPattern string
 	Middlewares []Middleware
+	// AltSvc is the Alt-Svc header value sent in TLS responses, advertising
+	// alternative services (e.g. HTTP/3).
+	AltSvc string
+	// Analytics collects visitor analytics of sites if non-nil.
+	Analytics *analytics.Collector
+	// AccessLog exports access logs of apps if non-nil.
+	AccessLog *accesslog.Exporter
 }
 
 type Handler struct {
@@ -36,6 +47,9 @@ type Handler struct {
 	hostPattern    *config.HostPattern
 	cache          *cache.Cache[*SiteHandler]
 	middlewares    []Middleware
+	altSvc         string
+	analytics      *analytics.Collector
+	accessLog      *accesslog.Exporter
 }
 
 func NewHandler(ctx context.Context, logger *zap.Logger, domainResolver domain.Resolver, siteResolver site.Resolver, conf HandlerConfig) (*Handler, error) {
@@ -46,9 +60,12 @@ func NewHandler(ctx context.Context, logger *zap.Logger, domainResolver domain.R
 		siteResolver:   siteResolver,
 		hostPattern:    config.NewHostPattern(conf.HostPattern),
 		middlewares:    conf.Middlewares,
+		altSvc:         conf.AltSvc,
+		analytics:      conf.Analytics,
+		accessLog:      conf.AccessLog,
 	}
 
-	cache, err := cache.NewCache(cacheSize, cacheTTL, h.doResolveHandler)
+	cache, err := cache.NewCache("site", cacheSize, cacheTTL, h.doResolveHandler)
 	if err != nil {
 		return nil, fmt.Errorf("setup cache: %w", err)
 	}
@@ -57,18 +74,25 @@ func NewHandler(ctx context.Context, logger *zap.Logger, domainResolver domain.R
 	return h, nil
 }
 
-func (h *Handler) resolveHandler(host string) (*SiteHandler, error) {
-	return h.cache.Load(host)
+func (h *Handler) resolveHandler(ctx context.Context, host string) (handler *SiteHandler, err error) {
+	ctx, span := tracing.Start(ctx, "site.resolve", attribute.String("host", host))
+	defer func() { tracing.End(span, err) }()
+
+	return h.cache.Load(ctx, host)
 }
 
 func (h *Handler) ResolveSite(host string) (*site.Descriptor, error) {
+	return h.resolveSite(h.ctx, host)
+}
+
+func (h *Handler) resolveSite(ctx context.Context, host string) (*site.Descriptor, error) {
 	matchedID, ok := h.hostPattern.MatchString(host)
 	if !ok {
 		hostname, _, err := net.SplitHostPort(host)
 		if err != nil {
 			hostname = host
 		}
-		id, err := h.domainResolver.Resolve(h.ctx, hostname)
+		id, err := h.domainResolver.Resolve(ctx, hostname)
 		if errors.Is(err, domain.ErrDomainNotFound) {
 			return nil, site.ErrSiteNotFound
 		} else if err != nil {
@@ -77,7 +101,7 @@ func (h *Handler) ResolveSite(host string) (*site.Descriptor, error) {
 		matchedID = id
 	}
 
-	desc, err := h.siteResolver.Resolve(h.ctx, matchedID)
+	desc, err := h.siteResolver.Resolve(ctx, matchedID)
 	if err != nil {
 		return nil, err
 	}
@@ -85,8 +109,8 @@ func (h *Handler) ResolveSite(host string) (*site.Descriptor, error) {
 	return desc, nil
 }
 
-func (h *Handler) doResolveHandler(host string) (*SiteHandler, error) {
-	desc, err := h.ResolveSite(host)
+func (h *Handler) doResolveHandler(ctx context.Context, host string) (*SiteHandler, error) {
+	desc, err := h.resolveSite(ctx, host)
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +149,11 @@ func (h *Handler) checkAuthz(r *http.Request, handler *SiteHandler) error {
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	handler, err := h.resolveHandler(r.Host)
+	if r.TLS != nil && h.altSvc != "" {
+		w.Header().Set("Alt-Svc", h.altSvc)
+	}
+
+	handler, err := h.resolveHandler(r.Context(), r.Host)
 	if errors.Is(err, site.ErrSiteNotFound) {
 		http.NotFound(w, r)
 		return
@@ -138,11 +166,28 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.logger.Debug("resolved site", zap.String("site", handler.ID()))
 	entry := middleware.GetLogEntry(r).(*httputil.LogEntry)
 	entry.Logger = entry.Logger.With(zap.String("site", handler.ID()))
+	entry.App = handler.desc.App
+	entry.Site = handler.desc.Site
+	entry.AccessLog = h.accessLog
+	tracing.Annotate(r.Context(),
+		attribute.String("pageship.app", handler.desc.App),
+		attribute.String("pageship.site", handler.desc.Site),
+	)
 
 	if err := h.checkAuthz(r, handler); err != nil {
 		http.NotFound(w, r)
 		return
 	}
 
-	handler.ServeHTTP(w, r)
+	// Preview deployments are not counted.
+	if h.analytics == nil || handler.desc.Site == "" {
+		handler.ServeHTTP(w, r)
+		return
+	}
+
+	// Request path may be rewritten by site middlewares.
+	path := r.URL.Path
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	handler.ServeHTTP(ww, r)
+	h.analytics.Record(handler.desc.App, handler.desc.Site, r, path, ww.Status(), ww.Header())
 }
@@ -0,0 +1,70 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"time"
+
+	"github.com/caddyserver/certmagic"
+	"go.uber.org/zap"
+)
+
+// CertError records the last failure of obtaining/renewing certificate of a
+// domain; it is cleared when certificate is obtained successfully.
+type CertError struct {
+	Time    time.Time `json:"time"`
+	Renewal bool      `json:"renewal"`
+	Error   string    `json:"error"`
+}
+
+func CertErrorKey(domain string) string {
+	return "pageship/cert_errors/" + certmagic.StorageKeys.Safe(domain) + ".json"
+}
+
+func LoadCertError(ctx context.Context, storage certmagic.Storage, domain string) (*CertError, error) {
+	data, err := storage.Load(ctx, CertErrorKey(domain))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var certErr CertError
+	if err := json.Unmarshal(data, &certErr); err != nil {
+		return nil, err
+	}
+	return &certErr, nil
+}
+
+func (s *Server) handleCertEvent(ctx context.Context, event string, data map[string]any) error {
+	name, _ := data["identifier"].(string)
+	if name == "" {
+		return nil
+	}
+
+	switch event {
+	case "cert_failed":
+		certErr := CertError{Time: time.Now().UTC()}
+		certErr.Renewal, _ = data["renewal"].(bool)
+		if err, ok := data["error"].(error); ok {
+			certErr.Error = err.Error()
+		}
+
+		value, err := json.Marshal(certErr)
+		if err != nil {
+			return nil
+		}
+		if err := s.TLS.Storage.Store(ctx, CertErrorKey(name), value); err != nil {
+			s.Logger.Warn("failed to record cert error", zap.String("domain", name), zap.Error(err))
+		}
+
+	case "cert_obtained":
+		err := s.TLS.Storage.Delete(ctx, CertErrorKey(name))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			s.Logger.Warn("failed to clear cert error", zap.String("domain", name), zap.Error(err))
+		}
+	}
+	return nil
+}
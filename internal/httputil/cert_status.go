@@ -0,0 +1,56 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"time"
+
+	"github.com/caddyserver/certmagic"
+)
+
+const certStatusKey = "pageship/cert_status.json"
+
+// CertStatus is the status of a stored certificate, reported by certificate
+// monitor.
+type CertStatus struct {
+	Domain             string     `json:"domain"`
+	Issuer             string     `json:"issuer"`
+	Custom             bool       `json:"custom"`
+	NotAfter           time.Time  `json:"notAfter"`
+	Expiring           bool       `json:"expiring"`
+	Expired            bool       `json:"expired"`
+	RenewalAttemptedAt *time.Time `json:"renewalAttemptedAt,omitempty"`
+	RenewalError       string     `json:"renewalError,omitempty"`
+}
+
+type CertReport struct {
+	CheckedAt    time.Time    `json:"checkedAt"`
+	Certificates []CertStatus `json:"certificates"`
+}
+
+func StoreCertReport(ctx context.Context, storage certmagic.Storage, report *CertReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return storage.Store(ctx, certStatusKey, data)
+}
+
+// LoadCertReport loads the last certificate report; nil is returned if
+// certificates are not checked yet.
+func LoadCertReport(ctx context.Context, storage certmagic.Storage) (*CertReport, error) {
+	data, err := storage.Load(ctx, certStatusKey)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var report CertReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
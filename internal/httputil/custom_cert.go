@@ -0,0 +1,211 @@
+package httputil
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caddyserver/certmagic"
+	"go.uber.org/zap"
+)
+
+const CustomCertPrefix = "pageship/custom_certs"
+
+const (
+	customCertsInterval   = 1 * time.Minute
+	customCertExpiryWarn  = 14 * 24 * time.Hour
+	customCertWarnBackoff = 24 * time.Hour
+)
+
+var ErrCustomCertExpired = errors.New("certificate is expired")
+
+// CustomCertKey returns the storage key of certificate uploaded for the
+// domain; the certificate chain and private key are stored in a PEM bundle.
+func CustomCertKey(domain string) string {
+	return CustomCertPrefix + "/" + certmagic.StorageKeys.Safe(domain) + ".pem"
+}
+
+// ParseCustomCert parses and validates the uploaded PEM certificate chain and
+// private key for the domain.
+func ParseCustomCert(domain string, certPEM []byte, keyPEM []byte, now time.Time) (*tls.Certificate, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	cert.Leaf = leaf
+
+	if strings.HasPrefix(domain, "*.") {
+		if !containsName(leaf.DNSNames, domain) {
+			return nil, fmt.Errorf("certificate is not valid for %s", domain)
+		}
+	} else if err := leaf.VerifyHostname(domain); err != nil {
+		return nil, err
+	}
+
+	if now.After(leaf.NotAfter) {
+		return nil, ErrCustomCertExpired
+	}
+	return &cert, nil
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func StoreCustomCert(ctx context.Context, storage certmagic.Storage, domain string, certPEM []byte, keyPEM []byte) error {
+	var bundle bytes.Buffer
+	bundle.Write(bytes.TrimSpace(certPEM))
+	bundle.WriteString("\n")
+	bundle.Write(bytes.TrimSpace(keyPEM))
+	bundle.WriteString("\n")
+	return storage.Store(ctx, CustomCertKey(domain), bundle.Bytes())
+}
+
+// LoadCustomCert loads the certificate uploaded for the domain; nil is
+// returned if no certificate is uploaded.
+func LoadCustomCert(ctx context.Context, storage certmagic.Storage, domain string) (*x509.Certificate, error) {
+	data, err := storage.Load(ctx, CustomCertKey(domain))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	cert, err := tls.X509KeyPair(data, data)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(cert.Certificate[0])
+}
+
+func DeleteCustomCert(ctx context.Context, storage certmagic.Storage, domain string) error {
+	err := storage.Delete(ctx, CustomCertKey(domain))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// customCerts serves uploaded certificates in preference to certificates
+// managed by certmagic; certificates are reloaded from storage periodically.
+type customCerts struct {
+	logger  *zap.Logger
+	storage certmagic.Storage
+
+	lock   sync.RWMutex
+	certs  map[string]*tls.Certificate
+	warned map[string]time.Time
+}
+
+func newCustomCerts(logger *zap.Logger, storage certmagic.Storage) *customCerts {
+	return &customCerts{
+		logger:  logger,
+		storage: storage,
+		certs:   make(map[string]*tls.Certificate),
+		warned:  make(map[string]time.Time),
+	}
+}
+
+func (c *customCerts) get(serverName string) *tls.Certificate {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	name := strings.ToLower(serverName)
+	if cert, ok := c.certs[certmagic.StorageKeys.Safe(name)]; ok {
+		return cert
+	}
+	if _, parent, ok := strings.Cut(name, "."); ok {
+		if cert, ok := c.certs[certmagic.StorageKeys.Safe("*."+parent)]; ok {
+			return cert
+		}
+	}
+	return nil
+}
+
+func (c *customCerts) load(ctx context.Context) error {
+	keys, err := c.storage.List(ctx, CustomCertPrefix, false)
+	if errors.Is(err, fs.ErrNotExist) {
+		keys = nil
+	} else if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	certs := make(map[string]*tls.Certificate)
+	for _, key := range keys {
+		// Storages may list keys relative to prefix, or as full keys.
+		key = strings.TrimPrefix(key, CustomCertPrefix+"/")
+		name, ok := strings.CutSuffix(key, ".pem")
+		if !ok {
+			continue
+		}
+
+		data, err := c.storage.Load(ctx, CustomCertPrefix+"/"+key)
+		if err != nil {
+			c.logger.Warn("failed to load custom certificate", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		cert, err := tls.X509KeyPair(data, data)
+		if err != nil {
+			c.logger.Warn("invalid custom certificate", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			c.logger.Warn("invalid custom certificate", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		cert.Leaf = leaf
+
+		if expiry := leaf.NotAfter.Sub(now); expiry < customCertExpiryWarn {
+			if last, ok := c.warned[name]; !ok || now.Sub(last) > customCertWarnBackoff {
+				c.logger.Warn("custom certificate expiring",
+					zap.String("name", name),
+					zap.Strings("dns_names", leaf.DNSNames),
+					zap.Time("not_after", leaf.NotAfter))
+				c.warned[name] = now
+			}
+		}
+		if now.After(leaf.NotAfter) {
+			// Fallback to managed certificate.
+			continue
+		}
+		certs[name] = &cert
+	}
+
+	c.lock.Lock()
+	c.certs = certs
+	c.lock.Unlock()
+	return nil
+}
+
+func (c *customCerts) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(customCertsInterval):
+		}
+
+		if err := c.load(ctx); err != nil {
+			c.logger.Warn("failed to load custom certificates", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package httputil_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/httputil"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeCert(t *testing.T, names []string, notAfter time.Time) (certPEM []byte, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return
+}
+
+func TestParseCustomCert(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	certPEM, keyPEM := makeCert(t, []string{"example.com", "*.preview.example.com"}, now.Add(30*24*time.Hour))
+
+	cert, err := httputil.ParseCustomCert("example.com", certPEM, keyPEM, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", cert.Leaf.Subject.CommonName)
+
+	_, err = httputil.ParseCustomCert("*.preview.example.com", certPEM, keyPEM, now)
+	assert.NoError(t, err)
+
+	_, err = httputil.ParseCustomCert("other.com", certPEM, keyPEM, now)
+	assert.Error(t, err)
+
+	_, err = httputil.ParseCustomCert("example.com", certPEM, keyPEM, now.Add(60*24*time.Hour))
+	assert.ErrorIs(t, err, httputil.ErrCustomCertExpired)
+
+	_, otherKeyPEM := makeCert(t, []string{"example.com"}, now.Add(30*24*time.Hour))
+	_, err = httputil.ParseCustomCert("example.com", certPEM, otherKeyPEM, now)
+	assert.Error(t, err)
+}
@@ -5,12 +5,20 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/oursky/pageship/internal/accesslog"
+	"github.com/oursky/pageship/internal/metrics"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 type LogEntry struct {
-	Logger  *zap.Logger
-	request *http.Request
+	Logger *zap.Logger
+	// App & Site label the request metrics; empty if not resolved.
+	App  string
+	Site string
+	// AccessLog exports access log of the app if non-nil.
+	AccessLog *accesslog.Exporter
+	request   *http.Request
 }
 
 func (l *LogEntry) Panic(v interface{}, stack []byte) {
@@ -37,12 +45,37 @@ func (l *LogEntry) Write(status int, bytes int, header http.Header, elapsed time
 		zap.Int("status", status),
 		zap.Duration("elapsed", elapsed),
 	)
+	metrics.ObserveRequest(l.App, l.Site, status, bytes, elapsed)
+
+	if l.AccessLog != nil && l.App != "" {
+		if status == 0 {
+			status = http.StatusOK
+		}
+		l.AccessLog.Write(l.App, &accesslog.Entry{
+			RequestID: middleware.GetReqID(l.request.Context()),
+			Site:      l.Site,
+			Host:      l.request.Host,
+			Method:    l.request.Method,
+			URI:       l.request.RequestURI,
+			Proto:     l.request.Proto,
+			Status:    status,
+			Bytes:     bytes,
+			Remote:    l.request.RemoteAddr,
+			Referer:   l.request.Referer(),
+			UserAgent: l.request.UserAgent(),
+			Elapsed:   elapsed.Seconds(),
+		})
+	}
 }
 
 type LogFormatter struct{ *zap.Logger }
 
 func (f LogFormatter) NewLogEntry(r *http.Request) middleware.LogEntry {
-	return &LogEntry{Logger: f.Logger.With(
+	logger := f.Logger.With(
 		zap.String("request_id", middleware.GetReqID(r.Context())),
-	), request: r}
+	)
+	if span := trace.SpanContextFromContext(r.Context()); span.IsValid() {
+		logger = logger.With(zap.String("trace_id", span.TraceID().String()))
+	}
+	return &LogEntry{Logger: logger, request: r}
 }
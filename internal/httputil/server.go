@@ -3,15 +3,25 @@ package httputil
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/caddyserver/certmagic"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/mholt/acmez"
+	"github.com/mholt/acmez/acme"
+	"github.com/oursky/pageship/internal/cache"
+	"github.com/oursky/pageship/internal/dnsprovider"
+	"github.com/oursky/pageship/internal/tracing"
+	"github.com/quic-go/quic-go/http3"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -23,16 +33,138 @@ type ServerTLSConfig struct {
 	Addr          string
 	DomainNames   []string
 	CheckDomain   func(name string) error
+
+	// ACMEEABKeyID & ACMEEABHMACKey are the External Account Binding
+	// credentials, required by some CAs.
+	ACMEEABKeyID   string
+	ACMEEABHMACKey string
+	// ACMEChallenges are the enabled ACME challenge types; default to HTTP-01
+	// & TLS-ALPN-01. DNS-01 challenge requires DNSProvider, and cannot be
+	// combined with other challenge types.
+	ACMEChallenges []string
+
+	// DNSProvider manages challenge records of ACME DNS-01 challenge; it also
+	// enables issuing wildcard certificates for names returned by
+	// WildcardDomains.
+	DNSProvider     dnsprovider.Provider
+	WildcardDomains func(ctx context.Context) ([]string, error)
+
+	// HTTP3 enables serving HTTP/3 over QUIC on the UDP port of Addr.
+	HTTP3 bool
+
+	// MinVersion & CipherSuites restrict the accepted TLS versions & cipher
+	// suites; default to Go defaults.
+	MinVersion   uint16
+	CipherSuites []uint16
+	// ClientAuth is the policy of client certificates, verified using
+	// ClientCAs.
+	ClientAuth tls.ClientAuthType
+	ClientCAs  *x509.CertPool
+	// HSTSMaxAge enables Strict-Transport-Security header in TLS responses if
+	// positive.
+	HSTSMaxAge time.Duration
+}
+
+// AltSvc returns the Alt-Svc header value advertising the HTTP/3 listener,
+// or empty if HTTP/3 is not enabled.
+func (c *ServerTLSConfig) AltSvc() string {
+	if c == nil || !c.HTTP3 {
+		return ""
+	}
+	_, portStr, err := net.SplitHostPort(c.Addr)
+	if err != nil {
+		return ""
+	}
+	port, err := net.LookupPort("udp", portStr)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf(`h3=":%d"; ma=86400`, port)
+}
+
+var DefaultACMEChallenges = []string{acme.ChallengeTypeHTTP01, acme.ChallengeTypeTLSALPN01}
+
+func (c *ServerTLSConfig) acmeChallenges() (map[string]bool, error) {
+	challenges := c.ACMEChallenges
+	if len(challenges) == 0 {
+		challenges = DefaultACMEChallenges
+	}
+
+	enabled := make(map[string]bool)
+	for _, c := range challenges {
+		switch c {
+		case acme.ChallengeTypeHTTP01, acme.ChallengeTypeTLSALPN01, acme.ChallengeTypeDNS01:
+			enabled[c] = true
+		default:
+			return nil, fmt.Errorf("unsupported ACME challenge type: %q", c)
+		}
+	}
+
+	if enabled[acme.ChallengeTypeDNS01] {
+		if c.DNSProvider == nil {
+			return nil, errors.New("DNS provider is required for DNS-01 challenge")
+		}
+		if len(enabled) > 1 {
+			return nil, errors.New("DNS-01 challenge cannot be combined with other challenge types")
+		}
+	}
+	return enabled, nil
+}
+
+func (c *ServerTLSConfig) acmeCA() string {
+	if c.ACMEDirectory != "" {
+		return c.ACMEDirectory
+	}
+	return certmagic.LetsEncryptProductionCA
+}
+
+// IssuerKey returns the key of ACME issuer, under which issued certificates
+// are kept in storage.
+func (c *ServerTLSConfig) IssuerKey() string {
+	issuer := &certmagic.ACMEIssuer{CA: c.acmeCA()}
+	return issuer.IssuerKey()
 }
 
+func (s *Server) newACMEIssuer(magic *certmagic.Config, challenges map[string]bool) *certmagic.ACMEIssuer {
+	issuer := certmagic.ACMEIssuer{
+		Logger:                  zap.NewNop(),
+		CA:                      s.TLS.acmeCA(),
+		Email:                   s.TLS.ACMEEmail,
+		Agreed:                  true,
+		DisableHTTPChallenge:    !challenges[acme.ChallengeTypeHTTP01],
+		DisableTLSALPNChallenge: !challenges[acme.ChallengeTypeTLSALPN01],
+	}
+	if challenges[acme.ChallengeTypeDNS01] {
+		issuer.DNS01Solver = &dnsprovider.Solver{Provider: s.TLS.DNSProvider}
+	}
+	if s.TLS.ACMEEABKeyID != "" {
+		issuer.ExternalAccount = &acme.EAB{
+			KeyID:  s.TLS.ACMEEABKeyID,
+			MACKey: s.TLS.ACMEEABHMACKey,
+		}
+	}
+	return certmagic.NewACMEIssuer(magic, issuer)
+}
+
+const wildcardDomainsInterval = 1 * time.Minute
+
+// customCertDomainTTL is the duration of cached check of whether domain of
+// uploaded certificate is still managed.
+const customCertDomainTTL = 10 * time.Second
+
 type Server struct {
 	Logger  *zap.Logger
 	Addr    string
 	Handler http.Handler
 
 	TLS *ServerTLSConfig
+
+	magic         atomic.Pointer[certmagic.Config]
+	wildcardMagic atomic.Pointer[certmagic.Config]
 }
 
+var ErrCertNotManaged = errors.New("certificate is not managed by server")
+
 func (s *Server) makeServer(handler http.Handler) *http.Server {
 	return &http.Server{
 		ErrorLog:          zap.NewStdLog(s.Logger),
@@ -73,51 +205,108 @@ func (s *Server) serveTLS(ctx context.Context, server *http.Server) error {
 	return nil
 }
 
+func (s *Server) serveHTTP3(ctx context.Context, server *http3.Server) error {
+	conn, err := net.ListenPacket("udp", s.TLS.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	s.Logger.Info("http3 server starting", zap.String("addr", conn.LocalAddr().String()))
+	err = server.Serve(conn)
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to start http3 server: %w", err)
+	}
+	return nil
+}
+
 func (s *Server) buildHandler(handler http.Handler) http.Handler {
 	middlewares := chi.Chain(
 		RequestId,
 		middleware.RequestLogger(LogFormatter{Logger: s.Logger}),
 		middleware.Recoverer,
 	)
-	return middlewares.Handler(handler)
+	return tracing.Handler(middlewares.Handler(handler), "http")
 }
 
 func (s *Server) setupTLS(ctx context.Context, httpHandler *http.Handler) (*http.Server, error) {
+	challenges, err := s.TLS.acmeChallenges()
+	if err != nil {
+		return nil, err
+	}
+
 	magic := &certmagic.Config{
 		Storage: s.TLS.Storage,
 		Logger:  s.Logger.Named("cert"),
+		OnEvent: s.handleCertEvent,
 		OnDemand: &certmagic.OnDemandConfig{
 			DecisionFunc: s.TLS.CheckDomain,
 		},
 	}
 
-	cache := certmagic.NewCache(certmagic.CacheOptions{
+	var wildcardMagic *certmagic.Config
+
+	certCache := certmagic.NewCache(certmagic.CacheOptions{
 		Logger: zap.NewNop(),
 		GetConfigForCert: func(cert certmagic.Certificate) (*certmagic.Config, error) {
+			if wildcardMagic != nil && isWildcardCert(cert) {
+				return wildcardMagic, nil
+			}
 			return magic, nil
 		},
 	})
-	magic = certmagic.New(cache, *magic)
+	magic = certmagic.New(certCache, *magic)
 
-	ca := certmagic.LetsEncryptProductionCA
-	if s.TLS.ACMEDirectory != "" {
-		ca = s.TLS.ACMEDirectory
-	}
-	issuer := certmagic.NewACMEIssuer(magic, certmagic.ACMEIssuer{
-		Logger: zap.NewNop(),
-		CA:     ca,
-		Email:  s.TLS.ACMEEmail,
-		Agreed: true,
-	})
+	issuer := s.newACMEIssuer(magic, challenges)
 	magic.Issuers = []certmagic.Issuer{issuer}
 
 	if err := magic.ManageAsync(ctx, s.TLS.DomainNames); err != nil {
 		return nil, err
 	}
 
+	if s.TLS.DNSProvider != nil && s.TLS.WildcardDomains != nil {
+		// Wildcard certificates are managed separately, since on-demand
+		// issuance cannot use DNS-01 challenge.
+		wildcardMagic = certmagic.New(certCache, certmagic.Config{
+			Storage: s.TLS.Storage,
+			Logger:  s.Logger.Named("cert"),
+			OnEvent: s.handleCertEvent,
+		})
+		wildcardMagic.Issuers = []certmagic.Issuer{
+			s.newACMEIssuer(wildcardMagic, map[string]bool{acme.ChallengeTypeDNS01: true}),
+		}
+		go s.manageWildcardDomains(ctx, wildcardMagic)
+	}
+	s.magic.Store(magic)
+	s.wildcardMagic.Store(wildcardMagic)
+
+	customCerts := newCustomCerts(s.Logger.Named("cert"), s.TLS.Storage)
+	if err := customCerts.load(ctx); err != nil {
+		s.Logger.Warn("failed to load custom certificates", zap.Error(err))
+	}
+	go customCerts.run(ctx)
+
+	// Domain checks may query database; avoid doing so on every handshake.
+	customCertDomains, err := cache.NewCache("custom-cert-domain", 100, customCertDomainTTL,
+		func(ctx context.Context, domain string) (bool, error) {
+			return s.isManagedDomain(ctx, domain), nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
 	tlsConf := magic.TLSConfig()
 	tlsConf.NextProtos = append([]string{"h2", "http/1.1"}, tlsConf.NextProtos...)
 	tlsConf.GetCertificate = func(chi *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		// Prefer uploaded certificate over managed certificate; certificates
+		// of deactivated domains are not served, even if not yet deleted.
+		if cert := customCerts.get(chi.ServerName); cert != nil {
+			if managed, _ := customCertDomains.Load(chi.Context(), chi.ServerName); managed {
+				return cert, nil
+			}
+		}
+
 		// Don't timeout when handling certificate
 		chi.Conn.SetReadDeadline(time.Time{})
 		chi.Conn.SetWriteDeadline(time.Time{})
@@ -131,7 +320,29 @@ func (s *Server) setupTLS(ctx context.Context, httpHandler *http.Handler) (*http
 		return cert, err
 	}
 
-	server := s.makeServer(*httpHandler)
+	tlsConf.MinVersion = s.TLS.MinVersion
+	tlsConf.CipherSuites = s.TLS.CipherSuites
+	tlsConf.ClientAuth = s.TLS.ClientAuth
+	tlsConf.ClientCAs = s.TLS.ClientCAs
+	if tlsConf.ClientAuth != tls.NoClientCert {
+		// ACME CA does not present client certificate in TLS-ALPN-01
+		// challenge.
+		acmeConf := tlsConf.Clone()
+		acmeConf.ClientAuth = tls.NoClientCert
+		tlsConf.GetConfigForClient = func(chi *tls.ClientHelloInfo) (*tls.Config, error) {
+			if slices.Contains(chi.SupportedProtos, acmez.ACMETLS1Protocol) {
+				return acmeConf, nil
+			}
+			return nil, nil
+		}
+	}
+
+	handler := *httpHandler
+	if s.TLS.HSTSMaxAge > 0 {
+		handler = hsts(s.TLS.HSTSMaxAge, handler)
+	}
+
+	server := s.makeServer(handler)
 	server.TLSConfig = tlsConf
 	*httpHandler = s.buildHandler(
 		issuer.HTTPChallengeHandler(http.HandlerFunc(redirectToHTTPS)),
@@ -140,6 +351,91 @@ func (s *Server) setupTLS(ctx context.Context, httpHandler *http.Handler) (*http
 	return server, nil
 }
 
+// RenewCertificate renews the managed certificate of the domain if it is
+// due for renewal.
+func (s *Server) RenewCertificate(ctx context.Context, domain string) error {
+	magic := s.magic.Load()
+	if strings.HasPrefix(domain, "*.") {
+		magic = s.wildcardMagic.Load()
+	}
+	if magic == nil || !s.isManagedDomain(ctx, domain) {
+		return ErrCertNotManaged
+	}
+	return magic.RenewCertSync(ctx, domain, false)
+}
+
+func (s *Server) isManagedDomain(ctx context.Context, domain string) bool {
+	if strings.HasPrefix(domain, "*.") {
+		if s.TLS.WildcardDomains == nil {
+			return false
+		}
+		names, err := s.TLS.WildcardDomains(ctx)
+		if err != nil {
+			return false
+		}
+		return containsName(names, domain)
+	}
+
+	if containsName(s.TLS.DomainNames, domain) {
+		return true
+	}
+	return s.TLS.CheckDomain != nil && s.TLS.CheckDomain(domain) == nil
+}
+
+func isWildcardCert(cert certmagic.Certificate) bool {
+	for _, name := range cert.Names {
+		if strings.HasPrefix(name, "*.") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) manageWildcardDomains(ctx context.Context, magic *certmagic.Config) {
+	managed := make(map[string]struct{})
+	for {
+		names, err := s.TLS.WildcardDomains(ctx)
+		if err != nil {
+			s.Logger.Warn("failed to list wildcard domains", zap.Error(err))
+		} else {
+			current := make(map[string]struct{})
+			var added, removed []string
+			for _, name := range names {
+				current[name] = struct{}{}
+				if _, ok := managed[name]; !ok {
+					added = append(added, name)
+				}
+			}
+			for name := range managed {
+				if _, ok := current[name]; !ok {
+					removed = append(removed, name)
+				}
+			}
+
+			if len(removed) > 0 {
+				s.Logger.Info("unmanaging wildcard domains", zap.Strings("domains", removed))
+				magic.Unmanage(removed)
+			}
+			if len(added) > 0 {
+				s.Logger.Info("managing wildcard domains", zap.Strings("domains", added))
+				if err := magic.ManageAsync(ctx, added); err != nil {
+					s.Logger.Warn("failed to manage wildcard domains", zap.Error(err))
+					for _, name := range added {
+						delete(current, name)
+					}
+				}
+			}
+			managed = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wildcardDomainsInterval):
+		}
+	}
+}
+
 func (s *Server) Run(ctx context.Context) error {
 	httpHandler := s.buildHandler(s.Handler)
 
@@ -153,6 +449,16 @@ func (s *Server) Run(ctx context.Context) error {
 		tlsServer = server
 	}
 
+	var h3Server *http3.Server
+	if tlsServer != nil && s.TLS.HTTP3 {
+		h3Server = &http3.Server{
+			Addr:           s.TLS.Addr,
+			Handler:        tlsServer.Handler,
+			TLSConfig:      tlsServer.TLSConfig,
+			MaxHeaderBytes: tlsServer.MaxHeaderBytes,
+		}
+	}
+
 	httpServer := s.makeServer(httpHandler)
 
 	shutdown := make(chan struct{})
@@ -168,6 +474,9 @@ func (s *Server) Run(ctx context.Context) error {
 		if tlsServer != nil {
 			g.Go(func() error { return tlsServer.Shutdown(ctx) })
 		}
+		if h3Server != nil {
+			g.Go(func() error { return h3Server.Close() })
+		}
 		g.Wait()
 		close(shutdown)
 	}()
@@ -177,6 +486,9 @@ func (s *Server) Run(ctx context.Context) error {
 	if tlsServer != nil {
 		g.Go(func() error { return s.serveTLS(ctx, tlsServer) })
 	}
+	if h3Server != nil {
+		g.Go(func() error { return s.serveHTTP3(ctx, h3Server) })
+	}
 	err := g.Wait()
 	if err != nil {
 		s.Logger.Error("failed to start server", zap.Error(err))
@@ -0,0 +1,32 @@
+package httputil_test
+
+import (
+	"testing"
+
+	"github.com/oursky/pageship/internal/httputil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerTLSConfigAltSvc(t *testing.T) {
+	altSvc := func(addr string, http3 bool) string {
+		conf := &httputil.ServerTLSConfig{Addr: addr, HTTP3: http3}
+		return conf.AltSvc()
+	}
+
+	assert.Equal(t, `h3=":443"; ma=86400`, altSvc(":443", true))
+	assert.Equal(t, `h3=":8443"; ma=86400`, altSvc("127.0.0.1:8443", true))
+	assert.Equal(t, `h3=":443"; ma=86400`, altSvc(":https", true))
+	assert.Equal(t, "", altSvc(":443", false))
+	assert.Equal(t, "", altSvc("invalid", true))
+
+	var conf *httputil.ServerTLSConfig
+	assert.Equal(t, "", conf.AltSvc())
+}
+
+func TestServerTLSConfigIssuerKey(t *testing.T) {
+	conf := &httputil.ServerTLSConfig{}
+	assert.Equal(t, "acme-v02.api.letsencrypt.org-directory", conf.IssuerKey())
+
+	conf = &httputil.ServerTLSConfig{ACMEDirectory: "https://acme.example.com/acme/directory"}
+	assert.Equal(t, "acme.example.com-acme-directory", conf.IssuerKey())
+}
@@ -0,0 +1,98 @@
+package httputil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion parses TLS version of form '1.2'; empty value is parsed as
+// zero (i.e. Go default).
+func ParseTLSVersion(value string) (uint16, error) {
+	if value == "" {
+		return 0, nil
+	}
+	version, ok := tlsVersions[value]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version: %s", value)
+	}
+	return version, nil
+}
+
+// ParseCipherSuites parses cipher suite names (e.g.
+// 'TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256'); only secure cipher suites are
+// accepted. Empty list is parsed as nil (i.e. Go default).
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	suites := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		suites[s.Name] = s.ID
+	}
+
+	var ids []uint16
+	for _, name := range names {
+		id, ok := suites[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite: %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":     tls.NoClientCert,
+	"optional": tls.VerifyClientCertIfGiven,
+	"require":  tls.RequireAndVerifyClientCert,
+}
+
+// ParseClientAuth parses client certificate requirement: 'none', 'optional'
+// or 'require'; empty value is parsed as 'none'.
+func ParseClientAuth(value string) (tls.ClientAuthType, error) {
+	if value == "" {
+		return tls.NoClientCert, nil
+	}
+	auth, ok := clientAuthTypes[value]
+	if !ok {
+		return tls.NoClientCert, fmt.Errorf("unknown client auth type: %s", value)
+	}
+	return auth, nil
+}
+
+// LoadCertPool loads PEM-encoded CA certificates from file.
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+func hsts(maxAge time.Duration, next http.Handler) http.Handler {
+	value := "max-age=" + strconv.FormatInt(int64(maxAge/time.Second), 10)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			w.Header().Set("Strict-Transport-Security", value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
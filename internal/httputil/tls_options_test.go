@@ -0,0 +1,64 @@
+package httputil_test
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/oursky/pageship/internal/httputil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	version, err := httputil.ParseTLSVersion("")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0), version)
+
+	version, err = httputil.ParseTLSVersion("1.2")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), version)
+
+	version, err = httputil.ParseTLSVersion("1.3")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), version)
+
+	_, err = httputil.ParseTLSVersion("2.0")
+	assert.Error(t, err)
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	suites, err := httputil.ParseCipherSuites(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, suites)
+
+	suites, err = httputil.ParseCipherSuites([]string{
+		"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+		"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+	}, suites)
+
+	_, err = httputil.ParseCipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"})
+	assert.Error(t, err)
+	_, err = httputil.ParseCipherSuites([]string{"unknown"})
+	assert.Error(t, err)
+}
+
+func TestParseClientAuth(t *testing.T) {
+	auth, err := httputil.ParseClientAuth("")
+	assert.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, auth)
+
+	auth, err = httputil.ParseClientAuth("optional")
+	assert.NoError(t, err)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, auth)
+
+	auth, err = httputil.ParseClientAuth("require")
+	assert.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, auth)
+
+	_, err = httputil.ParseClientAuth("always")
+	assert.Error(t, err)
+}
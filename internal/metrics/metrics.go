@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pageship"
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests served.",
+	}, []string{"app", "site", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"app", "site", "status"})
+
+	responseBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_response_bytes_total",
+		Help:      "Number of response body bytes served.",
+	}, []string{"app", "site"})
+
+	cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Number of cache lookups by result (hit/miss).",
+	}, []string{"cache", "result"})
+
+	storageReadDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_read_duration_seconds",
+		Help:      "Latency of opening objects for read from object storage.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	uploadBytes = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "deployment_upload_bytes",
+		Help:      "Total file size of uploaded deployments.",
+		Buckets:   prometheus.ExponentialBuckets(64*1024, 4, 10),
+	}, []string{"method"})
+
+	uploadDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "deployment_upload_duration_seconds",
+		Help:      "Duration from deployment creation to upload completion.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 12),
+	}, []string{"method"})
+
+	cronJobs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cron_jobs_total",
+		Help:      "Number of cron job runs by result (success/failure/panic).",
+	}, []string{"job", "result"})
+
+	cronJobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cron_job_duration_seconds",
+		Help:      "Duration of cron job runs.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 4, 10),
+	}, []string{"job"})
+
+	certificates = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "certificates",
+		Help:      "Number of stored certificates by kind (managed/custom) and state (valid/expiring/expired), as of last check.",
+	}, []string{"kind", "state"})
+
+	certRenewals = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "certificate_renewals_total",
+		Help:      "Number of certificate renewal attempts by result (success/failure).",
+	}, []string{"result"})
+)
+
+// Handler serves the metrics in Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveRequest records a served HTTP request; app & site are empty if not
+// applicable (e.g. unknown site).
+func ObserveRequest(app string, site string, status int, bytes int, elapsed time.Duration) {
+	if status == 0 {
+		// Nothing is written by handler; net/http responds with 200.
+		status = http.StatusOK
+	}
+	statusLabel := strconv.Itoa(status)
+	requestsTotal.WithLabelValues(app, site, statusLabel).Inc()
+	requestDuration.WithLabelValues(app, site, statusLabel).Observe(elapsed.Seconds())
+	responseBytes.WithLabelValues(app, site).Add(float64(bytes))
+}
+
+// ObserveCache records a cache lookup.
+func ObserveCache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// ObserveStorageRead records latency of opening an object for read.
+func ObserveStorageRead(elapsed time.Duration) {
+	storageReadDuration.Observe(elapsed.Seconds())
+}
+
+// ObserveUpload records a completed deployment upload.
+func ObserveUpload(method string, size int64, elapsed time.Duration) {
+	uploadBytes.WithLabelValues(method).Observe(float64(size))
+	uploadDuration.WithLabelValues(method).Observe(elapsed.Seconds())
+}
+
+// ObserveCronJob records outcome of a cron job run.
+func ObserveCronJob(job string, result string, elapsed time.Duration) {
+	cronJobs.WithLabelValues(job, result).Inc()
+	cronJobDuration.WithLabelValues(job).Observe(elapsed.Seconds())
+}
+
+// SetCertificates sets number of stored certificates of kind & state.
+func SetCertificates(kind string, state string, n int) {
+	certificates.WithLabelValues(kind, state).Set(float64(n))
+}
+
+// ObserveCertRenewal records a certificate renewal attempt.
+func ObserveCertRenewal(err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	certRenewals.WithLabelValues(result).Inc()
+}
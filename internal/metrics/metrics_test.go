@@ -0,0 +1,79 @@
+package metrics_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/cache"
+	"github.com/oursky/pageship/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func counterValue(t *testing.T, name string, labels map[string]string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metric:
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if labels[l.GetName()] != l.GetValue() {
+					continue metric
+				}
+			}
+			if m.GetCounter() != nil {
+				return m.GetCounter().GetValue()
+			}
+			return float64(m.GetHistogram().GetSampleCount())
+		}
+	}
+	return 0
+}
+
+func TestObserveRequest(t *testing.T) {
+	// Metrics are registered globally; compare with values before the test
+	// so that the test can be repeated.
+	labels := map[string]string{"app": "test", "site": "main", "status": "200"}
+	siteLabels := map[string]string{"app": "test", "site": "main"}
+	requests := counterValue(t, "pageship_http_requests_total", labels)
+	durations := counterValue(t, "pageship_http_request_duration_seconds", labels)
+	bytes := counterValue(t, "pageship_http_response_bytes_total", siteLabels)
+
+	metrics.ObserveRequest("test", "main", 0, 10, time.Millisecond)
+	metrics.ObserveRequest("test", "main", http.StatusOK, 20, time.Millisecond)
+	metrics.ObserveRequest("test", "main", http.StatusNotFound, 5, time.Millisecond)
+
+	assert.Equal(t, requests+2, counterValue(t, "pageship_http_requests_total", labels))
+	assert.Equal(t, durations+2, counterValue(t, "pageship_http_request_duration_seconds", labels))
+	assert.Equal(t, bytes+35, counterValue(t, "pageship_http_response_bytes_total", siteLabels))
+}
+
+func TestCacheMetrics(t *testing.T) {
+	c, err := cache.NewCache("test", 10, time.Minute, func(ctx context.Context, id string) (string, error) {
+		return id, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hitLabels := map[string]string{"cache": "test", "result": "hit"}
+	missLabels := map[string]string{"cache": "test", "result": "miss"}
+	hits := counterValue(t, "pageship_cache_requests_total", hitLabels)
+	misses := counterValue(t, "pageship_cache_requests_total", missLabels)
+
+	ctx := context.Background()
+	c.Load(ctx, "a")
+	c.Load(ctx, "a")
+	c.Load(ctx, "b")
+
+	assert.Equal(t, hits+1, counterValue(t, "pageship_cache_requests_total", hitLabels))
+	assert.Equal(t, misses+2, counterValue(t, "pageship_cache_requests_total", missLabels))
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Server serves metrics at '/metrics' on a dedicated admin address, separated
+// from public listeners.
+type Server struct {
+	Logger *zap.Logger
+	Addr   string
+}
+
+func (s *Server) Run(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+
+	server := &http.Server{
+		Addr:              s.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	shutdown := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+		close(shutdown)
+	}()
+
+	s.Logger.Info("metrics server starting", zap.String("addr", s.Addr))
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.Logger.Error("failed to start metrics server", zap.Error(err))
+		return err
+	}
+	<-shutdown
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditAppCreate        AuditAction = "app.create"
+	AuditAppConfigUpdate  AuditAction = "app.config.update"
+	AuditSiteCreate       AuditAction = "site.create"
+	AuditSiteUpdate       AuditAction = "site.update"
+	AuditDeploymentCreate AuditAction = "deployment.create"
+	AuditDeploymentUpload AuditAction = "deployment.upload"
+	AuditDeploymentRename AuditAction = "deployment.rename"
+	AuditDeploymentDelete AuditAction = "deployment.delete"
+	AuditDomainCreate     AuditAction = "domain.create"
+	AuditDomainDelete     AuditAction = "domain.delete"
+	AuditDomainVerify     AuditAction = "domain.verify"
+	AuditDomainCertSet    AuditAction = "domain.cert.set"
+	AuditDomainCertDelete AuditAction = "domain.cert.delete"
+	AuditUserLogin        AuditAction = "user.login"
+
+	AuditWebhookSecretRotate AuditAction = "webhook.secret.rotate"
+)
+
+// AuditEvent records a mutation performed through controller; it is never
+// updated or deleted once recorded.
+type AuditEvent struct {
+	ID        string    `json:"id" db:"id"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	// AppID is nil for events not belonging to an app (e.g. login).
+	AppID          *string      `json:"appID" db:"app_id"`
+	ActorID        string       `json:"actorID" db:"actor_id"`
+	ActorName      string       `json:"actorName" db:"actor_name"`
+	CredentialID   CredentialID `json:"credentialID" db:"credential_id"`
+	CredentialRule string       `json:"credentialRule" db:"credential_rule"`
+	Action         AuditAction  `json:"action" db:"action"`
+	Target         string       `json:"target" db:"target"`
+	Diff           *AuditDiff   `json:"diff,omitempty" db:"diff"`
+	RequestID      string       `json:"requestID" db:"request_id"`
+}
+
+func NewAuditEvent(now time.Time, appID *string, action AuditAction, target string) *AuditEvent {
+	return &AuditEvent{
+		ID:        newID("audit"),
+		CreatedAt: now,
+		AppID:     appID,
+		Action:    action,
+		Target:    target,
+	}
+}
+
+// AuditDiff is the state of target before & after the mutation, in JSON.
+type AuditDiff struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+func NewAuditDiff(before any, after any) (*AuditDiff, error) {
+	diff := &AuditDiff{}
+	var err error
+	if before != nil {
+		if diff.Before, err = json.Marshal(before); err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		if diff.After, err = json.Marshal(after); err != nil {
+			return nil, err
+		}
+	}
+	return diff, nil
+}
+
+func (d *AuditDiff) Scan(val any) error {
+	switch v := val.(type) {
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	default:
+		return fmt.Errorf("unsupported type: %T", v)
+	}
+}
+func (d *AuditDiff) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
@@ -0,0 +1,29 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/oursky/pageship/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditDiff(t *testing.T) {
+	diff, err := models.NewAuditDiff(nil, map[string]any{"deploymentID": "deployment_1"})
+	assert.NoError(t, err)
+	assert.Nil(t, diff.Before)
+	assert.JSONEq(t, `{"deploymentID":"deployment_1"}`, string(diff.After))
+
+	value, err := diff.Value()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"after":{"deploymentID":"deployment_1"}}`, string(value.([]byte)))
+
+	var scanned models.AuditDiff
+	assert.NoError(t, scanned.Scan(string(value.([]byte))))
+	assert.Equal(t, *diff, scanned)
+
+	scanned = models.AuditDiff{}
+	assert.NoError(t, scanned.Scan(value))
+	assert.Equal(t, *diff, scanned)
+
+	assert.Error(t, scanned.Scan(42))
+}
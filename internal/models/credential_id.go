@@ -1,6 +1,7 @@
 package models
 
 import (
+	"crypto/x509"
 	"net/netip"
 	"strings"
 
@@ -14,6 +15,7 @@ const (
 	CredentialIDKindGitHubUser          CredentialIDKind = "github"
 	CredentialIDGitHubRepositoryActions CredentialIDKind = "github-repo-actions"
 	CredentialIDIP                      CredentialIDKind = "ip"
+	CredentialIDClientCert              CredentialIDKind = "cert"
 )
 
 type CredentialID string
@@ -33,6 +35,31 @@ func CredentialIP(ip string) CredentialID {
 	return CredentialID(string(CredentialIDIP) + ":" + ip)
 }
 
+// CredentialClientCert makes credential ID of a client certificate identity,
+// in form of '<attribute>=<value>' (e.g. 'CN=deploy-bot').
+func CredentialClientCert(identity string) CredentialID {
+	return CredentialID(string(CredentialIDClientCert) + ":" + identity)
+}
+
+// ClientCertCredentials returns credential IDs of the subject common name &
+// subject alternative names of a client certificate.
+func ClientCertCredentials(cert *x509.Certificate) []CredentialID {
+	var ids []CredentialID
+	if cert.Subject.CommonName != "" {
+		ids = append(ids, CredentialClientCert("CN="+cert.Subject.CommonName))
+	}
+	for _, name := range cert.DNSNames {
+		ids = append(ids, CredentialClientCert("DNS="+name))
+	}
+	for _, email := range cert.EmailAddresses {
+		ids = append(ids, CredentialClientCert("EMAIL="+email))
+	}
+	for _, uri := range cert.URIs {
+		ids = append(ids, CredentialClientCert("URI="+uri.String()))
+	}
+	return ids
+}
+
 func (c CredentialID) Matches(r *config.ACLSubjectRule) bool {
 	kind, data, found := strings.Cut(string(c), ":")
 	if !found {
@@ -77,6 +104,9 @@ func (c CredentialID) Matches(r *config.ACLSubjectRule) bool {
 
 		return cidr.Contains(addr)
 
+	case CredentialIDClientCert:
+		return r.ClientCert != "" && r.ClientCert == data
+
 	default:
 		return false
 	}
@@ -1,6 +1,9 @@
 package models_test
 
 import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
 	"testing"
 
 	"github.com/oursky/pageship/internal/config"
@@ -63,3 +66,36 @@ func TestGitHubActionsCredentials(t *testing.T) {
 		models.CredentialGitHubRepositoryActions("Oursky/Example"),
 	))
 }
+
+func TestClientCertCredentials(t *testing.T) {
+	assert.True(t, matchRule(
+		&config.ACLSubjectRule{ClientCert: "CN=deploy-bot"},
+		models.CredentialClientCert("CN=deploy-bot"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{ClientCert: "CN=deploy-bot"},
+		models.CredentialClientCert("CN=other-bot"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{ClientCert: "CN=deploy-bot"},
+		models.CredentialClientCert("DNS=deploy-bot"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{GitHubUser: "deploy-bot"},
+		models.CredentialClientCert("CN=deploy-bot"),
+	))
+
+	uri, _ := url.Parse("spiffe://example.com/deploy-bot")
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "deploy-bot"},
+		DNSNames:       []string{"bot.example.com"},
+		EmailAddresses: []string{"bot@example.com"},
+		URIs:           []*url.URL{uri},
+	}
+	assert.Equal(t, []models.CredentialID{
+		"cert:CN=deploy-bot",
+		"cert:DNS=bot.example.com",
+		"cert:EMAIL=bot@example.com",
+		"cert:URI=spiffe://example.com/deploy-bot",
+	}, models.ClientCertCredentials(cert))
+}
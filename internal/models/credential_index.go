@@ -18,7 +18,8 @@ func MakeCredentialIDIndexKeys(id CredentialID) []CredentialIndexKey {
 
 	switch CredentialIDKind(kind) {
 	case CredentialIDKindUserID,
-		CredentialIDKindGitHubUser:
+		CredentialIDKindGitHubUser,
+		CredentialIDClientCert:
 		return []CredentialIndexKey{CredentialIndexKey(id)}
 
 	case CredentialIDGitHubRepositoryActions:
@@ -91,6 +92,8 @@ func MakeCredentialRuleIndexKeys(r *config.ACLSubjectRule) []CredentialIndexKey
 
 		keys := makeIPKeys(addr, bits)
 		return []CredentialIndexKey{keys[len(keys)-1]} // Use longest key (i.e. last key)
+	case r.ClientCert != "":
+		return MakeCredentialIDIndexKeys(CredentialClientCert(r.ClientCert))
 	}
 	return nil
 }
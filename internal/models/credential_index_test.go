@@ -154,3 +154,14 @@ func FuzzIPCredentialsIndex(f *testing.F) {
 			models.MakeCredentialRuleIndexKeys(rule), models.MakeCredentialIDIndexKeys(cred))
 	})
 }
+
+func TestClientCertCredentialsIndex(t *testing.T) {
+	assert.True(t, matchIndex(
+		&config.ACLSubjectRule{ClientCert: "CN=deploy-bot"},
+		models.CredentialClientCert("CN=deploy-bot"),
+	))
+	assert.False(t, matchIndex(
+		&config.ACLSubjectRule{ClientCert: "CN=deploy-bot"},
+		models.CredentialClientCert("CN=other-bot"),
+	))
+}
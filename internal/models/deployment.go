@@ -47,6 +47,18 @@ func NewDeployment(
 	}
 }
 
+// StagingKey returns the storage key of a file uploaded directly to storage;
+// it is copied to its final key once verified on finalize.
+func (d *Deployment) StagingKey(path string) string {
+	return StagingKeyPrefix(d.StorageKeyPrefix) + path
+}
+
+// StagingKeyPrefix returns the storage key prefix of files uploaded directly
+// to storage, under the storage key prefix of deployment.
+func StagingKeyPrefix(storageKeyPrefix string) string {
+	return storageKeyPrefix + "/.staging"
+}
+
 func (d *Deployment) IsExpired(now time.Time) bool {
 	return d.ExpireAt != nil && !now.Before(*d.ExpireAt)
 }
@@ -66,6 +78,18 @@ func (d *Deployment) CheckAlive(now time.Time) error {
 type DeploymentMetadata struct {
 	Files  []FileEntry       `json:"files,omitempty"`
 	Config config.SiteConfig `json:"config"`
+	// HashAlgorithm is the algorithm of file hashes; empty for default
+	// algorithm (SHA3-256).
+	HashAlgorithm string `json:"hashAlgorithm,omitempty"`
+	// Signature is the signature of deployment manifest; nil if unsigned.
+	Signature *DeploymentSignature `json:"signature,omitempty"`
+}
+
+type DeploymentSignature struct {
+	// PublicKey is the signing SSH public key, in authorized_keys format.
+	PublicKey string `json:"publicKey"`
+	// Signature is the base64-encoded SSH signature of manifest digest.
+	Signature string `json:"signature"`
 }
 
 func (m *DeploymentMetadata) Scan(val any) error {
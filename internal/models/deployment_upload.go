@@ -0,0 +1,33 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type DeploymentUploadChunk struct {
+	ID           string    `json:"id" db:"id"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+	DeploymentID string    `json:"deploymentID" db:"deployment_id"`
+	Offset       int64     `json:"offset" db:"byte_offset"`
+	Size         int64     `json:"size" db:"size"`
+	// Total is the total upload size declared by client; nil if unknown.
+	Total *int64 `json:"total" db:"total_size"`
+}
+
+func NewDeploymentUploadChunk(now time.Time, deploymentID string, offset int64, size int64, total *int64) *DeploymentUploadChunk {
+	return &DeploymentUploadChunk{
+		ID:           newID("chunk"),
+		CreatedAt:    now,
+		DeploymentID: deploymentID,
+		Offset:       offset,
+		Size:         size,
+		Total:        total,
+	}
+}
+
+// StorageKey returns the storage key of staged chunk, under the storage key
+// prefix of deployment.
+func (c *DeploymentUploadChunk) StorageKey(storageKeyPrefix string) string {
+	return fmt.Sprintf("%s/.upload/%s", storageKeyPrefix, c.ID)
+}
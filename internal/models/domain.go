@@ -2,6 +2,10 @@ package models
 
 import "time"
 
+// DomainSiteWildcard is the site name of wildcard domains; the site is
+// determined by the subdomain label of requested host.
+const DomainSiteWildcard = "*"
+
 type Domain struct {
 	ID        string     `json:"id" db:"id"`
 	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
@@ -10,9 +14,10 @@ type Domain struct {
 	Domain    string     `json:"domain" db:"domain"`
 	AppID     string     `json:"appID" db:"app_id"`
 	SiteName  string     `json:"siteName" db:"site_name"`
+	AliasOf   *string    `json:"aliasOf" db:"alias_of"`
 }
 
-func NewDomain(now time.Time, domain string, appID string, siteName string) *Domain {
+func NewDomain(now time.Time, domain string, appID string, siteName string, aliasOf *string) *Domain {
 	return &Domain{
 		ID:        newID("domain"),
 		CreatedAt: now,
@@ -21,5 +26,6 @@ func NewDomain(now time.Time, domain string, appID string, siteName string) *Dom
 		Domain:    domain,
 		AppID:     appID,
 		SiteName:  siteName,
+		AliasOf:   aliasOf,
 	}
 }
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+type DomainVerification struct {
+	ID            string     `json:"id" db:"id"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updatedAt" db:"updated_at"`
+	DeletedAt     *time.Time `json:"deletedAt" db:"deleted_at"`
+	Domain        string     `json:"domain" db:"domain"`
+	AppID         string     `json:"appID" db:"app_id"`
+	Value         string     `json:"value" db:"value"`
+	VerifiedAt    *time.Time `json:"verifiedAt" db:"verified_at"`
+	LastCheckedAt *time.Time `json:"lastCheckedAt" db:"last_checked_at"`
+}
+
+func NewDomainVerification(now time.Time, domain string, appID string) *DomainVerification {
+	return &DomainVerification{
+		ID:            newID("verification"),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		DeletedAt:     nil,
+		Domain:        domain,
+		AppID:         appID,
+		Value:         RandomID(16),
+		VerifiedAt:    nil,
+		LastCheckedAt: nil,
+	}
+}
+
+func (v *DomainVerification) IsVerified() bool {
+	return v.VerifiedAt != nil
+}
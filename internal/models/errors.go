@@ -13,14 +13,27 @@ var ErrDeploymentUsedName = errors.New("used deployment name")
 var ErrDeploymentNotUploaded = errors.New("deployment is not uploaded")
 var ErrDeploymentAlreadyUploaded = errors.New("deployment is already uploaded")
 var ErrDeploymentExpired = errors.New("deployment expired")
+var ErrDeploymentUploadOffset = errors.New("unexpected deployment upload offset")
+var ErrDeploymentNotSigned = errors.New("deployment is not signed")
+var ErrDeploymentUntrustedSignature = errors.New("deployment is not signed by trusted key")
+var ErrDeploymentActive = errors.New("deployment is assigned to site")
+var ErrDeploymentNameIsSite = errors.New("deployment name conflicts with site name")
 
 var ErrUndefinedDomain = errors.New("undefined domain")
 var ErrDomainNotFound = errors.New("domain not found")
 var ErrDomainUsedName = errors.New("used domain name")
+var ErrDomainNotVerified = errors.New("domain ownership is not verified")
+var ErrDomainVerificationNotFound = errors.New("domain verification not found")
+var ErrTLSNotEnabled = errors.New("TLS is not enabled on server")
+var ErrInvalidCertificate = errors.New("invalid certificate")
 
 var ErrUserNotFound = errors.New("user not found")
 var ErrAccessDenied = errors.New("access denied")
 var ErrInvalidCredentials = errors.New("invalid credentials")
 
+// ErrorCodeDomainNotVerified is the machine-readable code of API error
+// responses for ErrDomainNotVerified.
+const ErrorCodeDomainNotVerified = "domain_not_verified"
+
 var ErrCertificateDataNotFound = errors.New("cert data not found")
 var ErrCertificateDataLocked = errors.New("cert locked")
@@ -14,6 +14,9 @@ type FileEntry struct {
 	Size        int64  `json:"size" validate:"required,gte=0"`
 	Hash        string `json:"hash" validate:"required,max=100"`
 	ContentType string `json:"contentType" validate:"required,max=100"`
+	// MD5 is the base64-encoded MD5 checksum of content, for verification by
+	// storage in direct uploads; empty if unknown.
+	MD5 string `json:"md5,omitempty" validate:"max=100"`
 }
 
 func DetectContentType(fileName string, initialBytes []byte) string {
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// SiteAnalyticsDateLayout is the layout of dates of analytics counters, in
+// UTC.
+const SiteAnalyticsDateLayout = "2006-01-02"
+
+type SiteAnalyticsKind string
+
+const (
+	SiteAnalyticsPath     SiteAnalyticsKind = "path"
+	SiteAnalyticsReferrer SiteAnalyticsKind = "referrer"
+	SiteAnalyticsStatus   SiteAnalyticsKind = "status"
+	// SiteAnalyticsVisitor counts page views by anonymized visitor hash.
+	SiteAnalyticsVisitor SiteAnalyticsKind = "visitor"
+)
+
+type SiteAnalyticsCount struct {
+	AppID    string            `db:"app_id"`
+	SiteName string            `db:"site_name"`
+	Date     string            `db:"date"`
+	Kind     SiteAnalyticsKind `db:"kind"`
+	Key      string            `db:"key"`
+	Count    int64             `db:"count"`
+}
+
+type SiteAnalyticsDaily struct {
+	Date      string `json:"date" db:"date"`
+	PageViews int64  `json:"pageViews" db:"page_views"`
+	Visitors  int64  `json:"visitors" db:"visitors"`
+}
+
+type SiteAnalyticsEntry struct {
+	Key   string `json:"key" db:"key"`
+	Count int64  `json:"count" db:"count"`
+}
+
+func SiteAnalyticsDate(t time.Time) string {
+	return t.UTC().Format(SiteAnalyticsDateLayout)
+}
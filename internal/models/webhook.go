@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/oursky/pageship/internal/config"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is a queued delivery of webhook event to an endpoint; it is
+// kept after delivery as delivery log.
+type WebhookDelivery struct {
+	ID        string              `json:"id" db:"id"`
+	CreatedAt time.Time           `json:"createdAt" db:"created_at"`
+	AppID     string              `json:"appID" db:"app_id"`
+	URL       string              `json:"url" db:"url"`
+	Event     config.WebhookEvent `json:"event" db:"event"`
+	// Payload is the request body; it is sent as is to keep signature stable
+	// across attempts.
+	Payload        string                `json:"payload" db:"payload"`
+	Status         WebhookDeliveryStatus `json:"status" db:"status"`
+	Attempts       int                   `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time             `json:"nextAttemptAt" db:"next_attempt_at"`
+	LastAttemptAt  *time.Time            `json:"lastAttemptAt" db:"last_attempt_at"`
+	ResponseStatus *int                  `json:"responseStatus" db:"response_status"`
+	LastError      *string               `json:"lastError" db:"last_error"`
+}
+
+func NewWebhookDelivery(now time.Time, appID string, url string, event config.WebhookEvent, payload string) *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:            newID("delivery"),
+		CreatedAt:     now,
+		AppID:         appID,
+		URL:           url,
+		Event:         event,
+		Payload:       payload,
+		Status:        WebhookDeliveryPending,
+		Attempts:      0,
+		NextAttemptAt: now,
+	}
+}
+
+func NewWebhookEventID() string {
+	return newID("event")
+}
@@ -32,7 +32,7 @@ func NewKeys(ctx context.Context) (*Keys, error) {
 		client: &http.Client{},
 	}
 
-	cache, err := cache.NewCache(100, time.Hour, keys.load)
+	cache, err := cache.NewCache("oidc-keys", 100, time.Hour, keys.load)
 	if err != nil {
 		return nil, err
 	}
@@ -42,11 +42,11 @@ func NewKeys(ctx context.Context) (*Keys, error) {
 }
 
 func (k *Keys) Get(issuer string) (*Key, error) {
-	return k.cache.Load(issuer)
+	return k.cache.Load(k.ctx, issuer)
 }
 
-func (k *Keys) load(issuer string) (*Key, error) {
-	ctx, cancel := context.WithTimeout(k.ctx, time.Second*10)
+func (k *Keys) load(ctx context.Context, issuer string) (*Key, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
 	defer cancel()
 
 	u, err := url.Parse(issuer)
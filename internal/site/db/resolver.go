@@ -11,6 +11,8 @@ import (
 	"github.com/oursky/pageship/internal/models"
 	"github.com/oursky/pageship/internal/site"
 	"github.com/oursky/pageship/internal/storage"
+	"github.com/oursky/pageship/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Resolver struct {
@@ -70,7 +72,10 @@ func (r *Resolver) resolveDeployment(
 
 func (h *Resolver) IsWildcard() bool { return false }
 
-func (r *Resolver) Resolve(ctx context.Context, matchedID string) (*site.Descriptor, error) {
+func (r *Resolver) Resolve(ctx context.Context, matchedID string) (_ *site.Descriptor, err error) {
+	ctx, span := tracing.Start(ctx, "site.db.Resolve", attribute.String("pageship.matched_id", matchedID))
+	defer func() { tracing.End(span, err) }()
+
 	appID, siteName := r.HostIDScheme.Split(matchedID)
 
 	app, err := r.DB.GetApp(ctx, appID)
@@ -114,6 +119,8 @@ func (r *Resolver) Resolve(ctx context.Context, matchedID string) (*site.Descrip
 	id := strings.Join([]string{deployment.AppID, siteName, deployment.ID}, "/")
 	desc := &site.Descriptor{
 		ID:     id,
+		App:    deployment.AppID,
+		Site:   siteName,
 		Config: &config,
 		Domain: domainName,
 		FS:     newStorageFS(r.Storage, deployment),
@@ -23,7 +23,10 @@ type FS interface {
 }
 
 type Descriptor struct {
-	ID     string
+	ID string
+	// App & Site identify the site in metrics; empty if not applicable.
+	App    string
+	Site   string
 	Domain string
 	Config *config.SiteConfig
 	FS     FS
@@ -55,6 +55,7 @@ func (h *resolverAdhoc) Resolve(ctx context.Context, matchedID string) (*site.De
 
 	return &site.Descriptor{
 		ID:     matchedID,
+		Site:   matchedID,
 		Config: &config.Site,
 		FS:     siteFS{fs: fsys},
 	}, nil
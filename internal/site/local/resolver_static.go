@@ -45,6 +45,7 @@ func (h *resolverStatic) Resolve(ctx context.Context, matchedID string) (*site.D
 
 	return &site.Descriptor{
 		ID:     matchedID,
+		Site:   matchedID,
 		Domain: entry.Domain,
 		Config: &config.Site,
 		FS:     siteFS{fs: fsys},
@@ -61,6 +61,17 @@ func (k *UserKey) Signers() ([]ssh.Signer, error) {
 		return nil, err
 	}
 
+	key, err := LoadKeyFile(file, k.promptPassphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return []ssh.Signer{key}, nil
+}
+
+// LoadKeyFile loads the private key file; passphrase is prompted if the key
+// is encrypted.
+func LoadKeyFile(file string, promptPassphrase func() ([]byte, error)) (ssh.Signer, error) {
 	pem, err := os.ReadFile(file)
 	if err != nil {
 		return nil, err
@@ -68,7 +79,7 @@ func (k *UserKey) Signers() ([]ssh.Signer, error) {
 
 	key, err := ssh.ParsePrivateKey(pem)
 	if errors.As(err, new(*ssh.PassphraseMissingError)) {
-		passphrase, perr := k.promptPassphrase()
+		passphrase, perr := promptPassphrase()
 		if perr != nil {
 			return nil, perr
 		}
@@ -78,7 +89,7 @@ func (k *UserKey) Signers() ([]ssh.Signer, error) {
 		return nil, err
 	}
 
-	return []ssh.Signer{key}, nil
+	return key, nil
 }
 
 func homeSSHKeyFile() (string, error) {
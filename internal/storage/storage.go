@@ -2,16 +2,30 @@ package storage
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"io"
+	"net/http"
+	"time"
 
+	gcs "cloud.google.com/go/storage"
+	s3v2 "github.com/aws/aws-sdk-go-v2/service/s3"
+	s3v1 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/oursky/pageship/internal/metrics"
+	"github.com/oursky/pageship/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"gocloud.dev/blob"
 	_ "gocloud.dev/blob/azureblob"
 	_ "gocloud.dev/blob/fileblob"
 	_ "gocloud.dev/blob/gcsblob"
 	_ "gocloud.dev/blob/memblob"
 	_ "gocloud.dev/blob/s3blob"
+	"gocloud.dev/gcerrors"
 )
 
+var ErrSignedURLUnsupported = errors.New("signed URL is not supported by storage")
+var ErrNotFound = errors.New("object not found")
+
 type Storage struct {
 	bucket *blob.Bucket
 }
@@ -29,6 +43,9 @@ func (s *Storage) Close() error {
 }
 
 func (s *Storage) Upload(ctx context.Context, key string, r io.Reader) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.Upload", attribute.String("storage.key", key))
+	defer func() { tracing.End(span, err) }()
+
 	writer, err := s.bucket.NewWriter(ctx, key, nil)
 	if err != nil {
 		return err
@@ -44,11 +61,135 @@ func (s *Storage) Upload(ctx context.Context, key string, r io.Reader) (err erro
 	return
 }
 
-func (s *Storage) OpenRead(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+func (s *Storage) OpenRead(ctx context.Context, key string) (_ io.ReadSeekCloser, err error) {
+	ctx, span := tracing.Start(ctx, "storage.OpenRead", attribute.String("storage.key", key))
+	defer func() { tracing.End(span, err) }()
+
+	start := time.Now()
 	reader, err := s.bucket.NewReader(ctx, key, nil)
-	if err != nil {
+	metrics.ObserveStorageRead(time.Since(start))
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		return nil, ErrNotFound
+	} else if err != nil {
 		return nil, err
 	}
 
 	return reader, nil
 }
+
+func (s *Storage) Delete(ctx context.Context, key string) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.Delete", attribute.String("storage.key", key))
+	defer func() { tracing.End(span, err) }()
+
+	err = s.bucket.Delete(ctx, key)
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+// SignedUploadURL returns a presigned URL for uploading object with PUT
+// request directly to the storage. If contentMD5 is provided, the URL requires
+// a matching Content-MD5 header where the storage supports it (S3 and GCS).
+func (s *Storage) SignedUploadURL(ctx context.Context, key string, expiry time.Duration, contentMD5 []byte) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "storage.SignedUploadURL", attribute.String("storage.key", key))
+	defer func() { tracing.End(span, err) }()
+
+	opts := &blob.SignedURLOptions{
+		Method: http.MethodPut,
+		Expiry: expiry,
+	}
+	if len(contentMD5) > 0 {
+		md5 := base64.StdEncoding.EncodeToString(contentMD5)
+		opts.BeforeSign = func(as func(any) bool) error {
+			var inputV1 *s3v1.PutObjectInput
+			var inputV2 *s3v2.PutObjectInput
+			var gcsOpts *gcs.SignedURLOptions
+			switch {
+			case as(&inputV1):
+				inputV1.ContentMD5 = &md5
+			case as(&inputV2):
+				inputV2.ContentMD5 = &md5
+			case as(&gcsOpts):
+				gcsOpts.MD5 = md5
+			}
+			return nil
+		}
+	}
+
+	url, err := s.bucket.SignedURL(ctx, key, opts)
+	if gcerrors.Code(err) == gcerrors.Unimplemented {
+		return "", ErrSignedURLUnsupported
+	} else if err != nil {
+		return "", err
+	}
+
+	return url, nil
+}
+
+// Copy copies the object to another key within the storage, without
+// transferring its content through the caller.
+func (s *Storage) Copy(ctx context.Context, dstKey string, srcKey string) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.Copy",
+		attribute.String("storage.key", dstKey),
+		attribute.String("storage.source_key", srcKey))
+	defer func() { tracing.End(span, err) }()
+
+	err = s.bucket.Copy(ctx, dstKey, srcKey, nil)
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Attributes contains the stored attributes of an object.
+type Attributes struct {
+	Size int64
+	// MD5 is the MD5 checksum of the object content; nil if unavailable.
+	MD5 []byte
+}
+
+// Attributes returns the attributes of the object, without reading its
+// content.
+func (s *Storage) Attributes(ctx context.Context, key string) (_ *Attributes, err error) {
+	ctx, span := tracing.Start(ctx, "storage.Attributes", attribute.String("storage.key", key))
+	defer func() { tracing.End(span, err) }()
+
+	attrs, err := s.bucket.Attributes(ctx, key)
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &Attributes{Size: attrs.Size, MD5: attrs.MD5}, nil
+}
+
+// List returns keys of objects with the prefix, in lexicographical order.
+func (s *Storage) List(ctx context.Context, prefix string) (_ []string, err error) {
+	ctx, span := tracing.Start(ctx, "storage.List", attribute.String("storage.prefix", prefix))
+	defer func() { tracing.End(span, err) }()
+
+	var keys []string
+	iter := s.bucket.List(&blob.ListOptions{Prefix: prefix})
+	for {
+		obj, err := iter.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if !obj.IsDir {
+			keys = append(keys, obj.Key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (s *Storage) Exists(ctx context.Context, key string) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "storage.Exists", attribute.String("storage.key", key))
+	defer func() { tracing.End(span, err) }()
+
+	return s.bucket.Exists(ctx, key)
+}
@@ -0,0 +1,124 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/oursky/pageship"
+
+var enabled atomic.Bool
+
+type Config struct {
+	// Endpoint is the OTLP/HTTP endpoint URL (e.g. 'http://localhost:4318');
+	// tracing is disabled if empty.
+	Endpoint    string
+	ServiceName string
+	// SampleRatio is the ratio of traces sampled, unless sampled by parent.
+	SampleRatio float64
+	Version     string
+}
+
+// Setup installs the global tracer provider exporting spans over OTLP. The
+// returned function flushes pending spans and shuts down the provider.
+// Without endpoint, the default no-op provider is retained.
+func Setup(ctx context.Context, conf Config) (shutdown func(context.Context) error, err error) {
+	if conf.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(conf.Endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(conf.ServiceName),
+		semconv.ServiceVersion(conf.Version),
+	))
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	enabled.Store(true)
+
+	return provider.Shutdown, nil
+}
+
+// Enabled reports whether spans are exported; instrumentation with overhead
+// should be skipped if disabled.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Start starts a span; it is no-op if tracing is disabled.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends the span, recording the error if any.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Annotate sets attributes on the current span.
+func Annotate(ctx context.Context, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
+
+// Handler traces HTTP requests served by the handler if tracing is enabled.
+func Handler(handler http.Handler, operation string) http.Handler {
+	if !Enabled() {
+		return handler
+	}
+	return otelhttp.NewHandler(handler, operation,
+		otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+			return operation + " " + r.Method
+		}),
+	)
+}
+
+// OpenDB opens the database, tracing queries if tracing is enabled.
+func OpenDB(driverName string, dsn string, system attribute.KeyValue) (*sql.DB, error) {
+	if !Enabled() {
+		return sql.Open(driverName, dsn)
+	}
+	return otelsql.Open(driverName, dsn,
+		otelsql.WithAttributes(system),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
+}
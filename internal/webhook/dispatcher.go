@@ -0,0 +1,253 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+	apptime "github.com/oursky/pageship/internal/time"
+	"go.uber.org/zap"
+)
+
+const (
+	pollInterval  = 5 * time.Second
+	pruneInterval = time.Hour
+	batchSize     = 20
+
+	requestTimeout = 10 * time.Second
+	// leaseDuration is the time a claimed delivery is hidden from other
+	// dispatchers; it must exceed the request timeout.
+	leaseDuration = time.Minute
+
+	minBackoff = 30 * time.Second
+	maxBackoff = time.Hour
+
+	maxErrorLength = 500
+)
+
+var ErrPrivateNetwork = errors.New("webhook to private network address is not allowed")
+
+// NewSecret generates a random webhook signing secret.
+func NewSecret() string {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Dispatcher sends queued webhook deliveries, and retries failed deliveries
+// with exponential backoff until MaxAttempts is reached. Deliveries are
+// claimed before sending, so multiple dispatchers may share the same queue.
+type Dispatcher struct {
+	Logger      *zap.Logger
+	Clock       apptime.Clock
+	DB          db.DB
+	MaxAttempts int
+	// Retention is the duration to keep finished deliveries in delivery log;
+	// kept forever if zero.
+	Retention time.Duration
+	// AllowPrivateNetwork allows delivering to private network addresses
+	// (e.g. loopback, RFC 1918), which is denied by default.
+	AllowPrivateNetwork bool
+
+	client *http.Client
+}
+
+func (d *Dispatcher) clock() apptime.Clock {
+	if d.Clock == nil {
+		return apptime.SystemClock
+	}
+	return d.Clock
+}
+
+func (d *Dispatcher) httpClient() *http.Client {
+	if d.client != nil {
+		return d.client
+	}
+
+	dialer := &net.Dialer{Timeout: requestTimeout}
+	if !d.AllowPrivateNetwork {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+				return ErrPrivateNetwork
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	d.client = &http.Client{
+		Transport: transport,
+		Timeout:   requestTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return d.client
+}
+
+func (d *Dispatcher) Run(ctx context.Context) error {
+	var lastPrune time.Time
+	for {
+		if err := d.Dispatch(ctx); err != nil {
+			d.Logger.Error("failed to dispatch webhooks", zap.Error(err))
+		}
+
+		now := d.clock().Now()
+		if d.Retention > 0 && now.Sub(lastPrune) >= pruneInterval {
+			lastPrune = now
+			if err := d.Prune(ctx); err != nil {
+				d.Logger.Error("failed to prune webhook deliveries", zap.Error(err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-d.clock().After(pollInterval):
+		}
+	}
+}
+
+// Dispatch sends due deliveries until none is left.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	for {
+		now := d.clock().Now().UTC()
+		deliveries, err := d.DB.ListDueWebhookDeliveries(ctx, now, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			ok, err := d.DB.ClaimWebhookDelivery(ctx, delivery.ID, now, now.Add(leaseDuration))
+			if err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+
+			if err := d.deliver(ctx, delivery); err != nil {
+				return err
+			}
+		}
+
+		if len(deliveries) < batchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// Prune deletes finished deliveries older than retention.
+func (d *Dispatcher) Prune(ctx context.Context) error {
+	before := d.clock().Now().UTC().Add(-d.Retention)
+	n, err := d.DB.DeleteWebhookDeliveries(ctx, before)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		d.Logger.Info("deleted webhook deliveries", zap.Int64("n", n))
+	}
+	return nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	secret, err := d.DB.GetOrCreateWebhookSecret(ctx, delivery.AppID, d.clock().Now().UTC(), NewSecret())
+	if err != nil {
+		return err
+	}
+
+	status, sendErr := d.send(ctx, delivery, secret)
+
+	now := d.clock().Now().UTC()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = nil
+	delivery.LastError = nil
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+	case delivery.Attempts >= d.MaxAttempts:
+		delivery.Status = models.WebhookDeliveryFailed
+	default:
+		delivery.NextAttemptAt = now.Add(backoff(delivery.Attempts))
+	}
+	if sendErr != nil {
+		msg := sendErr.Error()
+		if len(msg) > maxErrorLength {
+			msg = msg[:maxErrorLength]
+		}
+		delivery.LastError = &msg
+
+		d.Logger.Warn("failed to deliver webhook",
+			zap.String("delivery", delivery.ID),
+			zap.String("app", delivery.AppID),
+			zap.Int("attempts", delivery.Attempts),
+			zap.Error(sendErr))
+	} else {
+		d.Logger.Debug("delivered webhook",
+			zap.String("delivery", delivery.ID),
+			zap.String("app", delivery.AppID))
+	}
+
+	return d.DB.UpdateWebhookDelivery(ctx, delivery)
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *models.WebhookDelivery, secret string) (int, error) {
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, "POST", delivery.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pageship-webhook")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderSignature, Sign(secret, payload))
+
+	resp, err := d.httpClient().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before next attempt after n failed attempts.
+func backoff(n int) time.Duration {
+	delay := minBackoff
+	for i := 1; i < n && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,167 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/db/dbtest"
+	"github.com/oursky/pageship/internal/models"
+	apptime "github.com/oursky/pageship/internal/time"
+	"github.com/oursky/pageship/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type mockDB struct {
+	db.Tx
+	secrets    map[string]string
+	deliveries []*models.WebhookDelivery
+}
+
+func (d *mockDB) GetOrCreateWebhookSecret(ctx context.Context, appID string, now time.Time, secret string) (string, error) {
+	if s, ok := d.secrets[appID]; ok {
+		return s, nil
+	}
+	d.secrets[appID] = secret
+	return secret, nil
+}
+
+func (d *mockDB) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	var result []*models.WebhookDelivery
+	for _, delivery := range d.deliveries {
+		if delivery.Status == models.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			copied := *delivery
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (d *mockDB) ClaimWebhookDelivery(ctx context.Context, id string, now time.Time, leaseUntil time.Time) (bool, error) {
+	for _, delivery := range d.deliveries {
+		if delivery.ID == id && delivery.Status == models.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			delivery.NextAttemptAt = leaseUntil
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (d *mockDB) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	for i, existing := range d.deliveries {
+		if existing.ID == delivery.ID {
+			d.deliveries[i] = delivery
+		}
+	}
+	return nil
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	var received []*http.Request
+	var bodies []string
+	statuses := []int{http.StatusInternalServerError, http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(statuses[0])
+		statuses = statuses[1:]
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := apptime.NewFakeClock(now)
+	database := &mockDB{
+		secrets: map[string]string{"test": "secret"},
+		deliveries: []*models.WebhookDelivery{
+			models.NewWebhookDelivery(now, "test", server.URL, config.WebhookEventSiteUpdated, `{"event":"site.updated"}`),
+		},
+	}
+	d := &webhook.Dispatcher{
+		Logger:              zap.NewNop(),
+		Clock:               clock,
+		DB:                  &dbtest.DB{Tx: database},
+		MaxAttempts:         3,
+		AllowPrivateNetwork: true,
+	}
+
+	assert.NoError(t, d.Dispatch(ctx))
+	delivery := database.deliveries[0]
+	assert.Len(t, received, 1)
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, *delivery.ResponseStatus)
+	assert.Equal(t, now.Add(30*time.Second), delivery.NextAttemptAt)
+
+	r := received[0]
+	assert.Equal(t, "site.updated", r.Header.Get(webhook.HeaderEvent))
+	assert.Equal(t, delivery.ID, r.Header.Get(webhook.HeaderDelivery))
+	assert.Equal(t, `{"event":"site.updated"}`, bodies[0])
+	assert.True(t, webhook.Verify("secret", []byte(bodies[0]), r.Header.Get(webhook.HeaderSignature)))
+	assert.False(t, webhook.Verify("other", []byte(bodies[0]), r.Header.Get(webhook.HeaderSignature)))
+
+	// Not retried before backoff
+	clock.Set(now.Add(10 * time.Second))
+	assert.NoError(t, d.Dispatch(ctx))
+	assert.Len(t, received, 1)
+
+	clock.Set(now.Add(30 * time.Second))
+	assert.NoError(t, d.Dispatch(ctx))
+	delivery = database.deliveries[0]
+	assert.Len(t, received, 2)
+	assert.Equal(t, models.WebhookDeliverySucceeded, delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Nil(t, delivery.LastError)
+	assert.Equal(t, bodies[0], bodies[1])
+	assert.Equal(t, received[0].Header.Get(webhook.HeaderSignature), received[1].Header.Get(webhook.HeaderSignature))
+}
+
+func TestDispatcherFailed(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := apptime.NewFakeClock(now)
+	database := &mockDB{
+		secrets: map[string]string{},
+		deliveries: []*models.WebhookDelivery{
+			models.NewWebhookDelivery(now, "test", server.URL, config.WebhookEventSiteUpdated, `{}`),
+		},
+	}
+	d := &webhook.Dispatcher{
+		Logger:      zap.NewNop(),
+		Clock:       clock,
+		DB:          &dbtest.DB{Tx: database},
+		MaxAttempts: 3,
+	}
+
+	// Loopback address of test server is denied.
+	delays := []time.Duration{30 * time.Second, time.Minute}
+	for _, delay := range delays {
+		assert.NoError(t, d.Dispatch(ctx))
+		delivery := database.deliveries[0]
+		assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+		assert.Equal(t, clock.Now().Add(delay), delivery.NextAttemptAt)
+		assert.Contains(t, *delivery.LastError, webhook.ErrPrivateNetwork.Error())
+		clock.Set(delivery.NextAttemptAt)
+	}
+
+	assert.NoError(t, d.Dispatch(ctx))
+	delivery := database.deliveries[0]
+	assert.Equal(t, models.WebhookDeliveryFailed, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Nil(t, delivery.ResponseStatus)
+	assert.NotEmpty(t, database.secrets["test"])
+}
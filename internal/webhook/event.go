@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/oursky/pageship/internal/config"
+)
+
+const (
+	HeaderEvent     = "X-Pageship-Event"
+	HeaderDelivery  = "X-Pageship-Delivery"
+	HeaderSignature = "X-Pageship-Signature-256"
+)
+
+// Event is the payload of webhook in JSON format.
+type Event struct {
+	ID        string              `json:"id"`
+	Event     config.WebhookEvent `json:"event"`
+	CreatedAt time.Time           `json:"createdAt"`
+	App       string              `json:"app"`
+	Data      any                 `json:"data"`
+
+	// Text is the human readable summary of event, used in chat formats.
+	Text string `json:"-"`
+}
+
+type DeploymentUploadedData struct {
+	Deployment Deployment `json:"deployment"`
+}
+
+type SiteUpdatedData struct {
+	Site Site `json:"site"`
+	// PreviousDeploymentName is empty if site had no deployment.
+	PreviousDeploymentName string `json:"previousDeploymentName,omitempty"`
+}
+
+type DomainActivatedData struct {
+	Domain Domain `json:"domain"`
+}
+
+type Deployment struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// URL is set if the deployment is accessible.
+	URL string `json:"url,omitempty"`
+}
+
+type Site struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// DeploymentName is empty if site has no deployment.
+	DeploymentName string `json:"deploymentName,omitempty"`
+}
+
+type Domain struct {
+	Domain string `json:"domain"`
+	Site   string `json:"site"`
+	URL    string `json:"url"`
+}
+
+// Payload returns the request body of the event in the format.
+func (e *Event) Payload(format config.WebhookFormat) ([]byte, error) {
+	switch format {
+	case config.WebhookFormatSlack:
+		return json.Marshal(struct {
+			Text string `json:"text"`
+		}{Text: e.Text})
+	default:
+		return json.Marshal(e)
+	}
+}
+
+// Sign returns signature of the payload, as HMAC-SHA256 in hex keyed by the
+// secret.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of payload; it is for use by receivers.
+func Verify(secret string, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, payload)), []byte(signature))
+}
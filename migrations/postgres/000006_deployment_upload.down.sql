@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE deployment_upload_staging;
+DROP TABLE deployment_upload_chunk;
+
+COMMIT;
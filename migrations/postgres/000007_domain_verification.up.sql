@@ -0,0 +1,21 @@
+BEGIN;
+
+CREATE TABLE domain_verification (
+    id                  TEXT NOT NULL PRIMARY KEY,
+    created_at          TIMESTAMPTZ NOT NULL,
+    updated_at          TIMESTAMPTZ NOT NULL,
+    deleted_at          TIMESTAMPTZ,
+    domain              TEXT NOT NULL,
+    app_id              TEXT NOT NULL REFERENCES app(id),
+    value               TEXT NOT NULL,
+    verified_at         TIMESTAMPTZ,
+    last_checked_at     TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX domain_verification_key ON domain_verification(domain, app_id) WHERE deleted_at IS NULL;
+
+-- Existing active domains are considered verified.
+INSERT INTO domain_verification (id, created_at, updated_at, deleted_at, domain, app_id, value, verified_at, last_checked_at)
+    SELECT 'verification_' || d.id, NOW(), NOW(), NULL, d.domain, d.app_id, md5(random()::text), NOW(), NULL
+        FROM domain_association d WHERE d.deleted_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE site_analytics_salt;
+DROP TABLE site_analytics;
+
+COMMIT;
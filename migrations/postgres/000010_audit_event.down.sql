@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE audit_event;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE webhook_delivery;
+DROP TABLE webhook_secret;
+
+COMMIT;
@@ -0,0 +1,2 @@
+DROP TABLE deployment_upload_staging;
+DROP TABLE deployment_upload_chunk;
@@ -0,0 +1,14 @@
+CREATE TABLE deployment_upload_chunk (
+    id                  TEXT NOT NULL PRIMARY KEY,
+    created_at          TIMESTAMP NOT NULL,
+    deployment_id       TEXT NOT NULL REFERENCES deployment(id),
+    byte_offset         INTEGER NOT NULL,
+    size                INTEGER NOT NULL,
+    total_size          INTEGER
+);
+CREATE UNIQUE INDEX deployment_upload_chunk_key ON deployment_upload_chunk(deployment_id, byte_offset);
+
+CREATE TABLE deployment_upload_staging (
+    deployment_id       TEXT NOT NULL PRIMARY KEY REFERENCES deployment(id),
+    created_at          TIMESTAMP NOT NULL
+);
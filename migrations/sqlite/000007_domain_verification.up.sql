@@ -0,0 +1,17 @@
+CREATE TABLE domain_verification (
+    id                  TEXT NOT NULL PRIMARY KEY,
+    created_at          TIMESTAMP NOT NULL,
+    updated_at          TIMESTAMP NOT NULL,
+    deleted_at          TIMESTAMP,
+    domain              TEXT NOT NULL,
+    app_id              TEXT NOT NULL REFERENCES app(id),
+    value               TEXT NOT NULL,
+    verified_at         TIMESTAMP,
+    last_checked_at     TIMESTAMP
+);
+CREATE UNIQUE INDEX domain_verification_key ON domain_verification(domain, app_id) WHERE deleted_at IS NULL;
+
+-- Existing active domains are considered verified.
+INSERT INTO domain_verification (id, created_at, updated_at, deleted_at, domain, app_id, value, verified_at, last_checked_at)
+    SELECT 'verification_' || d.id, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, NULL, d.domain, d.app_id, lower(hex(randomblob(16))), CURRENT_TIMESTAMP, NULL
+        FROM domain_association d WHERE d.deleted_at IS NULL;
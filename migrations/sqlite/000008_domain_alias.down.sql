@@ -0,0 +1,4 @@
+DELETE FROM domain_association WHERE alias_of IS NOT NULL;
+DROP INDEX domain_mapping;
+CREATE UNIQUE INDEX domain_mapping ON domain_association(app_id, site_name) WHERE deleted_at IS NULL;
+ALTER TABLE domain_association DROP COLUMN alias_of;
@@ -0,0 +1,3 @@
+ALTER TABLE domain_association ADD COLUMN alias_of TEXT;
+DROP INDEX domain_mapping;
+CREATE UNIQUE INDEX domain_mapping ON domain_association(app_id, site_name) WHERE deleted_at IS NULL AND alias_of IS NULL;
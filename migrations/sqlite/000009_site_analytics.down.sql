@@ -0,0 +1,2 @@
+DROP TABLE site_analytics_salt;
+DROP TABLE site_analytics;
@@ -0,0 +1,14 @@
+CREATE TABLE site_analytics (
+    app_id              TEXT NOT NULL,
+    site_name           TEXT NOT NULL,
+    date                TEXT NOT NULL,
+    kind                TEXT NOT NULL,
+    key                 TEXT NOT NULL,
+    count               INTEGER NOT NULL,
+    PRIMARY KEY (app_id, site_name, date, kind, key)
+);
+
+CREATE TABLE site_analytics_salt (
+    date                TEXT NOT NULL PRIMARY KEY,
+    salt                TEXT NOT NULL
+);
@@ -0,0 +1,15 @@
+CREATE TABLE audit_event (
+    id                  TEXT NOT NULL PRIMARY KEY,
+    created_at          TIMESTAMP NOT NULL,
+    app_id              TEXT,
+    actor_id            TEXT NOT NULL,
+    actor_name          TEXT NOT NULL,
+    credential_id       TEXT NOT NULL,
+    credential_rule     TEXT NOT NULL,
+    action              TEXT NOT NULL,
+    target              TEXT NOT NULL,
+    diff                TEXT,
+    request_id          TEXT NOT NULL
+);
+CREATE INDEX audit_event_order ON audit_event(created_at, id);
+CREATE INDEX audit_event_app ON audit_event(app_id, created_at, id);
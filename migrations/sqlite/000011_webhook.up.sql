@@ -0,0 +1,22 @@
+CREATE TABLE webhook_secret (
+    app_id              TEXT NOT NULL PRIMARY KEY REFERENCES app(id),
+    created_at          TIMESTAMP NOT NULL,
+    secret              TEXT NOT NULL
+);
+
+CREATE TABLE webhook_delivery (
+    id                  TEXT NOT NULL PRIMARY KEY,
+    created_at          TIMESTAMP NOT NULL,
+    app_id              TEXT NOT NULL REFERENCES app(id),
+    url                 TEXT NOT NULL,
+    event               TEXT NOT NULL,
+    payload             TEXT NOT NULL,
+    status              TEXT NOT NULL,
+    attempts            INTEGER NOT NULL,
+    next_attempt_at     TIMESTAMP NOT NULL,
+    last_attempt_at     TIMESTAMP,
+    response_status     INTEGER,
+    last_error          TEXT
+);
+CREATE INDEX webhook_delivery_queue ON webhook_delivery(status, next_attempt_at);
+CREATE INDEX webhook_delivery_app ON webhook_delivery(app_id, created_at);